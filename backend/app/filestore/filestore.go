@@ -0,0 +1,115 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid object key")
+
+type Object struct {
+	Key         string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// FileStore is the storage backend for uploaded files. Keys are slash
+// separated paths relative to the store root, e.g. "reviews/abc.jpg".
+type FileStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *Object, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	List(ctx context.Context, prefix string) ([]Object, error)
+}
+
+func CleanKey(key string) (string, error) {
+	key = strings.TrimPrefix(strings.TrimSpace(key), "/")
+	if key == "" || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned != key || cleaned == "." || strings.HasPrefix(cleaned, "../") || cleaned == ".." {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// FromEnv builds the store selected by FILESTORE_DRIVER ("local" or "s3").
+func FromEnv(uploadDir string) (FileStore, error) {
+	secret := getenv("FILESTORE_SIGNING_SECRET", getenv("JWT_SECRET", "change-me-secret"))
+	switch strings.ToLower(getenv("FILESTORE_DRIVER", "local")) {
+	case "local":
+		return NewLocal(uploadDir, "/api/static/", []byte(secret)), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  getenv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:    getenv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			Prefix:    os.Getenv("S3_PREFIX"),
+			PathStyle: getenv("S3_FORCE_PATH_STYLE", "true") != "false",
+		}
+		public, err := NewS3(cfg)
+		if err != nil {
+			return nil, err
+		}
+		// Private folders go to their own bucket or prefix. Once a CDN serves
+		// the public bucket only a separate bucket keeps them off it; a
+		// prefix inside the public bucket is still reachable through the CDN.
+		privateBucket, privatePrefix := os.Getenv("S3_PRIVATE_BUCKET"), os.Getenv("S3_PRIVATE_PREFIX")
+		if os.Getenv("FILES_CDN_BASE_URL") != "" && (privateBucket == "" || privateBucket == cfg.Bucket) {
+			return nil, errors.New("S3_PRIVATE_BUCKET must name a bucket other than S3_BUCKET when FILES_CDN_BASE_URL is set")
+		}
+		if privateBucket == "" && privatePrefix == "" {
+			return public, nil
+		}
+		cfg.Bucket = getenv("S3_PRIVATE_BUCKET", cfg.Bucket)
+		cfg.Prefix = privatePrefix
+		private, err := NewS3(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &Split{Public: public, Private: private}, nil
+	default:
+		return nil, fmt.Errorf("unknown FILESTORE_DRIVER %q", os.Getenv("FILESTORE_DRIVER"))
+	}
+}
+
+// Copy transfers every object under prefix from src to dst and returns the
+// number of objects copied.
+func Copy(ctx context.Context, src, dst FileStore, prefix string) (int, error) {
+	objects, err := src.List(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+	copied := 0
+	for _, obj := range objects {
+		r, info, err := src.Get(ctx, obj.Key)
+		if err != nil {
+			return copied, fmt.Errorf("%s: %w", obj.Key, err)
+		}
+		err = dst.Put(ctx, obj.Key, r, info.Size, info.ContentType)
+		r.Close()
+		if err != nil {
+			return copied, fmt.Errorf("%s: %w", obj.Key, err)
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+func getenv(key, fallback string) string {
+	if val := strings.TrimSpace(os.Getenv(key)); val != "" {
+		return val
+	}
+	return fallback
+}
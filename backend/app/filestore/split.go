@@ -0,0 +1,72 @@
+package filestore
+
+import (
+	"context"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// PrivateFolders hold customer files: order thread attachments, proofing
+// images and the originals kept for re-rendering watermarked uploads. They
+// must never be served from the public CDN.
+var PrivateFolders = []string{"threads", "proofing", "originals"}
+
+func IsPrivateKey(key string) bool {
+	first, _, _ := strings.Cut(strings.TrimPrefix(path.Clean("/"+key), "/"), "/")
+	return slices.Contains(PrivateFolders, first)
+}
+
+// Split keeps private keys in a separate store, e.g. a bucket that is not
+// behind the CDN, and everything else in the public one.
+type Split struct {
+	Public  FileStore
+	Private FileStore
+}
+
+func (s *Split) pick(key string) FileStore {
+	if IsPrivateKey(key) {
+		return s.Private
+	}
+	return s.Public
+}
+
+func (s *Split) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	return s.pick(key).Put(ctx, key, r, size, contentType)
+}
+
+func (s *Split) Get(ctx context.Context, key string) (io.ReadCloser, *Object, error) {
+	return s.pick(key).Get(ctx, key)
+}
+
+func (s *Split) Delete(ctx context.Context, key string) error {
+	return s.pick(key).Delete(ctx, key)
+}
+
+func (s *Split) SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	return s.pick(key).SignedURL(ctx, key, ttl)
+}
+
+// List merges both stores when prefix does not name a single folder.
+func (s *Split) List(ctx context.Context, prefix string) ([]Object, error) {
+	if prefix != "" {
+		return s.pick(prefix).List(ctx, prefix)
+	}
+	public, err := s.Public.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	private, err := s.Private.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Object, 0, len(public)+len(private))
+	for _, obj := range public {
+		if !IsPrivateKey(obj.Key) {
+			out = append(out, obj)
+		}
+	}
+	return append(out, private...), nil
+}
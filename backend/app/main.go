@@ -1,15 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"devara-creative-backend/app/auth"
 	"devara-creative-backend/app/database"
+	"devara-creative-backend/app/filestore"
 	"devara-creative-backend/app/repository"
 	"devara-creative-backend/app/server"
 	"devara-creative-backend/app/storage"
@@ -30,6 +33,7 @@ func main() {
 		uploadDir = flag.String("uploads", filepath.Join("storage", "uploads"), "upload directory")
 		adminUser = flag.String("admin-email", getenv("ADMIN_EMAIL", "admin@devara-creative.local"), "admin email")
 		adminPass = flag.String("admin-password", getenv("ADMIN_PASSWORD", "admin123"), "admin password")
+		migrate   = flag.Bool("migrate-uploads", false, "copy local uploads into the configured file store and exit")
 	)
 	flag.Parse()
 
@@ -42,6 +46,17 @@ func main() {
 	}
 	store.EnsureAdmin(*adminUser, auth.HashPassword(*adminPass))
 
+	files, err := filestore.FromEnv(*uploadDir)
+	if err != nil {
+		log.Fatalf("failed configuring file store: %v", err)
+	}
+	if *migrate {
+		if err := migrateUploads(store, *uploadDir, files); err != nil {
+			log.Fatalf("upload migration failed: %v", err)
+		}
+		return
+	}
+
 	var (
 		userRepo    repository.UserRepository
 		sessionRepo repository.SessionRepository
@@ -88,10 +103,26 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to schedule job: %v", err)
 	}
+	_, err = scheduler.NewJob(
+		gocron.DurationJob(15*time.Minute),
+		gocron.NewTask(func() {
+			expired, err := store.ExpireQuoteOffers(time.Now().UTC())
+			if err != nil {
+				log.Printf("Error running ExpireQuoteOffers job: %v", err)
+				return
+			}
+			if len(expired) > 0 {
+				log.Printf("ExpireQuoteOffers job completed, expired %d offers", len(expired))
+			}
+		}),
+	)
+	if err != nil {
+		log.Fatalf("failed to schedule job: %v", err)
+	}
 	scheduler.Start()
 	log.Println("Cron job for expired orders scheduled every 5 minutes")
 
-	srv := server.New(store, userRepo, sessionRepo, *uploadDir)
+	srv := server.New(store, userRepo, sessionRepo, files, *uploadDir)
 	handler := srv.Handler()
 
 	srvHTTP := &http.Server{
@@ -106,6 +137,33 @@ func main() {
 	}
 }
 
+func migrateUploads(store *storage.Store, uploadDir string, files filestore.FileStore) error {
+	if _, local := files.(*filestore.Local); !local {
+		src := filestore.NewLocal(uploadDir, "/api/static/", nil)
+		copied, err := filestore.Copy(context.Background(), src, files, "")
+		if err != nil {
+			return err
+		}
+		log.Printf("copied %d uploads into the file store", copied)
+	}
+	base := strings.TrimRight(getenv("FILES_CDN_BASE_URL", ""), "/")
+	if base == "" {
+		log.Println("FILES_CDN_BASE_URL not set, stored upload URLs left unchanged")
+		return nil
+	}
+	changed, err := store.RewriteUploadURLs(func(u string) string {
+		if key, ok := strings.CutPrefix(u, "/api/static/"); ok && key != "" && !filestore.IsPrivateKey(key) {
+			return base + "/" + key
+		}
+		return u
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("rewrote upload URLs in %d records", changed)
+	return nil
+}
+
 func getenv(key, fallback string) string {
 	if val := os.Getenv(key); val != "" {
 		return val
@@ -16,46 +16,254 @@ type ServiceHighlight struct {
 	Icon        string `json:"icon"`
 }
 
+type Publishing struct {
+	PublishStatus string    `json:"publish_status,omitempty"`
+	PublishAt     time.Time `json:"publish_at,omitempty"`
+	UnpublishAt   time.Time `json:"unpublish_at,omitempty"`
+	ArchivedAt    time.Time `json:"archived_at,omitempty"`
+}
+
 type Category struct {
-	ID   uint   `json:"id"`
-	Name string `json:"name"`
-	Slug string `json:"slug"`
+	ID           uint   `json:"id"`
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	ParentID     uint   `json:"parent_id,omitempty"`
+	SortOrder    int    `json:"sort_order"`
+	Description  string `json:"description,omitempty"`
+	Icon         string `json:"icon,omitempty"`
+	CoverImage   string `json:"cover_image,omitempty"`
+	CoverMediaID uint   `json:"cover_media_id,omitempty"`
 }
 
 type Service struct {
-	ID            uint               `json:"id"`
-	Title         string             `json:"title"`
-	Slug          string             `json:"slug"`
-	Price         float64            `json:"price"`
-	CategoryID    uint               `json:"category_id"`
-	Thumbnail     string             `json:"thumbnail"`
-	Summary       string             `json:"summary"`
-	Description   string             `json:"description"`
-	GalleryImages []string           `json:"gallery_images"`
-	AddOns        []AddOn            `json:"add_ons"`
-	Highlights    []ServiceHighlight `json:"highlights"`
+	ID               uint                  `json:"id"`
+	Title            string                `json:"title"`
+	Slug             string                `json:"slug"`
+	Price            float64               `json:"price"`
+	CategoryID       uint                  `json:"category_id"`
+	Thumbnail        string                `json:"thumbnail"`
+	Summary          string                `json:"summary"`
+	Description      string                `json:"description"`
+	GalleryImages    []string              `json:"gallery_images"`
+	AddOns           []AddOn               `json:"add_ons"`
+	Packages         []ServicePackage      `json:"packages,omitempty"`
+	Highlights       []ServiceHighlight    `json:"highlights"`
+	Booking          *ServiceBooking       `json:"booking,omitempty"`
+	Capacity         *ServiceCapacity      `json:"capacity,omitempty"`
+	Images           map[string]ImageAsset `json:"images,omitempty"`
+	ThumbnailMediaID uint                  `json:"thumbnail_media_id,omitempty"`
+	GalleryMediaIDs  []uint                `json:"gallery_media_ids,omitempty"`
+	Publishing
+}
+
+type MediaItem struct {
+	ID                uint             `json:"id"`
+	Key               string           `json:"key"`
+	URL               string           `json:"url"`
+	Type              string           `json:"type"`
+	ContentType       string           `json:"content_type"`
+	Size              int64            `json:"size"`
+	Width             int              `json:"width,omitempty"`
+	Height            int              `json:"height,omitempty"`
+	FileName          string           `json:"file_name"`
+	AltText           string           `json:"alt_text,omitempty"`
+	Caption           string           `json:"caption,omitempty"`
+	UploadedBy        string           `json:"uploaded_by,omitempty"`
+	Image             *ImageAsset      `json:"image,omitempty"`
+	OriginalKey       string           `json:"original_key,omitempty"`
+	Watermarked       bool             `json:"watermarked"`
+	PublicUseApproved bool             `json:"public_use_approved"`
+	References        []MediaReference `json:"references"`
+	UnusedSince       time.Time        `json:"unused_since,omitempty"`
+	CreatedAt         time.Time        `json:"created_at"`
+	UpdatedAt         time.Time        `json:"updated_at"`
+}
+
+type MediaReference struct {
+	EntityType string `json:"entity_type"`
+	EntityID   uint   `json:"entity_id"`
+	Field      string `json:"field"`
+	Title      string `json:"title,omitempty"`
+}
+
+type ImageVariant struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Format string `json:"format"`
+}
+
+type ImageAsset struct {
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Format      string         `json:"format"`
+	Placeholder string         `json:"placeholder,omitempty"`
+	SrcSet      string         `json:"srcset,omitempty"`
+	WebPSrcSet  string         `json:"webp_srcset,omitempty"`
+	Variants    []ImageVariant `json:"variants,omitempty"`
+}
+
+type ServicePackage struct {
+	Key          string   `json:"key"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description,omitempty"`
+	Price        float64  `json:"price"`
+	DeliveryDays int      `json:"delivery_days,omitempty"`
+	Revisions    int      `json:"revisions"`
+	Features     []string `json:"features,omitempty"`
+	AddOns       []string `json:"add_ons,omitempty"`
+	Recommended  bool     `json:"recommended,omitempty"`
+	ProofPicks   int      `json:"proof_picks,omitempty"`
+}
+
+type ServiceCapacity struct {
+	MaxActiveOrders  int `json:"max_active_orders,omitempty"`
+	WeeklyQuota      int `json:"weekly_quota,omitempty"`
+	ReservationHours int `json:"reservation_hours,omitempty"`
+}
+
+type CheckoutRecovery struct {
+	ID            uint      `json:"id"`
+	OrderID       uint      `json:"order_id"`
+	CustomerEmail string    `json:"customer_email"`
+	Step          int       `json:"step"`
+	PromoCode     string    `json:"promo_code,omitempty"`
+	SentAt        time.Time `json:"sent_at"`
+	ClickedAt     time.Time `json:"clicked_at,omitempty"`
+}
+
+type RecoveryOptOut struct {
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Review struct {
+	ID          uint      `json:"id"`
+	OrderID     uint      `json:"order_id"`
+	ServiceID   uint      `json:"service_id"`
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Photos      []string  `json:"photos,omitempty"`
+	Status      string    `json:"status"`
+	Reply       string    `json:"reply,omitempty"`
+	RepliedAt   time.Time `json:"replied_at,omitempty"`
+	ModeratedAt time.Time `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type Revision struct {
+	ID         uint            `json:"id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   uint            `json:"entity_id"`
+	Version    int             `json:"version"`
+	Action     string          `json:"action"`
+	Author     string          `json:"author,omitempty"`
+	Snapshot   json.RawMessage `json:"snapshot"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type ExportJob struct {
+	ID          uint      `json:"id"`
+	Dataset     string    `json:"dataset"`
+	Format      string    `json:"format"`
+	From        time.Time `json:"from,omitempty"`
+	To          time.Time `json:"to,omitempty"`
+	Columns     []string  `json:"columns,omitempty"`
+	Status      string    `json:"status"`
+	FileName    string    `json:"file_name,omitempty"`
+	RowCount    int       `json:"row_count"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type WaitlistEntry struct {
+	ID                   uint      `json:"id"`
+	ServiceID            uint      `json:"service_id"`
+	CustomerName         string    `json:"customer_name"`
+	CustomerEmail        string    `json:"customer_email"`
+	CustomerPhone        string    `json:"customer_phone,omitempty"`
+	Notes                string    `json:"notes,omitempty"`
+	Status               string    `json:"status"`
+	NotifiedAt           time.Time `json:"notified_at,omitempty"`
+	ReservationExpiresAt time.Time `json:"reservation_expires_at,omitempty"`
+	OrderID              uint      `json:"order_id,omitempty"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+type WorkingHours struct {
+	Weekday int    `json:"weekday"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+}
+
+type ServiceBooking struct {
+	Enabled             bool           `json:"enabled"`
+	DurationMinutes     int            `json:"duration_minutes"`
+	BufferMinutes       int            `json:"buffer_minutes"`
+	SlotIntervalMinutes int            `json:"slot_interval_minutes,omitempty"`
+	MinNoticeHours      int            `json:"min_notice_hours,omitempty"`
+	MaxAdvanceDays      int            `json:"max_advance_days,omitempty"`
+	Timezone            string         `json:"timezone,omitempty"`
+	WorkingHours        []WorkingHours `json:"working_hours"`
+}
+
+type BlackoutDate struct {
+	ID        uint      `json:"id"`
+	ServiceID uint      `json:"service_id,omitempty"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type GalleryAsset struct {
 	URL     string `json:"url"`
 	Caption string `json:"caption,omitempty"`
 	Type    string `json:"type"`
+	MediaID uint   `json:"media_id,omitempty"`
 }
 
 type GalleryItem struct {
-	ID          uint           `json:"id"`
-	Section     string         `json:"section"`
-	Title       string         `json:"title"`
-	Subtitle    string         `json:"subtitle"`
-	Thumbnail   string         `json:"thumbnail"`
-	Filters     []string       `json:"filters,omitempty"`
-	DisplayMode string         `json:"display_mode,omitempty"`
-	Assets      []GalleryAsset `json:"assets,omitempty"`
-	VideoURL    string         `json:"video_url,omitempty"`
-	LinkURL     string         `json:"link_url,omitempty"`
-	Description string         `json:"description,omitempty"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
+	ID               uint                  `json:"id"`
+	Section          string                `json:"section"`
+	Title            string                `json:"title"`
+	Subtitle         string                `json:"subtitle"`
+	Thumbnail        string                `json:"thumbnail"`
+	Filters          []string              `json:"filters,omitempty"`
+	DisplayMode      string                `json:"display_mode,omitempty"`
+	Assets           []GalleryAsset        `json:"assets,omitempty"`
+	VideoURL         string                `json:"video_url,omitempty"`
+	VideoEmbed       *LinkEmbed            `json:"video_embed,omitempty"`
+	LinkURL          string                `json:"link_url,omitempty"`
+	LinkEmbed        *LinkEmbed            `json:"link_embed,omitempty"`
+	Description      string                `json:"description,omitempty"`
+	Images           map[string]ImageAsset `json:"images,omitempty"`
+	ThumbnailMediaID uint                  `json:"thumbnail_media_id,omitempty"`
+	SortOrder        int                   `json:"sort_order"`
+	Featured         bool                  `json:"featured"`
+	Pinned           bool                  `json:"pinned"`
+	ServiceIDs       []uint                `json:"service_ids,omitempty"`
+	CreatedAt        time.Time             `json:"created_at"`
+	UpdatedAt        time.Time             `json:"updated_at"`
+	Publishing
+}
+
+// LinkEmbed describes an external video or project link in a form the
+// frontend can embed without knowing each provider.
+type LinkEmbed struct {
+	Provider     string `json:"provider"`
+	Kind         string `json:"kind"`
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	EmbedURL     string `json:"embed_url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	AspectRatio  string `json:"aspect_ratio"`
 }
 
 type Experience struct {
@@ -67,32 +275,91 @@ type Experience struct {
 	Order       int       `json:"order"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Publishing
+}
+
+// CaseStudy tells the story behind a gallery item. Challenge, Approach and
+// Result hold markdown. When ClientAnonymized is set the client name and the
+// testimonial author are withheld from public responses.
+type CaseStudy struct {
+	ID               uint                 `json:"id"`
+	Slug             string               `json:"slug"`
+	Title            string               `json:"title"`
+	Summary          string               `json:"summary,omitempty"`
+	CoverImage       string               `json:"cover_image,omitempty"`
+	CoverMediaID     uint                 `json:"cover_media_id,omitempty"`
+	GalleryItemID    uint                 `json:"gallery_item_id,omitempty"`
+	ClientName       string               `json:"client_name,omitempty"`
+	ClientAnonymized bool                 `json:"client_anonymized"`
+	ClientIndustry   string               `json:"client_industry,omitempty"`
+	Challenge        string               `json:"challenge,omitempty"`
+	Approach         string               `json:"approach,omitempty"`
+	Result           string               `json:"result,omitempty"`
+	BeforeAfter      []CaseStudyAssetPair `json:"before_after,omitempty"`
+	Metrics          []CaseStudyMetric    `json:"metrics,omitempty"`
+	Testimonial      *CaseStudyQuote      `json:"testimonial,omitempty"`
+	ServiceIDs       []uint               `json:"service_ids,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
+	Publishing
+}
+
+type CaseStudyAssetPair struct {
+	Before        string `json:"before"`
+	BeforeMediaID uint   `json:"before_media_id,omitempty"`
+	After         string `json:"after"`
+	AfterMediaID  uint   `json:"after_media_id,omitempty"`
+	Caption       string `json:"caption,omitempty"`
+}
+
+type CaseStudyMetric struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Note  string `json:"note,omitempty"`
+}
+
+type CaseStudyQuote struct {
+	Quote  string `json:"quote"`
+	Author string `json:"author,omitempty"`
+	Role   string `json:"role,omitempty"`
 }
 
 type Order struct {
-	ID                   uint      `json:"id"`
-	ServiceID            uint      `json:"service_id"`
-	CustomerName         string    `json:"customer_name"`
-	CustomerEmail        string    `json:"customer_email"`
-	CustomerPhone        string    `json:"customer_phone"`
-	Notes                string    `json:"notes"`
-	Status               string    `json:"status"`
-	CancelReason         string    `json:"cancel_reason,omitempty"`
-	Amount               float64   `json:"amount"`
-	PromoCode            string    `json:"promo_code,omitempty"`
-	PromoDiscountPercent float64   `json:"promo_discount_percent,omitempty"`
-	PromoDiscountAmount  float64   `json:"promo_discount_amount,omitempty"`
-	PaymentMethod        string    `json:"payment_method,omitempty"`
-	PaymentStatus        string    `json:"payment_status,omitempty"`
-	PaymentReference     string    `json:"payment_reference,omitempty"`
-	PaymentExpiresAt     time.Time `json:"payment_expires_at,omitempty"`
-	RequestReason        string    `json:"request_reason,omitempty"`
-	RefundStatus         string    `json:"refund_status,omitempty"`
-	RatingValue          int       `json:"rating_value,omitempty"`
-	RatingReview         string    `json:"rating_review,omitempty"`
-	RatedAt              time.Time `json:"rated_at,omitempty"`
-	CreatedAt            time.Time `json:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at"`
+	ID                   uint            `json:"id"`
+	ServiceID            uint            `json:"service_id"`
+	CustomerName         string          `json:"customer_name"`
+	CustomerEmail        string          `json:"customer_email"`
+	CustomerPhone        string          `json:"customer_phone"`
+	Notes                string          `json:"notes"`
+	LineItems            []QuoteLineItem `json:"line_items,omitempty"`
+	Package              *ServicePackage `json:"package,omitempty"`
+	AddOns               []AddOn         `json:"add_ons,omitempty"`
+	Status               string          `json:"status"`
+	CancelReason         string          `json:"cancel_reason,omitempty"`
+	Amount               float64         `json:"amount"`
+	PromoCode            string          `json:"promo_code,omitempty"`
+	PromoDiscountPercent float64         `json:"promo_discount_percent,omitempty"`
+	PromoDiscountAmount  float64         `json:"promo_discount_amount,omitempty"`
+	DiscountAmount       float64         `json:"discount_amount,omitempty"`
+	PaymentMethod        string          `json:"payment_method,omitempty"`
+	PaymentStatus        string          `json:"payment_status,omitempty"`
+	PaymentReference     string          `json:"payment_reference,omitempty"`
+	PaymentExpiresAt     time.Time       `json:"payment_expires_at,omitempty"`
+	RequestReason        string          `json:"request_reason,omitempty"`
+	RefundStatus         string          `json:"refund_status,omitempty"`
+	RatingValue          int             `json:"rating_value,omitempty"`
+	RatingReview         string          `json:"rating_review,omitempty"`
+	RatedAt              time.Time       `json:"rated_at,omitempty"`
+	CompletedAt          time.Time       `json:"completed_at,omitempty"`
+	ReviewRequestedAt    time.Time       `json:"review_requested_at,omitempty"`
+	QuoteID              uint            `json:"quote_id,omitempty"`
+	Source               string          `json:"source,omitempty"`
+	RecoveredFromID      uint            `json:"recovered_from_id,omitempty"`
+	BookingStart         time.Time       `json:"booking_start,omitempty"`
+	BookingEnd           time.Time       `json:"booking_end,omitempty"`
+	WaitlistEntryID      uint            `json:"waitlist_entry_id,omitempty"`
+	CreatedAt            time.Time       `json:"created_at"`
+	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
 type PaymentTransaction struct {
@@ -135,6 +402,7 @@ type PromoCode struct {
 	UsedCount       int       `json:"used_count"`
 	ValidFrom       time.Time `json:"valid_from,omitempty"`
 	ValidUntil      time.Time `json:"valid_until,omitempty"`
+	CustomerEmail   string    `json:"customer_email,omitempty"`
 	Active          bool      `json:"active"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
@@ -151,6 +419,102 @@ type Message struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+type OrderMessageAttachment struct {
+	URL         string `json:"url"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+type OrderMessage struct {
+	ID               uint                     `json:"id"`
+	OrderID          uint                     `json:"order_id"`
+	AuthorRole       string                   `json:"author_role"`
+	AuthorName       string                   `json:"author_name"`
+	AuthorEmail      string                   `json:"author_email,omitempty"`
+	Body             string                   `json:"body"`
+	Attachments      []OrderMessageAttachment `json:"attachments,omitempty"`
+	Source           string                   `json:"source"`
+	ReadByCustomerAt *time.Time               `json:"read_by_customer_at,omitempty"`
+	ReadByAdminAt    *time.Time               `json:"read_by_admin_at,omitempty"`
+	CreatedAt        time.Time                `json:"created_at"`
+	UpdatedAt        time.Time                `json:"updated_at"`
+}
+
+type QuoteLineItem struct {
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+type QuoteRequest struct {
+	ID             uint            `json:"id"`
+	ServiceID      uint            `json:"service_id,omitempty"`
+	CategoryID     uint            `json:"category_id,omitempty"`
+	CustomerName   string          `json:"customer_name"`
+	CustomerEmail  string          `json:"customer_email"`
+	CustomerPhone  string          `json:"customer_phone,omitempty"`
+	Details        string          `json:"details"`
+	Budget         string          `json:"budget,omitempty"`
+	Deadline       string          `json:"deadline,omitempty"`
+	BookingStart   time.Time       `json:"booking_start,omitempty"`
+	Status         string          `json:"status"`
+	OfferItems     []QuoteLineItem `json:"offer_items,omitempty"`
+	OfferAmount    float64         `json:"offer_amount,omitempty"`
+	OfferTerms     string          `json:"offer_terms,omitempty"`
+	OfferNotes     string          `json:"offer_notes,omitempty"`
+	OfferExpiresAt time.Time       `json:"offer_expires_at,omitempty"`
+	OfferedAt      time.Time       `json:"offered_at,omitempty"`
+	RespondedAt    time.Time       `json:"responded_at,omitempty"`
+	DeclineReason  string          `json:"decline_reason,omitempty"`
+	OrderID        uint            `json:"order_id,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type ProofingGallery struct {
+	ID                uint            `json:"id"`
+	OrderID           uint            `json:"order_id"`
+	Title             string          `json:"title"`
+	Message           string          `json:"message,omitempty"`
+	Status            string          `json:"status"`
+	SelectionLimit    int             `json:"selection_limit"`
+	PasswordHash      string          `json:"password_hash,omitempty"`
+	PasswordProtected bool            `json:"password_protected"`
+	Images            []ProofingImage `json:"images"`
+	SubmissionNote    string          `json:"submission_note,omitempty"`
+	SubmittedAt       time.Time       `json:"submitted_at,omitempty"`
+	SentAt            time.Time       `json:"sent_at,omitempty"`
+	ExpiresAt         time.Time       `json:"expires_at,omitempty"`
+	CreatedBy         string          `json:"created_by,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+type ProofingImage struct {
+	ID         uint              `json:"id"`
+	Key        string            `json:"key,omitempty"`
+	URL        string            `json:"url,omitempty"`
+	PreviewKey string            `json:"preview_key,omitempty"`
+	PreviewURL string            `json:"preview_url"`
+	FileName   string            `json:"file_name,omitempty"`
+	Width      int               `json:"width,omitempty"`
+	Height     int               `json:"height,omitempty"`
+	Favorite   bool              `json:"favorite"`
+	Selected   bool              `json:"selected"`
+	Comments   []ProofingComment `json:"comments,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type ProofingComment struct {
+	Author     string    `json:"author"`
+	FromStudio bool      `json:"from_studio"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
 type Admin struct {
 	ID           uint   `json:"id"`
 	Email        string `json:"email"`
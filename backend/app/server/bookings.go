@@ -0,0 +1,374 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const bookingFeedPurpose = "booking-feed"
+
+func parseBookingTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t.UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", value)
+}
+
+func validateServiceBooking(cfg *models.ServiceBooking) error {
+	switch {
+	case cfg.DurationMinutes <= 0:
+		return errors.New("duration_minutes must be greater than zero")
+	case cfg.BufferMinutes < 0:
+		return errors.New("buffer_minutes cannot be negative")
+	case cfg.SlotIntervalMinutes < 0:
+		return errors.New("slot_interval_minutes cannot be negative")
+	case cfg.MinNoticeHours < 0 || cfg.MaxAdvanceDays < 0:
+		return errors.New("notice and advance limits cannot be negative")
+	}
+	if tz := strings.TrimSpace(cfg.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("invalid timezone %q", tz)
+		}
+	}
+	if cfg.Enabled && len(cfg.WorkingHours) == 0 {
+		return errors.New("working_hours are required for bookable services")
+	}
+	for _, wh := range cfg.WorkingHours {
+		if wh.Weekday < 0 || wh.Weekday > 6 {
+			return errors.New("weekday must be between 0 (Sunday) and 6 (Saturday)")
+		}
+		open, ok := storage.ParseClock(wh.Start)
+		if !ok {
+			return fmt.Errorf("invalid start time %q", wh.Start)
+		}
+		closing, ok := storage.ParseClock(wh.End)
+		if !ok {
+			return fmt.Errorf("invalid end time %q", wh.End)
+		}
+		if closing-open < cfg.DurationMinutes {
+			return errors.New("working hours must fit at least one booking")
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleServiceSlots(w http.ResponseWriter, r *http.Request, slug string) {
+	svc, ok := s.visibleServiceBySlug(r, slug)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if svc.Booking == nil || !svc.Booking.Enabled {
+		s.writeErrorMsg(w, http.StatusBadRequest, "service is not bookable")
+		return
+	}
+	loc := storage.BookingLocation(svc.Booking)
+	now := time.Now().UTC()
+	from := now
+	to := now.AddDate(0, 0, 14)
+	query := r.URL.Query()
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := parseBookingTime(raw, loc)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+		from = parsed
+		to = from.AddDate(0, 0, 14)
+	}
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := parseBookingTime(raw, loc)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+		to = parsed
+	}
+	if !to.After(from) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "to must be after from")
+		return
+	}
+	slots, err := s.Store.ListAvailableSlots(svc.ID, from, to, now)
+	if err != nil {
+		if errors.Is(err, storage.ErrServiceNotBookable) {
+			s.writeErrorMsg(w, http.StatusBadRequest, "service is not bookable")
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"service_id":       svc.ID,
+		"timezone":         loc.String(),
+		"duration_minutes": svc.Booking.DurationMinutes,
+		"slots":            slots,
+	})
+}
+
+func (s *Server) handleAdminServiceBooking(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/services/")
+	id, err := parseID(strings.TrimSuffix(path, "/booking"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid service id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		svc, ok := s.Store.GetServiceByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, svc.Booking)
+	case http.MethodPut:
+		var payload models.ServiceBooking
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		payload.Timezone = strings.TrimSpace(payload.Timezone)
+		if err := validateServiceBooking(&payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		svc, err := s.Store.UpdateServiceBooking(id, &payload)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, svc)
+	case http.MethodDelete:
+		if _, err := s.Store.UpdateServiceBooking(id, nil); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminBlackoutDates(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.Store.ListBlackoutDates())
+	case http.MethodPost:
+		var payload struct {
+			ServiceID uint   `json:"service_id"`
+			Start     string `json:"start"`
+			End       string `json:"end"`
+			Reason    string `json:"reason"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		var cfg *models.ServiceBooking
+		if payload.ServiceID != 0 {
+			svc, ok := s.Store.GetServiceByID(payload.ServiceID)
+			if !ok {
+				s.writeErrorMsg(w, http.StatusNotFound, "Service not found")
+				return
+			}
+			cfg = svc.Booking
+		}
+		loc := storage.BookingLocation(cfg)
+		start, err := parseBookingTime(payload.Start, loc)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid start")
+			return
+		}
+		end := start.Add(24 * time.Hour)
+		if strings.TrimSpace(payload.End) != "" {
+			end, err = parseBookingTime(payload.End, loc)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid end")
+				return
+			}
+			if !strings.Contains(payload.End, "T") {
+				end = end.Add(24 * time.Hour)
+			}
+		}
+		if !end.After(start) {
+			s.writeErrorMsg(w, http.StatusBadRequest, "end must be after start")
+			return
+		}
+		created, err := s.Store.CreateBlackoutDate(&models.BlackoutDate{
+			ServiceID: payload.ServiceID,
+			Start:     start,
+			End:       end,
+			Reason:    strings.TrimSpace(payload.Reason),
+		})
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, created)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminBlackoutDateByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/blackout-dates/"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid blackout date id")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	if err := s.Store.DeleteBlackoutDate(id); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAdminBookings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	query := r.URL.Query()
+	loc := storage.BookingLocation(nil)
+	var from, to time.Time
+	if raw := query.Get("from"); raw != "" {
+		parsed, err := parseBookingTime(raw, loc)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid from")
+			return
+		}
+		from = parsed
+	}
+	if raw := query.Get("to"); raw != "" {
+		parsed, err := parseBookingTime(raw, loc)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid to")
+			return
+		}
+		to = parsed
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"bookings": s.Store.ListBookings(from, to, query.Get("confirmed") == "true"),
+		"feed_url": s.bookingFeedURL(s.Store.BookingFeedVersion()),
+	})
+}
+
+// bookingFeedToken is bound to the feed version so rotating it revokes links
+// handed out earlier. Version 0 keeps the token issued before rotation existed.
+func (s *Server) bookingFeedToken(version uint) string {
+	payload := "calendar"
+	if version > 0 {
+		payload = fmt.Sprintf("calendar|%d", version)
+	}
+	return s.linkSignature(bookingFeedPurpose, payload)
+}
+
+func (s *Server) bookingFeedURL(version uint) string {
+	return strings.TrimRight(s.backendBaseURL, "/") + "/api/bookings/calendar.ics?" + url.Values{"token": {s.bookingFeedToken(version)}}.Encode()
+}
+
+func (s *Server) handleAdminBookingFeedRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	version, err := s.Store.RotateBookingFeed()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"feed_url": s.bookingFeedURL(version)})
+}
+
+func (s *Server) handleBookingCalendarFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	token := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("token")))
+	if token == "" || !hmac.Equal([]byte(token), []byte(s.bookingFeedToken(s.Store.BookingFeedVersion()))) {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid feed token")
+		return
+	}
+	now := time.Now().UTC()
+	bookings := s.Store.ListBookings(now.AddDate(0, -3, 0), time.Time{}, true)
+	host := "devara-creative"
+	if u, err := url.Parse(s.backendBaseURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN:VCALENDAR\r\n")
+	b.WriteString("VERSION:2.0\r\n")
+	b.WriteString("PRODID:-//" + orderThreadAdminName() + "//Bookings//ID\r\n")
+	b.WriteString("CALSCALE:GREGORIAN\r\n")
+	b.WriteString("METHOD:PUBLISH\r\n")
+	b.WriteString(icsLine("X-WR-CALNAME", orderThreadAdminName()+" Bookings"))
+	for _, order := range bookings {
+		serviceTitle := "Booking"
+		if svc, ok := s.Store.GetServiceByID(order.ServiceID); ok {
+			serviceTitle = svc.Title
+		}
+		description := fmt.Sprintf("Order #%d\nCustomer: %s\nEmail: %s\nPhone: %s", order.ID, order.CustomerName, order.CustomerEmail, order.CustomerPhone)
+		if strings.TrimSpace(order.Notes) != "" {
+			description += "\nNotes: " + order.Notes
+		}
+		b.WriteString("BEGIN:VEVENT\r\n")
+		b.WriteString(fmt.Sprintf("UID:order-%d@%s\r\n", order.ID, host))
+		b.WriteString("DTSTAMP:" + order.UpdatedAt.UTC().Format("20060102T150405Z") + "\r\n")
+		b.WriteString("DTSTART:" + order.BookingStart.UTC().Format("20060102T150405Z") + "\r\n")
+		b.WriteString("DTEND:" + order.BookingEnd.UTC().Format("20060102T150405Z") + "\r\n")
+		b.WriteString(icsLine("SUMMARY", fmt.Sprintf("%s - %s", serviceTitle, order.CustomerName)))
+		b.WriteString(icsLine("DESCRIPTION", description))
+		b.WriteString("STATUS:CONFIRMED\r\n")
+		b.WriteString("END:VEVENT\r\n")
+	}
+	b.WriteString("END:VCALENDAR\r\n")
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename=\"bookings.ics\"")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func icsLine(name, value string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n")
+	line := name + ":" + replacer.Replace(value)
+	var b strings.Builder
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && (line[cut]&0xC0) == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line + "\r\n")
+	return b.String()
+}
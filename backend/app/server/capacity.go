@@ -0,0 +1,196 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/utils"
+)
+
+func (s *Server) waitlistReservationURL(entry *models.WaitlistEntry, svc *models.Service) string {
+	path := "/services"
+	if svc != nil && svc.Slug != "" {
+		path = "/services/" + svc.Slug
+	}
+	return s.frontendURL(path, url.Values{"reservation": {s.signLinkToken("waitlist", entry.ID)}})
+}
+
+func (s *Server) startWaitlistLoop() {
+	interval := envDurationMinutes("WAITLIST_INTERVAL_MINUTES", 5*time.Minute)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.processWaitlist()
+		}
+	}()
+}
+
+func (s *Server) processWaitlist() {
+	if s.Store == nil {
+		return
+	}
+	notified, err := s.Store.AdvanceWaitlist(time.Now().UTC())
+	if err != nil {
+		log.Printf("waitlist processing failed: %v", err)
+		return
+	}
+	for i := range notified {
+		entry := notified[i]
+		svc, _ := s.Store.GetServiceByID(entry.ServiceID)
+		subject, htmlBody, textBody, err := utils.BuildWaitlistReservationEmail(&entry, svc, s.waitlistReservationURL(&entry, svc))
+		if err != nil {
+			log.Printf("Failed to build waitlist reservation email: %v", err)
+			continue
+		}
+		go func() {
+			if err := utils.SendEmail(entry.CustomerEmail, subject, htmlBody, textBody); err != nil {
+				log.Printf("Failed to send waitlist reservation email for entry %d: %v", entry.ID, err)
+			}
+		}()
+	}
+}
+
+func (s *Server) handleServiceWaitlist(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	svc, ok := s.visibleServiceBySlug(r, slug)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if _, limited := s.Store.GetServiceAvailability(svc.ID, time.Now().UTC()); !limited {
+		s.writeErrorMsg(w, http.StatusBadRequest, "service has no waitlist")
+		return
+	}
+	var payload struct {
+		Name  string `json:"customer_name"`
+		Email string `json:"customer_email"`
+		Phone string `json:"customer_phone"`
+		Notes string `json:"notes"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Email = strings.TrimSpace(payload.Email)
+	if payload.Name == "" || !isValidEmail(payload.Email) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "name and email are required")
+		return
+	}
+	entry, err := s.Store.JoinWaitlist(&models.WaitlistEntry{
+		ServiceID:     svc.ID,
+		CustomerName:  payload.Name,
+		CustomerEmail: payload.Email,
+		CustomerPhone: strings.TrimSpace(payload.Phone),
+		Notes:         strings.TrimSpace(payload.Notes),
+	})
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.processWaitlist()
+	s.writeJSON(w, http.StatusCreated, map[string]any{
+		"id":     entry.ID,
+		"status": entry.Status,
+	})
+}
+
+func (s *Server) handleAdminServiceCapacity(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/services/")
+	id, err := parseID(strings.TrimSuffix(path, "/capacity"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid service id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		svc, ok := s.Store.GetServiceByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		availability, _ := s.Store.GetServiceAvailability(id, time.Now().UTC())
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"capacity":     svc.Capacity,
+			"availability": availability,
+		})
+	case http.MethodPut, http.MethodDelete:
+		var cfg *models.ServiceCapacity
+		if r.Method == http.MethodPut {
+			var payload models.ServiceCapacity
+			if err := s.decodeJSON(r.Body, &payload); err != nil {
+				s.writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			if payload.MaxActiveOrders < 0 || payload.WeeklyQuota < 0 || payload.ReservationHours < 0 {
+				s.writeErrorMsg(w, http.StatusBadRequest, "capacity values cannot be negative")
+				return
+			}
+			cfg = &payload
+		}
+		svc, err := s.Store.UpdateServiceCapacity(id, cfg)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.processWaitlist()
+		s.writeJSON(w, http.StatusOK, svc)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminWaitlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var serviceID uint
+	if raw := strings.TrimSpace(r.URL.Query().Get("service_id")); raw != "" {
+		parsed, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid service_id")
+			return
+		}
+		serviceID = uint(parsed)
+	}
+	s.writeJSON(w, http.StatusOK, s.Store.ListWaitlistEntries(serviceID))
+}
+
+func (s *Server) handleAdminWaitlistByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/waitlist/"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid waitlist id")
+		return
+	}
+	if r.Method != http.MethodDelete {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	entry, err := s.Store.CancelWaitlistEntry(id)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.processWaitlist()
+	s.writeJSON(w, http.StatusOK, entry)
+}
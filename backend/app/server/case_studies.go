@@ -0,0 +1,388 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const (
+	maxCaseStudyPairs   = 20
+	maxCaseStudyMetrics = 12
+	maxCaseStudySection = 5000
+)
+
+type caseStudyPayload struct {
+	Slug             *string                      `json:"slug"`
+	Title            *string                      `json:"title"`
+	Summary          *string                      `json:"summary"`
+	CoverImage       *string                      `json:"cover_image"`
+	GalleryItemID    *uint                        `json:"gallery_item_id"`
+	ClientName       *string                      `json:"client_name"`
+	ClientAnonymized *bool                        `json:"client_anonymized"`
+	ClientIndustry   *string                      `json:"client_industry"`
+	Challenge        *string                      `json:"challenge"`
+	Approach         *string                      `json:"approach"`
+	Result           *string                      `json:"result"`
+	BeforeAfter      *[]models.CaseStudyAssetPair `json:"before_after"`
+	Metrics          *[]models.CaseStudyMetric    `json:"metrics"`
+	Testimonial      *models.CaseStudyQuote       `json:"testimonial"`
+	ServiceIDs       *[]uint                      `json:"service_ids"`
+
+	PublishStatus string `json:"publish_status"`
+	PublishAt     string `json:"publish_at"`
+	UnpublishAt   string `json:"unpublish_at"`
+}
+
+// apply merges the fields present in the payload into cs. A testimonial with
+// an empty quote removes it.
+func (p caseStudyPayload) apply(cs *models.CaseStudy) {
+	setString := func(dst *string, src *string) {
+		if src != nil {
+			*dst = strings.TrimSpace(*src)
+		}
+	}
+	setString(&cs.Slug, p.Slug)
+	setString(&cs.Title, p.Title)
+	setString(&cs.Summary, p.Summary)
+	setString(&cs.CoverImage, p.CoverImage)
+	setString(&cs.ClientName, p.ClientName)
+	setString(&cs.ClientIndustry, p.ClientIndustry)
+	setString(&cs.Challenge, p.Challenge)
+	setString(&cs.Approach, p.Approach)
+	setString(&cs.Result, p.Result)
+	if p.GalleryItemID != nil {
+		cs.GalleryItemID = *p.GalleryItemID
+	}
+	if p.ClientAnonymized != nil {
+		cs.ClientAnonymized = *p.ClientAnonymized
+	}
+	if p.BeforeAfter != nil {
+		cs.BeforeAfter = make([]models.CaseStudyAssetPair, 0, len(*p.BeforeAfter))
+		for _, pair := range *p.BeforeAfter {
+			cs.BeforeAfter = append(cs.BeforeAfter, models.CaseStudyAssetPair{
+				Before:  strings.TrimSpace(pair.Before),
+				After:   strings.TrimSpace(pair.After),
+				Caption: strings.TrimSpace(pair.Caption),
+			})
+		}
+	}
+	if p.Metrics != nil {
+		cs.Metrics = make([]models.CaseStudyMetric, 0, len(*p.Metrics))
+		for _, m := range *p.Metrics {
+			cs.Metrics = append(cs.Metrics, models.CaseStudyMetric{
+				Label: strings.TrimSpace(m.Label),
+				Value: strings.TrimSpace(m.Value),
+				Note:  strings.TrimSpace(m.Note),
+			})
+		}
+	}
+	if p.Testimonial != nil {
+		cs.Testimonial = &models.CaseStudyQuote{
+			Quote:  strings.TrimSpace(p.Testimonial.Quote),
+			Author: strings.TrimSpace(p.Testimonial.Author),
+			Role:   strings.TrimSpace(p.Testimonial.Role),
+		}
+	}
+	if p.ServiceIDs != nil {
+		cs.ServiceIDs = append([]uint(nil), *p.ServiceIDs...)
+	}
+}
+
+// validateCaseStudy checks a case study before it is saved. prev is the
+// stored version when updating; images it already uses are not checked
+// against the media library again.
+func (s *Server) validateCaseStudy(cs, prev *models.CaseStudy) error {
+	switch {
+	case cs.Title == "":
+		return errors.New("title is required")
+	case utf8.RuneCountInString(cs.Title) > 160:
+		return errors.New("title is too long (max 160 characters)")
+	case utf8.RuneCountInString(cs.Summary) > 500:
+		return errors.New("summary is too long (max 500 characters)")
+	case utf8.RuneCountInString(cs.ClientName) > 160:
+		return errors.New("client_name is too long (max 160 characters)")
+	case utf8.RuneCountInString(cs.Challenge) > maxCaseStudySection:
+		return fmt.Errorf("challenge is too long (max %d characters)", maxCaseStudySection)
+	case utf8.RuneCountInString(cs.Approach) > maxCaseStudySection:
+		return fmt.Errorf("approach is too long (max %d characters)", maxCaseStudySection)
+	case utf8.RuneCountInString(cs.Result) > maxCaseStudySection:
+		return fmt.Errorf("result is too long (max %d characters)", maxCaseStudySection)
+	case len(cs.BeforeAfter) > maxCaseStudyPairs:
+		return fmt.Errorf("at most %d before/after pairs are allowed", maxCaseStudyPairs)
+	case len(cs.Metrics) > maxCaseStudyMetrics:
+		return fmt.Errorf("at most %d metrics are allowed", maxCaseStudyMetrics)
+	}
+	saved := make(map[string]bool)
+	if prev != nil {
+		saved[prev.CoverImage] = true
+		for _, pair := range prev.BeforeAfter {
+			saved[pair.Before], saved[pair.After] = true, true
+		}
+	}
+	inLibrary := func(url string) bool {
+		if saved[url] {
+			return true
+		}
+		_, ok := s.Store.MediaByURL(url)
+		return ok
+	}
+	if cs.CoverImage != "" && !inLibrary(cs.CoverImage) {
+		return errors.New("cover_image must be an item from the media library")
+	}
+	for i, pair := range cs.BeforeAfter {
+		if pair.Before == "" || pair.After == "" {
+			return fmt.Errorf("before_after[%d] needs both a before and an after image", i)
+		}
+		for _, url := range []string{pair.Before, pair.After} {
+			if !inLibrary(url) {
+				return fmt.Errorf("before_after[%d] images must be items from the media library", i)
+			}
+		}
+	}
+	for i, m := range cs.Metrics {
+		if m.Label == "" || m.Value == "" {
+			return fmt.Errorf("metrics[%d] needs a label and a value", i)
+		}
+	}
+	if cs.GalleryItemID != 0 {
+		if _, ok := s.Store.GetGalleryItemByID(cs.GalleryItemID); !ok {
+			return fmt.Errorf("gallery item %d not found", cs.GalleryItemID)
+		}
+	}
+	for _, id := range cs.ServiceIDs {
+		if _, ok := s.Store.GetServiceByID(id); !ok {
+			return fmt.Errorf("service %d not found", id)
+		}
+	}
+	return nil
+}
+
+// fillFromGalleryItem defaults the title, summary and cover of a new case
+// study to those of the gallery item it is built from.
+func (s *Server) fillFromGalleryItem(cs *models.CaseStudy) {
+	if cs.GalleryItemID == 0 {
+		return
+	}
+	item, ok := s.Store.GetGalleryItemByID(cs.GalleryItemID)
+	if !ok {
+		return
+	}
+	if cs.Title == "" {
+		cs.Title = item.Title
+	}
+	if cs.Summary == "" {
+		cs.Summary = item.Subtitle
+	}
+	if _, ok := s.Store.MediaByURL(item.Thumbnail); ok && cs.CoverImage == "" {
+		cs.CoverImage = item.Thumbnail
+	}
+	if len(cs.ServiceIDs) == 0 {
+		cs.ServiceIDs = append([]uint(nil), item.ServiceIDs...)
+	}
+}
+
+func (s *Server) writeCaseStudyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.notFound(w)
+	case errors.Is(err, storage.ErrCaseStudySlugTaken):
+		s.writeErrorMsg(w, http.StatusConflict, err.Error())
+	case errors.Is(err, storage.ErrCaseStudySlugInvalid):
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// publicCaseStudy withholds the client's identity when the case study is
+// anonymised.
+func publicCaseStudy(cs models.CaseStudy) models.CaseStudy {
+	if !cs.ClientAnonymized {
+		return cs
+	}
+	cs.ClientName = ""
+	if cs.Testimonial != nil {
+		quote := *cs.Testimonial
+		quote.Author = ""
+		cs.Testimonial = &quote
+	}
+	return cs
+}
+
+func (s *Server) handleCaseStudies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var serviceID uint
+	if raw := strings.TrimSpace(r.URL.Query().Get("service")); raw != "" {
+		if id, err := parseID(raw); err == nil {
+			serviceID = id
+		} else if svc, ok := s.visibleServiceBySlug(r, raw); ok {
+			serviceID = svc.ID
+		} else {
+			s.writeErrorMsg(w, http.StatusBadRequest, "unknown service")
+			return
+		}
+	}
+	now := time.Now().UTC()
+	items := make([]models.CaseStudy, 0)
+	for _, cs := range s.Store.ListCaseStudies() {
+		if !storage.IsLive(cs.Publishing, now) && !s.previewAllowed(r, previewKindCaseStudy, cs.ID) {
+			continue
+		}
+		if serviceID != 0 && !slices.Contains(cs.ServiceIDs, serviceID) {
+			continue
+		}
+		items = append(items, publicCaseStudy(cs))
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+		"total": len(items),
+	})
+}
+
+func (s *Server) handleCaseStudyBySlug(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	slug := strings.TrimPrefix(r.URL.Path, "/api/case-studies/")
+	cs, ok := s.Store.GetCaseStudyBySlug(slug)
+	now := time.Now().UTC()
+	if !ok || (!storage.IsLive(cs.Publishing, now) && !s.previewAllowed(r, previewKindCaseStudy, cs.ID)) {
+		s.notFound(w)
+		return
+	}
+	var galleryItem *models.GalleryItem
+	if item, ok := s.Store.GetGalleryItemByID(cs.GalleryItemID); ok && storage.IsLive(item.Publishing, now) {
+		galleryItem = item
+	}
+	services := make([]models.Service, 0, len(cs.ServiceIDs))
+	for _, id := range cs.ServiceIDs {
+		if svc, ok := s.Store.GetServiceByID(id); ok && storage.IsLive(svc.Publishing, now) {
+			services = append(services, *svc)
+		}
+	}
+	response := struct {
+		models.CaseStudy
+		GalleryItem *models.GalleryItem `json:"gallery_item,omitempty"`
+		Services    []models.Service    `json:"services"`
+	}{
+		CaseStudy:   publicCaseStudy(*cs),
+		GalleryItem: galleryItem,
+		Services:    services,
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) handleAdminCaseStudies(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.writeJSON(w, http.StatusOK, s.Store.ListCaseStudies())
+	case http.MethodPost:
+		var payload caseStudyPayload
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		publishing, err := publishingPayload{
+			Status:      payload.PublishStatus,
+			PublishAt:   payload.PublishAt,
+			UnpublishAt: payload.UnpublishAt,
+		}.toPublishing()
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		cs := &models.CaseStudy{Publishing: publishing}
+		payload.apply(cs)
+		s.fillFromGalleryItem(cs)
+		if err := s.validateCaseStudy(cs, nil); err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		created, err := s.Store.CreateCaseStudy(cs)
+		if err != nil {
+			s.writeCaseStudyError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, map[string]any{
+			"status":     "created",
+			"case_study": created,
+		})
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminCaseStudyByID(w http.ResponseWriter, r *http.Request) {
+	if s.routeAdminPublishing(w, r, "/api/admin/case-studies/", previewKindCaseStudy) {
+		return
+	}
+	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/case-studies/"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		cs, ok := s.Store.GetCaseStudyByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, cs)
+	case http.MethodPut, http.MethodPatch:
+		cs, ok := s.Store.GetCaseStudyByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		var payload caseStudyPayload
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		prev := *cs
+		payload.apply(cs)
+		if err := s.validateCaseStudy(cs, &prev); err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		updated, err := s.Store.UpdateCaseStudy(id, cs, adminEmailFromContext(r.Context()))
+		if err != nil {
+			s.writeCaseStudyError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"status":     "updated",
+			"case_study": updated,
+		})
+	case http.MethodDelete:
+		var err error
+		result := "archived"
+		if r.URL.Query().Get("purge") == "true" {
+			_, err = s.Store.DeleteCaseStudy(id)
+			result = "deleted"
+		} else {
+			_, err = s.Store.SetCaseStudyPublishing(id, models.Publishing{PublishStatus: storage.PublishArchived}, adminEmailFromContext(r.Context()))
+		}
+		if err != nil {
+			s.writeCaseStudyError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": result})
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
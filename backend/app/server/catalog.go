@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const (
+	catalogSortTitle     = "title"
+	catalogSortPriceAsc  = "price_asc"
+	catalogSortPriceDesc = "price_desc"
+	catalogSortRating    = "rating"
+	catalogSortPopular   = "popular"
+	catalogSortNewest    = "newest"
+
+	defaultCatalogPageSize = 12
+	maxCatalogPageSize     = 50
+)
+
+var defaultCatalogPriceBuckets = []float64{1000000, 2500000, 5000000, 10000000}
+
+type serviceResponse struct {
+	models.Service
+	Category       string                       `json:"category"`
+	CategorySlug   string                       `json:"category_slug"`
+	AverageRating  float64                      `json:"average_rating"`
+	RatingCount    int                          `json:"rating_count"`
+	CompletedCount int                          `json:"completed_count"`
+	PackageMetrics map[string]packageMetrics    `json:"package_metrics,omitempty"`
+	Availability   *storage.ServiceAvailability `json:"availability,omitempty"`
+	StartingPrice  float64                      `json:"starting_price"`
+}
+
+type catalogCursor struct {
+	Sort string    `json:"s"`
+	Num  []float64 `json:"n,omitempty"`
+	Text string    `json:"t,omitempty"`
+	ID   uint      `json:"id"`
+}
+
+type catalogQuery struct {
+	categories map[string]bool
+	minPrice   *float64
+	maxPrice   *float64
+	minRating  float64
+	sort       string
+	limit      int
+	cursor     *catalogCursor
+	envelope   bool
+}
+
+type categoryFacet struct {
+	ID       uint   `json:"id"`
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	ParentID uint   `json:"parent_id,omitempty"`
+	Count    int    `json:"count"`
+}
+
+type priceBucketFacet struct {
+	Key   string  `json:"key"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max,omitempty"`
+	Count int     `json:"count"`
+}
+
+func parseCatalogQuery(values url.Values) (catalogQuery, error) {
+	q := catalogQuery{categories: make(map[string]bool), sort: catalogSortTitle, limit: defaultCatalogPageSize}
+	for _, raw := range values["category"] {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+				q.categories[part] = true
+			}
+		}
+	}
+	if len(q.categories) > 1 {
+		q.envelope = true
+	}
+	if raw := strings.ToLower(strings.TrimSpace(values.Get("sort"))); raw != "" {
+		switch raw {
+		case catalogSortTitle, catalogSortPriceAsc, catalogSortPriceDesc, catalogSortRating, catalogSortPopular, catalogSortNewest:
+			q.sort = raw
+		default:
+			return q, errors.New("unsupported sort")
+		}
+		q.envelope = true
+	}
+	var err error
+	if q.minPrice, err = parseCatalogPrice(values, "min_price"); err != nil {
+		return q, err
+	}
+	if q.maxPrice, err = parseCatalogPrice(values, "max_price"); err != nil {
+		return q, err
+	}
+	if q.minPrice != nil || q.maxPrice != nil {
+		q.envelope = true
+	}
+	if q.minPrice != nil && q.maxPrice != nil && *q.minPrice > *q.maxPrice {
+		return q, errors.New("min_price cannot exceed max_price")
+	}
+	if raw := strings.TrimSpace(values.Get("min_rating")); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || value < 0 || value > 5 {
+			return q, errors.New("min_rating must be between 0 and 5")
+		}
+		q.minRating = value
+		q.envelope = true
+	}
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return q, errors.New("invalid limit")
+		}
+		q.limit = min(n, maxCatalogPageSize)
+		q.envelope = true
+	}
+	if raw := strings.TrimSpace(values.Get("cursor")); raw != "" {
+		cursor, err := decodeCatalogCursor(raw)
+		if err != nil || cursor.Sort != q.sort {
+			return q, errors.New("invalid cursor")
+		}
+		q.cursor = cursor
+		q.envelope = true
+	}
+	return q, nil
+}
+
+func parseCatalogPrice(values url.Values, key string) (*float64, error) {
+	raw := strings.TrimSpace(values.Get(key))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return nil, errors.New("invalid " + key)
+	}
+	return &value, nil
+}
+
+func decodeCatalogCursor(raw string) (*catalogCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	var cursor catalogCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+func encodeCatalogCursor(cursor catalogCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func compareCatalogCursor(a, b catalogCursor) int {
+	for i := 0; i < len(a.Num) && i < len(b.Num); i++ {
+		if a.Num[i] != b.Num[i] {
+			if a.Num[i] < b.Num[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	if c := strings.Compare(a.Text, b.Text); c != 0 {
+		return c
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+func catalogSortKey(sortBy string, item serviceResponse, views map[string]int) catalogCursor {
+	key := catalogCursor{Sort: sortBy, ID: item.ID}
+	switch sortBy {
+	case catalogSortPriceAsc:
+		key.Num = []float64{item.StartingPrice}
+	case catalogSortPriceDesc:
+		key.Num = []float64{-item.StartingPrice}
+	case catalogSortRating:
+		key.Num = []float64{-item.AverageRating, -float64(item.RatingCount)}
+	case catalogSortPopular:
+		key.Num = []float64{-float64(item.CompletedCount), -float64(views[item.Slug])}
+	case catalogSortNewest:
+		key.Num = []float64{-float64(item.ID)}
+	default:
+		key.Text = strings.ToLower(item.Title)
+	}
+	return key
+}
+
+func (q catalogQuery) matches(item serviceResponse, skipCategory, skipPrice bool) bool {
+	if !skipCategory && len(q.categories) > 0 && !q.categories[item.CategorySlug] && !q.categories[strings.ToLower(item.Category)] {
+		return false
+	}
+	if !skipPrice && q.minPrice != nil && item.StartingPrice < *q.minPrice {
+		return false
+	}
+	if !skipPrice && q.maxPrice != nil && item.StartingPrice > *q.maxPrice {
+		return false
+	}
+	if q.minRating > 0 && (item.RatingCount == 0 || item.AverageRating < q.minRating) {
+		return false
+	}
+	return true
+}
+
+func catalogPriceBuckets() []float64 {
+	raw := envString("CATALOG_PRICE_BUCKETS", "")
+	if raw == "" {
+		return defaultCatalogPriceBuckets
+	}
+	var edges []float64
+	for _, part := range strings.Split(raw, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || value <= 0 || (len(edges) > 0 && value <= edges[len(edges)-1]) {
+			return defaultCatalogPriceBuckets
+		}
+		edges = append(edges, value)
+	}
+	return edges
+}
+
+func buildPriceBucketFacets(items []serviceResponse, q catalogQuery) []priceBucketFacet {
+	edges := catalogPriceBuckets()
+	facets := make([]priceBucketFacet, 0, len(edges)+1)
+	lower := 0.0
+	for _, edge := range edges {
+		facets = append(facets, priceBucketFacet{
+			Key: strconv.FormatFloat(lower, 'f', -1, 64) + "-" + strconv.FormatFloat(edge, 'f', -1, 64),
+			Min: lower,
+			Max: edge,
+		})
+		lower = edge
+	}
+	facets = append(facets, priceBucketFacet{Key: strconv.FormatFloat(lower, 'f', -1, 64) + "+", Min: lower})
+	for _, item := range items {
+		if !q.matches(item, false, true) {
+			continue
+		}
+		idx := sort.SearchFloat64s(edges, item.StartingPrice)
+		if idx < len(edges) && edges[idx] == item.StartingPrice {
+			idx++
+		}
+		facets[idx].Count++
+	}
+	return facets
+}
+
+func (s *Server) browseCatalog(items []serviceResponse, q catalogQuery, tree *categoryTree) map[string]any {
+	counts := make(map[uint]int, len(tree.byID))
+	matched := make([]serviceResponse, 0, len(items))
+	for _, item := range items {
+		if q.matches(item, true, false) {
+			for _, crumb := range tree.breadcrumbs(item.CategoryID) {
+				counts[crumb.ID]++
+			}
+		}
+		if q.matches(item, false, false) {
+			matched = append(matched, item)
+		}
+	}
+	nodes := tree.nodes(nil, false)
+	categoryFacets := make([]categoryFacet, 0, len(nodes))
+	for _, node := range nodes {
+		categoryFacets = append(categoryFacets, categoryFacet{
+			ID:       node.ID,
+			Slug:     node.Slug,
+			Name:     node.Name,
+			ParentID: node.ParentID,
+			Count:    counts[node.ID],
+		})
+	}
+	var views map[string]int
+	if q.sort == catalogSortPopular {
+		views = s.Store.CountPageViews("/services/")
+	}
+	keys := make(map[uint]catalogCursor, len(matched))
+	for _, item := range matched {
+		keys[item.ID] = catalogSortKey(q.sort, item, views)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return compareCatalogCursor(keys[matched[i].ID], keys[matched[j].ID]) < 0
+	})
+	start := 0
+	if q.cursor != nil {
+		start = sort.Search(len(matched), func(i int) bool {
+			return compareCatalogCursor(keys[matched[i].ID], *q.cursor) > 0
+		})
+	}
+	end := min(start+q.limit, len(matched))
+	page := matched[start:end]
+	var nextCursor string
+	if end < len(matched) && len(page) > 0 {
+		nextCursor = encodeCatalogCursor(keys[page[len(page)-1].ID])
+	}
+	return map[string]any{
+		"items":       page,
+		"total":       len(matched),
+		"limit":       q.limit,
+		"sort":        q.sort,
+		"next_cursor": nextCursor,
+		"facets": map[string]any{
+			"categories":   categoryFacets,
+			"price_ranges": buildPriceBucketFacets(items, q),
+		},
+	}
+}
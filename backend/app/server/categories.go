@@ -0,0 +1,186 @@
+package server
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+type categoryCrumb struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type categoryNode struct {
+	models.Category
+	Depth             int             `json:"depth"`
+	ServiceCount      int             `json:"service_count"`
+	TotalServiceCount int             `json:"total_service_count"`
+	Children          []*categoryNode `json:"children,omitempty"`
+}
+
+type categoryTree struct {
+	byID     map[uint]models.Category
+	children map[uint][]models.Category
+}
+
+type categoryPayload struct {
+	Name        string  `json:"name"`
+	Slug        string  `json:"slug"`
+	ParentID    *uint   `json:"parent_id"`
+	SortOrder   *int    `json:"sort_order"`
+	Description *string `json:"description"`
+	Icon        *string `json:"icon"`
+	CoverImage  *string `json:"cover_image"`
+}
+
+func newCategoryTree(categories []models.Category) *categoryTree {
+	tree := &categoryTree{
+		byID:     make(map[uint]models.Category, len(categories)),
+		children: make(map[uint][]models.Category),
+	}
+	for _, cat := range categories {
+		tree.byID[cat.ID] = cat
+	}
+	for _, cat := range categories {
+		parent := cat.ParentID
+		if _, ok := tree.byID[parent]; !ok {
+			parent = 0
+		}
+		tree.children[parent] = append(tree.children[parent], cat)
+	}
+	return tree
+}
+
+func (t *categoryTree) breadcrumbs(id uint) []categoryCrumb {
+	var crumbs []categoryCrumb
+	seen := make(map[uint]bool)
+	for current := id; current != 0 && !seen[current]; {
+		seen[current] = true
+		cat, ok := t.byID[current]
+		if !ok {
+			break
+		}
+		crumbs = append([]categoryCrumb{{ID: cat.ID, Name: cat.Name, Slug: cat.Slug}}, crumbs...)
+		current = cat.ParentID
+	}
+	return crumbs
+}
+
+func (t *categoryTree) descendants(id uint) []uint {
+	out := []uint{id}
+	for i := 0; i < len(out); i++ {
+		for _, child := range t.children[out[i]] {
+			out = append(out, child.ID)
+		}
+	}
+	return out
+}
+
+func (t *categoryTree) expandFilter(filter map[string]bool) map[string]bool {
+	if len(filter) == 0 {
+		return filter
+	}
+	expanded := make(map[string]bool, len(filter))
+	for _, cat := range t.byID {
+		if !filter[cat.Slug] && !filter[strings.ToLower(cat.Name)] {
+			continue
+		}
+		for _, id := range t.descendants(cat.ID) {
+			expanded[t.byID[id].Slug] = true
+		}
+	}
+	for key := range filter {
+		expanded[key] = true
+	}
+	return expanded
+}
+
+func (t *categoryTree) nodes(serviceCounts map[uint]int, nested bool) []*categoryNode {
+	var build func(parent uint, depth int) []*categoryNode
+	build = func(parent uint, depth int) []*categoryNode {
+		var out []*categoryNode
+		for _, cat := range t.children[parent] {
+			node := &categoryNode{Category: cat, Depth: depth, ServiceCount: serviceCounts[cat.ID]}
+			children := build(cat.ID, depth+1)
+			node.TotalServiceCount = node.ServiceCount
+			for _, child := range children {
+				if child.Depth == depth+1 {
+					node.TotalServiceCount += child.TotalServiceCount
+				}
+			}
+			if nested {
+				node.Children = children
+				out = append(out, node)
+			} else {
+				out = append(out, node)
+				out = append(out, children...)
+			}
+		}
+		return out
+	}
+	out := build(0, 0)
+	if out == nil {
+		out = []*categoryNode{}
+	}
+	return out
+}
+
+func (s *Server) decodeCategoryPayload(r *http.Request) (categoryPayload, *multipart.FileHeader, error) {
+	var payload categoryPayload
+	if !strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "multipart/") {
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			return payload, nil, err
+		}
+		payload.Name = strings.TrimSpace(payload.Name)
+		payload.Slug = strings.TrimSpace(payload.Slug)
+		return payload, nil, nil
+	}
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		return payload, nil, err
+	}
+	form := r.MultipartForm
+	payload.Name = strings.TrimSpace(getFormValue(form, "name"))
+	payload.Slug = strings.TrimSpace(getFormValue(form, "slug"))
+	if raw := strings.TrimSpace(getFormValue(form, "parent_id")); raw != "" {
+		id, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return payload, nil, errors.New("invalid parent_id")
+		}
+		parentID := uint(id)
+		payload.ParentID = &parentID
+	}
+	if raw := strings.TrimSpace(getFormValue(form, "sort_order")); raw != "" {
+		order, err := strconv.Atoi(raw)
+		if err != nil {
+			return payload, nil, errors.New("invalid sort_order")
+		}
+		payload.SortOrder = &order
+	}
+	if values, ok := form.Value["description"]; ok && len(values) > 0 {
+		payload.Description = &values[0]
+	}
+	if values, ok := form.Value["icon"]; ok && len(values) > 0 {
+		payload.Icon = &values[0]
+	}
+	cover := getFile(form, "cover_image")
+	if cover != nil {
+		if err := s.checkUpload(cover, imageField("cover_image")); err != nil {
+			return payload, nil, err
+		}
+	}
+	if cover == nil && getFormValue(form, "remove_cover_image") == "true" {
+		empty := ""
+		payload.CoverImage = &empty
+	}
+	return payload, cover, nil
+}
+
+func (s *Server) saveCategoryCover(file *multipart.FileHeader, uploader string) (string, error) {
+	return s.saveMedia(file, "categories", uploader)
+}
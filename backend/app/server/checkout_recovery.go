@@ -0,0 +1,230 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	recoveryPurpose       = "recovery"
+	recoveryOptOutPurpose = "recovery-optout"
+)
+
+type checkoutRecoveryConfig struct {
+	enabled      bool
+	policy       storage.RecoveryPolicy
+	promoPercent float64
+	promoStep    int
+	promoTTL     time.Duration
+}
+
+func parseRecoveryDelays(raw string) []time.Duration {
+	var delays []time.Duration
+	for _, part := range strings.Split(raw, ",") {
+		mins, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || mins <= 0 {
+			continue
+		}
+		delay := time.Duration(mins) * time.Minute
+		if len(delays) > 0 && delay <= delays[len(delays)-1] {
+			continue
+		}
+		delays = append(delays, delay)
+	}
+	return delays
+}
+
+func loadCheckoutRecoveryConfig() checkoutRecoveryConfig {
+	cfg := checkoutRecoveryConfig{
+		enabled: envBool("RECOVERY_ENABLED", true),
+		policy: storage.RecoveryPolicy{
+			Delays:         parseRecoveryDelays(envString("RECOVERY_DELAYS_MINUTES", "60,1440")),
+			MaxPerCustomer: envInt("RECOVERY_MAX_PER_CUSTOMER", 3),
+			CapWindow:      envDurationMinutes("RECOVERY_CAP_WINDOW_MINUTES", 7*24*time.Hour),
+		},
+		promoTTL: envDurationMinutes("RECOVERY_PROMO_VALID_MINUTES", 72*time.Hour),
+	}
+	if pct, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv("RECOVERY_PROMO_PERCENT")), 64); err == nil && pct > 0 && pct <= 100 {
+		cfg.promoPercent = pct
+	}
+	cfg.promoStep = envInt("RECOVERY_PROMO_STEP", len(cfg.policy.Delays))
+	return cfg
+}
+
+func (s *Server) recoveryURL(orderID uint) string {
+	return strings.TrimRight(s.backendBaseURL, "/") + "/api/recovery/" + s.signLinkToken(recoveryPurpose, orderID)
+}
+
+func (s *Server) recoveryOptOutURL(orderID uint) string {
+	return strings.TrimRight(s.backendBaseURL, "/") + "/api/recovery/unsubscribe?" + url.Values{"token": {s.signLinkToken(recoveryOptOutPurpose, orderID)}}.Encode()
+}
+
+func (s *Server) startCheckoutRecoveryLoop() {
+	if !s.recovery.enabled || len(s.recovery.policy.Delays) == 0 {
+		return
+	}
+	interval := envDurationMinutes("RECOVERY_INTERVAL_MINUTES", 10*time.Minute)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.processCheckoutRecovery(time.Now().UTC())
+		}
+	}()
+}
+
+func (s *Server) processCheckoutRecovery(now time.Time) {
+	if s.Store == nil {
+		return
+	}
+	for _, due := range s.Store.DueCheckoutRecoveries(now, s.recovery.policy) {
+		order := due.Order
+		var promo *models.PromoCode
+		if s.recovery.promoPercent > 0 && due.Step == s.recovery.promoStep {
+			created, err := s.createRecoveryPromo(order.CustomerEmail, now)
+			if err != nil {
+				log.Printf("failed to create recovery promo for order %d: %v", order.ID, err)
+			} else {
+				promo = created
+			}
+		}
+		promoCode := ""
+		if promo != nil {
+			promoCode = promo.Code
+		}
+		if _, err := s.Store.RecordCheckoutRecovery(order.ID, order.CustomerEmail, due.Step, promoCode, now); err != nil {
+			log.Printf("failed to record checkout recovery for order %d: %v", order.ID, err)
+			continue
+		}
+		service, _ := s.Store.GetServiceByID(order.ServiceID)
+		subject, htmlBody, textBody, err := utils.BuildCheckoutRecoveryEmail(&order, service, due.Step, s.recoveryURL(order.ID), s.recoveryOptOutURL(order.ID), promo)
+		if err != nil {
+			log.Printf("Failed to build checkout recovery email: %v", err)
+			continue
+		}
+		go func() {
+			if err := utils.SendEmail(order.CustomerEmail, subject, htmlBody, textBody); err != nil {
+				log.Printf("Failed to send checkout recovery email for order %d: %v", order.ID, err)
+			}
+		}()
+	}
+}
+
+// createRecoveryPromo issues a single-use code that only the recovered
+// customer can redeem.
+func (s *Server) createRecoveryPromo(email string, now time.Time) (*models.PromoCode, error) {
+	code, err := s.generatePromoCode()
+	if err != nil {
+		return nil, err
+	}
+	return s.Store.CreatePromoCode(&models.PromoCode{
+		Code:            code,
+		DiscountPercent: s.recovery.promoPercent,
+		MaxUsage:        1,
+		ValidFrom:       now,
+		ValidUntil:      now.Add(s.recovery.promoTTL),
+		CustomerEmail:   strings.TrimSpace(email),
+		Active:          true,
+	})
+}
+
+func (s *Server) handleRecoveryRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/recovery/"), "/")
+	if path == "unsubscribe" {
+		s.handleRecoveryOptOut(w, r)
+		return
+	}
+	orderID, err := s.verifyLinkToken(recoveryPurpose, path)
+	if err != nil {
+		s.notFound(w)
+		return
+	}
+	order, ok := s.Store.GetOrderByID(orderID)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	rec, err := s.Store.MarkCheckoutRecoveryClicked(order.ID, time.Now().UTC())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("failed to record recovery click for order %d: %v", order.ID, err)
+	}
+	latestTx, _ := s.Store.GetLatestPaymentTransactionByOrder(order.ID)
+	if allowed, _ := paymentAccessState(order, latestTx); allowed && latestTx != nil {
+		http.Redirect(w, r, s.paymentPageURL(order.ID), http.StatusFound)
+		return
+	}
+	params := url.Values{"recovery": {path}}
+	if rec != nil && rec.PromoCode != "" {
+		params.Set("promo", rec.PromoCode)
+	}
+	target := "/services"
+	if svc, ok := s.Store.GetServiceByID(order.ServiceID); ok && svc.Slug != "" {
+		target = "/services/" + svc.Slug
+	}
+	http.Redirect(w, r, s.frontendURL(target, params), http.StatusFound)
+}
+
+func (s *Server) handleRecoveryOptOut(w http.ResponseWriter, r *http.Request) {
+	orderID, err := s.verifyLinkToken(recoveryOptOutPurpose, r.URL.Query().Get("token"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid unsubscribe token")
+		return
+	}
+	order, ok := s.Store.GetOrderByID(orderID)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if err := s.Store.OptOutOfRecovery(order.CustomerEmail); err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	http.Redirect(w, r, s.frontendURL("/recovery/unsubscribed", nil), http.StatusFound)
+}
+
+func (s *Server) handleAdminCheckoutRecovery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	from, err := parseOrderRangeTime(r.URL.Query().Get("from"), false)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	to, err := parseOrderRangeTime(r.URL.Query().Get("to"), true)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	delays := make([]int, 0, len(s.recovery.policy.Delays))
+	for _, d := range s.recovery.policy.Delays {
+		delays = append(delays, int(d/time.Minute))
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"report": s.Store.CheckoutRecoveryReport(from, to),
+		"config": map[string]any{
+			"enabled":           s.recovery.enabled,
+			"delays_minutes":    delays,
+			"max_per_customer":  s.recovery.policy.MaxPerCustomer,
+			"cap_window_hours":  int(s.recovery.policy.CapWindow / time.Hour),
+			"promo_percent":     s.recovery.promoPercent,
+			"promo_step":        s.recovery.promoStep,
+			"promo_valid_hours": int(s.recovery.promoTTL / time.Hour),
+		},
+	})
+}
@@ -0,0 +1,554 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	exportPurpose          = "export"
+	defaultExportRowLimit  = 2000
+	defaultExportRetention = 72 * time.Hour
+	defaultExportCurrency  = "IDR"
+)
+
+type exportRow struct {
+	Order   *adminOrderRow
+	Tx      *models.PaymentTransaction
+	Service string
+	Email   string
+}
+
+type exportColumn struct {
+	Key    string
+	Header string
+	Value  func(row *exportRow) utils.TableCell
+}
+
+type exportRequest struct {
+	Dataset string
+	Format  string
+	From    time.Time
+	To      time.Time
+	Columns []exportColumn
+}
+
+func exportTime(t time.Time) utils.TableCell {
+	if t.IsZero() {
+		return utils.TextCell("")
+	}
+	return utils.TextCell(t.In(exportLocation()).Format("2006-01-02 15:04:05"))
+}
+
+func exportLocation() *time.Location {
+	if loc, err := time.LoadLocation(envString("EXPORT_TIMEZONE", "Asia/Jakarta")); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+func orderCurrency(row *exportRow) string {
+	if row.Tx != nil && strings.TrimSpace(row.Tx.Currency) != "" {
+		return strings.ToUpper(strings.TrimSpace(row.Tx.Currency))
+	}
+	if row.Order != nil && row.Order.LatestTransaction != nil && strings.TrimSpace(row.Order.LatestTransaction.Currency) != "" {
+		return strings.ToUpper(strings.TrimSpace(row.Order.LatestTransaction.Currency))
+	}
+	return defaultExportCurrency
+}
+
+func orderPackageName(o *models.Order) string {
+	if o.Package == nil {
+		return ""
+	}
+	return o.Package.Name
+}
+
+func orderAddOnNames(o *models.Order) string {
+	names := make([]string, 0, len(o.AddOns))
+	for _, addOn := range o.AddOns {
+		names = append(names, addOn.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func transactionType(tx *models.PaymentTransaction) string {
+	if strings.EqualFold(tx.Method, "xendit_disbursement") {
+		return "refund"
+	}
+	return "payment"
+}
+
+var exportDatasets = map[string][]exportColumn{
+	"orders": {
+		{"id", "Order ID", func(r *exportRow) utils.TableCell { return utils.NumberCell(float64(r.Order.ID)) }},
+		{"created_at", "Created At", func(r *exportRow) utils.TableCell { return exportTime(r.Order.CreatedAt) }},
+		{"updated_at", "Updated At", func(r *exportRow) utils.TableCell { return exportTime(r.Order.UpdatedAt) }},
+		{"status", "Status", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.Status) }},
+		{"service", "Service", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.Service) }},
+		{"package", "Package", func(r *exportRow) utils.TableCell { return utils.TextCell(orderPackageName(&r.Order.Order)) }},
+		{"add_ons", "Add-ons", func(r *exportRow) utils.TableCell { return utils.TextCell(orderAddOnNames(&r.Order.Order)) }},
+		{"customer_name", "Customer Name", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.CustomerName) }},
+		{"customer_email", "Customer Email", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.CustomerEmail) }},
+		{"customer_phone", "Customer Phone", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.CustomerPhone) }},
+		{"currency", "Currency", func(r *exportRow) utils.TableCell { return utils.TextCell(orderCurrency(r)) }},
+		{"gross_amount", "Gross Amount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.Amount+r.Order.PromoDiscountAmount+r.Order.DiscountAmount, orderCurrency(r))
+		}},
+		{"discount_amount", "Discount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.PromoDiscountAmount+r.Order.DiscountAmount, orderCurrency(r))
+		}},
+		{"promo_discount_amount", "Promo Discount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.PromoDiscountAmount, orderCurrency(r))
+		}},
+		{"amount", "Net Amount", func(r *exportRow) utils.TableCell { return utils.AmountCell(r.Order.Amount, orderCurrency(r)) }},
+		{"promo_code", "Promo Code", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.PromoCode) }},
+		{"payment_method", "Payment Method", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.PaymentMethod) }},
+		{"payment_status", "Payment Status", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.PaymentStatus) }},
+		{"payment_reference", "Payment Reference", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.PaymentReference) }},
+		{"refund_status", "Refund Status", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.RefundStatus) }},
+	},
+	"transactions": {
+		{"id", "Transaction ID", func(r *exportRow) utils.TableCell { return utils.NumberCell(float64(r.Tx.ID)) }},
+		{"created_at", "Created At", func(r *exportRow) utils.TableCell { return exportTime(r.Tx.CreatedAt) }},
+		{"updated_at", "Updated At", func(r *exportRow) utils.TableCell { return exportTime(r.Tx.UpdatedAt) }},
+		{"type", "Type", func(r *exportRow) utils.TableCell { return utils.TextCell(transactionType(r.Tx)) }},
+		{"order_id", "Order ID", func(r *exportRow) utils.TableCell { return utils.NumberCell(float64(r.Tx.OrderID)) }},
+		{"service", "Service", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Service) }},
+		{"customer_email", "Customer Email", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Email) }},
+		{"method", "Method", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Tx.Method) }},
+		{"channel", "Channel", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Tx.Channel) }},
+		{"status", "Status", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Tx.Status) }},
+		{"reference", "Reference", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Tx.Reference) }},
+		{"external_id", "External ID", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Tx.ExternalID) }},
+		{"currency", "Currency", func(r *exportRow) utils.TableCell { return utils.TextCell(orderCurrency(r)) }},
+		{"amount", "Amount", func(r *exportRow) utils.TableCell { return utils.AmountCell(r.Tx.Amount, orderCurrency(r)) }},
+	},
+	"promo_redemptions": {
+		{"order_id", "Order ID", func(r *exportRow) utils.TableCell { return utils.NumberCell(float64(r.Order.ID)) }},
+		{"created_at", "Redeemed At", func(r *exportRow) utils.TableCell { return exportTime(r.Order.CreatedAt) }},
+		{"promo_code", "Promo Code", func(r *exportRow) utils.TableCell { return utils.TextCell(strings.ToUpper(r.Order.PromoCode)) }},
+		{"discount_percent", "Discount %", func(r *exportRow) utils.TableCell { return utils.NumberCell(r.Order.PromoDiscountPercent) }},
+		{"service", "Service", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.Service) }},
+		{"customer_email", "Customer Email", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.CustomerEmail) }},
+		{"status", "Order Status", func(r *exportRow) utils.TableCell { return utils.TextCell(r.Order.Status) }},
+		{"currency", "Currency", func(r *exportRow) utils.TableCell { return utils.TextCell(orderCurrency(r)) }},
+		{"gross_amount", "Gross Amount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.Amount+r.Order.PromoDiscountAmount+r.Order.DiscountAmount, orderCurrency(r))
+		}},
+		{"discount_amount", "Discount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.PromoDiscountAmount+r.Order.DiscountAmount, orderCurrency(r))
+		}},
+		{"promo_discount_amount", "Promo Discount", func(r *exportRow) utils.TableCell {
+			return utils.AmountCell(r.Order.PromoDiscountAmount, orderCurrency(r))
+		}},
+		{"amount", "Net Amount", func(r *exportRow) utils.TableCell { return utils.AmountCell(r.Order.Amount, orderCurrency(r)) }},
+	},
+}
+
+func parseExportRequest(dataset, format, from, to string, columns []string) (*exportRequest, error) {
+	dataset = strings.ToLower(strings.TrimSpace(dataset))
+	available, ok := exportDatasets[dataset]
+	if !ok {
+		return nil, fmt.Errorf("unknown export dataset %q", dataset)
+	}
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+	req := &exportRequest{Dataset: dataset, Format: format}
+	var err error
+	if req.From, err = parseOrderRangeTime(from, false); err != nil {
+		return nil, err
+	}
+	if req.To, err = parseOrderRangeTime(to, true); err != nil {
+		return nil, err
+	}
+	if !req.From.IsZero() && !req.To.IsZero() && req.To.Before(req.From) {
+		return nil, errors.New("to must be after from")
+	}
+	if len(columns) == 0 {
+		req.Columns = available
+		return req, nil
+	}
+	index := make(map[string]exportColumn, len(available))
+	for _, col := range available {
+		index[col.Key] = col
+	}
+	for _, key := range columns {
+		col, ok := index[strings.ToLower(strings.TrimSpace(key))]
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q for %s", key, dataset)
+		}
+		req.Columns = append(req.Columns, col)
+	}
+	return req, nil
+}
+
+func (req *exportRequest) columnKeys() []string {
+	keys := make([]string, len(req.Columns))
+	for i, col := range req.Columns {
+		keys[i] = col.Key
+	}
+	return keys
+}
+
+func inExportRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+func (s *Server) collectExportRows(req *exportRequest) []exportRow {
+	orders := s.buildAdminOrderRows(s.Store.ListOrderRecords())
+	var rows []exportRow
+	switch req.Dataset {
+	case "transactions":
+		byID := make(map[uint]*adminOrderRow, len(orders))
+		for i := range orders {
+			byID[orders[i].ID] = &orders[i]
+		}
+		for _, tx := range s.Store.ListPaymentTransactions(req.From, req.To) {
+			txCopy := tx
+			row := exportRow{Tx: &txCopy}
+			if order, ok := byID[tx.OrderID]; ok {
+				row.Service = order.Service
+				row.Email = order.CustomerEmail
+			}
+			rows = append(rows, row)
+		}
+	default:
+		for i := len(orders) - 1; i >= 0; i-- {
+			order := &orders[i]
+			if !inExportRange(order.CreatedAt, req.From, req.To) {
+				continue
+			}
+			if req.Dataset == "promo_redemptions" && strings.TrimSpace(order.PromoCode) == "" {
+				continue
+			}
+			rows = append(rows, exportRow{Order: order})
+		}
+	}
+	return rows
+}
+
+func writeExportTable(w io.Writer, req *exportRequest, rows []exportRow) error {
+	var table utils.TableWriter
+	if req.Format == "xlsx" {
+		xlsx, err := utils.NewXLSXTableWriter(w, req.Dataset)
+		if err != nil {
+			return err
+		}
+		table = xlsx
+	} else {
+		table = utils.NewCSVTableWriter(w)
+	}
+	header := make([]utils.TableCell, len(req.Columns))
+	for i, col := range req.Columns {
+		header[i] = utils.TextCell(col.Header)
+	}
+	if err := table.WriteRow(header); err != nil {
+		return err
+	}
+	cells := make([]utils.TableCell, len(req.Columns))
+	for i := range rows {
+		for j, col := range req.Columns {
+			cells[j] = col.Value(&rows[i])
+		}
+		if err := table.WriteRow(cells); err != nil {
+			return err
+		}
+	}
+	return table.Close()
+}
+
+func exportContentType(format string) string {
+	if format == "xlsx" {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+func exportFileName(req *exportRequest, now time.Time) string {
+	name := req.Dataset
+	if !req.From.IsZero() {
+		name += "-" + req.From.In(exportLocation()).Format("20060102")
+	}
+	if !req.To.IsZero() {
+		name += "-" + req.To.In(exportLocation()).Format("20060102")
+	}
+	return fmt.Sprintf("%s-%s.%s", name, now.Format("20060102150405"), req.Format)
+}
+
+func (s *Server) exportDir() string {
+	return envString("EXPORT_DIR", filepath.Join("storage", "exports"))
+}
+
+func (s *Server) exportDownloadURL(job *models.ExportJob) string {
+	if job == nil || job.Status != "completed" {
+		return ""
+	}
+	expires := time.Now().Add(envDurationMinutes("EXPORT_LINK_TTL_MINUTES", time.Hour)).Unix()
+	query := url.Values{
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sig":     {s.exportSignature(job.ID, expires)},
+	}
+	return strings.TrimRight(s.backendBaseURL, "/") + fmt.Sprintf("/api/exports/%d/download?", job.ID) + query.Encode()
+}
+
+func (s *Server) exportSignature(id uint, expires int64) string {
+	return s.linkSignature(exportPurpose, fmt.Sprintf("%d|%d", id, expires))
+}
+
+func (s *Server) exportJobResponse(job *models.ExportJob) map[string]any {
+	return map[string]any{
+		"job":          job,
+		"download_url": s.exportDownloadURL(job),
+	}
+}
+
+func (s *Server) queueExportJob(req *exportRequest) (*models.ExportJob, error) {
+	job, err := s.Store.CreateExportJob(&models.ExportJob{
+		Dataset: req.Dataset,
+		Format:  req.Format,
+		From:    req.From,
+		To:      req.To,
+		Columns: req.columnKeys(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	go s.runExportJob(job.ID, req)
+	return job, nil
+}
+
+func (s *Server) runExportJob(id uint, req *exportRequest) {
+	if _, err := s.Store.MarkExportJobRunning(id); err != nil {
+		log.Printf("export job %d could not start: %v", id, err)
+		return
+	}
+	fail := func(err error) {
+		log.Printf("export job %d failed: %v", id, err)
+		if _, updateErr := s.Store.FailExportJob(id, err.Error()); updateErr != nil {
+			log.Printf("export job %d status update failed: %v", id, updateErr)
+		}
+	}
+	dir := s.exportDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		fail(err)
+		return
+	}
+	fileName := fmt.Sprintf("%d-%s", id, exportFileName(req, time.Now()))
+	rows := s.collectExportRows(req)
+	f, err := os.Create(filepath.Join(dir, fileName))
+	if err != nil {
+		fail(err)
+		return
+	}
+	if err := writeExportTable(f, req, rows); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		fail(err)
+		return
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		fail(err)
+		return
+	}
+	if _, err := s.Store.CompleteExportJob(id, fileName, len(rows)); err != nil {
+		log.Printf("export job %d status update failed: %v", id, err)
+	}
+}
+
+func (s *Server) startExportCleanupLoop() {
+	if err := s.Store.FailInterruptedExportJobs("export interrupted by server restart"); err != nil {
+		log.Printf("export job recovery failed: %v", err)
+	}
+	retention := envDurationMinutes("EXPORT_RETENTION_MINUTES", defaultExportRetention)
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			removed, err := s.Store.PruneExportJobs(time.Now().UTC().Add(-retention))
+			if err != nil {
+				log.Printf("export cleanup failed: %v", err)
+				continue
+			}
+			for _, job := range removed {
+				s.removeExportFile(&job)
+			}
+		}
+	}()
+}
+
+func (s *Server) removeExportFile(job *models.ExportJob) {
+	if job.FileName == "" {
+		return
+	}
+	if err := os.Remove(filepath.Join(s.exportDir(), filepath.Base(job.FileName))); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("failed to remove export file %s: %v", job.FileName, err)
+	}
+}
+
+func (s *Server) handleAdminExports(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		jobs := s.Store.ListExportJobs()
+		out := make([]map[string]any, 0, len(jobs))
+		for i := range jobs {
+			out = append(out, s.exportJobResponse(&jobs[i]))
+		}
+		s.writeJSON(w, http.StatusOK, out)
+	case http.MethodPost:
+		var payload struct {
+			Dataset string   `json:"dataset"`
+			Format  string   `json:"format"`
+			From    string   `json:"from"`
+			To      string   `json:"to"`
+			Columns []string `json:"columns"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		req, err := parseExportRequest(payload.Dataset, payload.Format, payload.From, payload.To, payload.Columns)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		job, err := s.queueExportJob(req)
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.writeJSON(w, http.StatusAccepted, s.exportJobResponse(job))
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminExportByPath(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/exports/"), "/")
+	if id, err := parseID(path); err == nil {
+		s.handleAdminExportJob(w, r, id)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	query := r.URL.Query()
+	req, err := parseExportRequest(path, query.Get("format"), query.Get("from"), query.Get("to"), splitQueryList(query, "columns"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rows := s.collectExportRows(req)
+	if len(rows) > envInt("EXPORT_SYNC_ROW_LIMIT", defaultExportRowLimit) {
+		job, err := s.queueExportJob(req)
+		if err != nil {
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.writeJSON(w, http.StatusAccepted, s.exportJobResponse(job))
+		return
+	}
+	w.Header().Set("Content-Type", exportContentType(req.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFileName(req, time.Now())))
+	if err := writeExportTable(w, req, rows); err != nil {
+		log.Printf("export %s failed: %v", req.Dataset, err)
+	}
+}
+
+func (s *Server) handleAdminExportJob(w http.ResponseWriter, r *http.Request, id uint) {
+	switch r.Method {
+	case http.MethodGet:
+		job, ok := s.Store.GetExportJobByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.exportJobResponse(job))
+	case http.MethodDelete:
+		job, err := s.Store.DeleteExportJob(id)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.removeExportFile(job)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleExportDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/api/exports/")
+	if !strings.HasSuffix(path, "/download") {
+		s.notFound(w)
+		return
+	}
+	id, err := parseID(strings.TrimSuffix(path, "/download"))
+	if err != nil {
+		s.notFound(w)
+		return
+	}
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil || !hmac.Equal([]byte(s.exportSignature(id, expires)), []byte(r.URL.Query().Get("sig"))) {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid download link")
+		return
+	}
+	if time.Now().Unix() > expires {
+		s.writeErrorMsg(w, http.StatusForbidden, "download link has expired")
+		return
+	}
+	job, ok := s.Store.GetExportJobByID(id)
+	if !ok || job.Status != "completed" || job.FileName == "" {
+		s.notFound(w)
+		return
+	}
+	f, err := os.Open(filepath.Join(s.exportDir(), filepath.Base(job.FileName)))
+	if err != nil {
+		s.notFound(w)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	downloadName := strings.TrimPrefix(job.FileName, fmt.Sprintf("%d-", job.ID))
+	w.Header().Set("Content-Type", exportContentType(job.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", downloadName))
+	http.ServeContent(w, r, downloadName, info.ModTime(), f)
+}
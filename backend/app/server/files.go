@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/utils"
+)
+
+func loadImageOptions() utils.ImageOptions {
+	opts := utils.ImageOptions{
+		Widths:       utils.ParseImageWidths(envString("IMAGE_VARIANT_WIDTHS", "320,640,1024,1600")),
+		MaxDimension: envInt("IMAGE_MAX_DIMENSION", 2400),
+		JPEGQuality:  envInt("IMAGE_JPEG_QUALITY", 82),
+		WebPQuality:  envInt("IMAGE_WEBP_QUALITY", 80),
+	}
+	// An encoder named explicitly must work; only the implicit default may
+	// fall back to serving JPEG and PNG variants alone.
+	explicit := strings.TrimSpace(os.Getenv("IMAGE_WEBP_ENCODER")) != ""
+	unavailable := log.Printf
+	if explicit {
+		unavailable = log.Fatalf
+	}
+	encoder := envString("IMAGE_WEBP_ENCODER", "cwebp")
+	switch {
+	case encoder == "" || strings.EqualFold(encoder, "off"):
+		log.Printf("webp variants disabled by IMAGE_WEBP_ENCODER")
+	default:
+		resolved, err := exec.LookPath(encoder)
+		if err != nil {
+			unavailable("webp encoder %q not available, uploads get no webp variants: %v", encoder, err)
+			break
+		}
+		if out, err := exec.Command(resolved, "-version").CombinedOutput(); err != nil {
+			unavailable("webp encoder %s does not run, uploads get no webp variants: %v: %s", resolved, err, strings.TrimSpace(string(out)))
+			break
+		}
+		opts.WebPEncoder = resolved
+		log.Printf("webp variants enabled using %s", resolved)
+	}
+	return opts
+}
+
+type storedUpload struct {
+	Key         string
+	URL         string
+	ContentType string
+	Size        int64
+	Width       int
+	Height      int
+	Image       *models.ImageAsset
+	OriginalKey string
+	Watermarked bool
+}
+
+// storeOptions controls how storeUpload treats JPEG and PNG files.
+// keepOriginal saves the untouched upload under originalsFolder so variants
+// can be rendered again later.
+type storeOptions struct {
+	keepOriginal      bool
+	watermark         *utils.Watermark
+	publicUseApproved bool
+}
+
+const originalsFolder = "originals"
+
+// saveUpload writes an upload to the file store under folder and returns its
+// public URL. JPEG and PNG files go through the image pipeline and have their
+// variants recorded in images when it is non-nil.
+func (s *Server) saveUpload(file *multipart.FileHeader, folder string, images map[string]models.ImageAsset) (string, error) {
+	stored, err := s.storeUpload(file, folder, storeOptions{})
+	if err != nil {
+		return "", err
+	}
+	if images != nil && stored.Image != nil {
+		images[stored.URL] = *stored.Image
+	}
+	return stored.URL, nil
+}
+
+func (s *Server) storeUpload(file *multipart.FileHeader, folder string, opts storeOptions) (*storedUpload, error) {
+	sniffed, err := utils.SniffUpload(file)
+	if err != nil {
+		return nil, err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	base := path.Join(folder, utils.RandomName(16))
+	if sniffed.ContentType == "image/jpeg" || sniffed.ContentType == "image/png" {
+		imageOpts := s.images
+		imageOpts.Watermark = opts.watermark
+		processed, err := utils.ProcessImage(data, imageOpts)
+		if err != nil {
+			return nil, &utils.UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s: image could not be decoded", file.Filename)}
+		}
+		var written []string
+		stored := &storedUpload{ContentType: sniffed.ContentType, Width: processed.Width, Height: processed.Height, Watermarked: opts.watermark.Enabled()}
+		for _, f := range processed.Files {
+			key := base + f.Suffix + f.Ext
+			if err := s.Files.Put(ctx, key, bytes.NewReader(f.Data), int64(len(f.Data)), f.ContentType); err != nil {
+				for _, done := range written {
+					s.Files.Delete(ctx, done)
+				}
+				return nil, err
+			}
+			written = append(written, key)
+			if f.Suffix == "" && f.Ext != ".webp" {
+				stored.Key, stored.Size = key, int64(len(f.Data))
+			}
+		}
+		if opts.keepOriginal {
+			key := path.Join(originalsFolder, base+sniffed.Ext)
+			if err := s.Files.Put(ctx, key, bytes.NewReader(data), int64(len(data)), sniffed.ContentType); err != nil {
+				for _, done := range written {
+					s.Files.Delete(ctx, done)
+				}
+				return nil, err
+			}
+			stored.OriginalKey = key
+		}
+		stored.URL = s.publicFileURL(stored.Key)
+		asset := processed.Asset(func(suffix, ext string) string {
+			return s.publicFileURL(base + suffix + ext)
+		})
+		stored.Image = &asset
+		return stored, nil
+	}
+	if sniffed.ContentType == "image/gif" || sniffed.ContentType == "image/webp" {
+		stripped, err := utils.StripImageMetadata(data, sniffed.ContentType)
+		if err != nil {
+			return nil, &utils.UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s: image could not be decoded", file.Filename)}
+		}
+		data = stripped
+	}
+	ext := sniffed.Ext
+	if ext == "" {
+		ext = ".bin"
+	}
+	if err := s.Files.Put(ctx, base+ext, bytes.NewReader(data), int64(len(data)), sniffed.ContentType); err != nil {
+		return nil, err
+	}
+	return &storedUpload{
+		Key:         base + ext,
+		URL:         s.publicFileURL(base + ext),
+		ContentType: sniffed.ContentType,
+		Size:        int64(len(data)),
+		Width:       sniffed.Width,
+		Height:      sniffed.Height,
+	}, nil
+}
+
+// publicFileURL returns the stored URL for key. Private keys never get the
+// CDN base; they are served through signed /api/files/ URLs.
+func (s *Server) publicFileURL(key string) string {
+	if s.filesBaseURL != "" && !isPrivateKey(key) {
+		return s.filesBaseURL + "/" + key
+	}
+	return "/api/static/" + key
+}
+
+func (s *Server) fileKeyFromURL(publicURL string) (string, bool) {
+	prefixes := []string{"/api/static/"}
+	if s.filesBaseURL != "" {
+		prefixes = append(prefixes, s.filesBaseURL+"/")
+	}
+	for _, prefix := range prefixes {
+		if key := strings.TrimPrefix(publicURL, prefix); key != publicURL && key != "" {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// deleteMediaFiles removes the files of a media library item, including the
+// original kept for re-rendering.
+func (s *Server) deleteMediaFiles(item *models.MediaItem) {
+	s.deleteStaticFile(item.URL)
+	if item.OriginalKey == "" {
+		return
+	}
+	if err := s.Files.Delete(context.Background(), item.OriginalKey); err != nil {
+		log.Printf("failed deleting %s: %v", item.OriginalKey, err)
+	}
+}
+
+// deleteStaticFile removes an uploaded file and any image variants stored
+// next to it.
+func (s *Server) deleteStaticFile(publicPath string) {
+	key, ok := s.fileKeyFromURL(publicPath)
+	if !ok {
+		return
+	}
+	ctx := context.Background()
+	if err := s.Files.Delete(ctx, key); err != nil {
+		log.Printf("failed deleting %s: %v", key, err)
+		return
+	}
+	ext := path.Ext(key)
+	if ext != ".jpg" && ext != ".png" {
+		return
+	}
+	base := strings.TrimSuffix(key, ext)
+	variants, err := s.Files.List(ctx, base)
+	if err != nil {
+		log.Printf("failed listing variants of %s: %v", key, err)
+		return
+	}
+	for _, obj := range variants {
+		rest := strings.TrimPrefix(obj.Key, base)
+		if rest == ".webp" || strings.HasPrefix(rest, "-") {
+			s.Files.Delete(ctx, obj.Key)
+		}
+	}
+}
+
+// staticFiles serves public uploads straight from the file store. Private
+// folders are only reachable through signed URLs and directories are never
+// listed.
+func (s *Server) staticFiles() http.Handler {
+	cacheControl := fmt.Sprintf("public, max-age=%d", envInt("STATIC_CACHE_MAX_AGE", 86400))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/api/static/")
+		if key == "" || strings.HasSuffix(key, "/") || isPrivateKey(key) {
+			s.notFound(w)
+			return
+		}
+		s.serveStoredFile(w, r, key, cacheControl)
+	})
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const maxGalleryPageSize = 100
+
+type galleryQuery struct {
+	Section   string
+	Filter    string
+	Featured  bool
+	ServiceID uint
+	Limit     int
+	Offset    int
+}
+
+func (s *Server) galleryQueryFromRequest(r *http.Request) (galleryQuery, error) {
+	values := r.URL.Query()
+	q := galleryQuery{
+		Section:  strings.TrimSpace(strings.ToLower(values.Get("section"))),
+		Filter:   strings.TrimSpace(values.Get("filter")),
+		Featured: values.Get("featured") == "true",
+	}
+	if raw := strings.TrimSpace(values.Get("service")); raw != "" {
+		if id, err := parseID(raw); err == nil {
+			q.ServiceID = id
+		} else if svc, ok := s.visibleServiceBySlug(r, raw); ok {
+			q.ServiceID = svc.ID
+		} else {
+			return q, errors.New("unknown service")
+		}
+	}
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return q, errors.New("invalid limit")
+		}
+		q.Limit = min(limit, maxGalleryPageSize)
+	}
+	if raw := strings.TrimSpace(values.Get("offset")); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return q, errors.New("invalid offset")
+		}
+		q.Offset = offset
+	}
+	return q, nil
+}
+
+func (q galleryQuery) matches(item models.GalleryItem) bool {
+	if q.Featured && !item.Featured {
+		return false
+	}
+	if q.ServiceID != 0 && !slices.Contains(item.ServiceIDs, q.ServiceID) {
+		return false
+	}
+	if q.Filter != "" && !slices.ContainsFunc(item.Filters, func(f string) bool { return strings.EqualFold(f, q.Filter) }) {
+		return false
+	}
+	return true
+}
+
+// page slices items to the requested window. A zero limit returns everything
+// from the offset on.
+func (q galleryQuery) page(items []models.GalleryItem) []models.GalleryItem {
+	start := min(q.Offset, len(items))
+	end := len(items)
+	if q.Limit > 0 {
+		end = min(start+q.Limit, end)
+	}
+	return items[start:end]
+}
+
+// galleryServiceIDs parses service references from the gallery form. Values
+// may repeat or hold comma separated IDs.
+func (s *Server) galleryServiceIDs(values []string) ([]uint, error) {
+	var ids []uint
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := parseID(part)
+			if err != nil {
+				return nil, fmt.Errorf("invalid service id %q", part)
+			}
+			if _, ok := s.Store.GetServiceByID(id); !ok {
+				return nil, fmt.Errorf("service %d not found", id)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// relatedGalleryItems returns live portfolio work linked to a service in
+// curated order.
+func (s *Server) relatedGalleryItems(serviceID uint) []models.GalleryItem {
+	now := time.Now().UTC()
+	limit := envInt("SERVICE_RELATED_WORK_LIMIT", 6)
+	out := make([]models.GalleryItem, 0, limit)
+	for _, item := range s.Store.ListGalleryItems("") {
+		if len(out) == limit {
+			break
+		}
+		if storage.IsLive(item.Publishing, now) && slices.Contains(item.ServiceIDs, serviceID) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func (s *Server) handleAdminGalleryReorder(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload struct {
+		Items []storage.GalleryPosition `json:"items"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(payload.Items) == 0 {
+		s.writeErrorMsg(w, http.StatusBadRequest, "items are required")
+		return
+	}
+	seen := make(map[uint]bool, len(payload.Items))
+	for _, pos := range payload.Items {
+		if seen[pos.ID] {
+			s.writeErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("gallery item %d listed twice", pos.ID))
+			return
+		}
+		seen[pos.ID] = true
+	}
+	items, err := s.Store.ReorderGalleryItems(payload.Items)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.writeError(w, http.StatusNotFound, err)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"status": "reordered",
+		"items":  items,
+	})
+}
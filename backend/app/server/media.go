@@ -0,0 +1,340 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	defaultMediaPageSize = 50
+	maxMediaPageSize     = 200
+)
+
+// createMedia stores an upload and registers it in the media library.
+func (s *Server) createMedia(file *multipart.FileHeader, folder, uploader string, opts storeOptions) (*models.MediaItem, error) {
+	stored, err := s.storeUpload(file, folder, opts)
+	if err != nil {
+		return nil, err
+	}
+	item := &models.MediaItem{
+		Key:               stored.Key,
+		URL:               stored.URL,
+		ContentType:       stored.ContentType,
+		Size:              stored.Size,
+		Width:             stored.Width,
+		Height:            stored.Height,
+		FileName:          file.Filename,
+		UploadedBy:        uploader,
+		Image:             stored.Image,
+		OriginalKey:       stored.OriginalKey,
+		Watermarked:       stored.Watermarked,
+		PublicUseApproved: opts.publicUseApproved,
+	}
+	created, err := s.Store.CreateMediaItem(item)
+	if err != nil {
+		s.deleteMediaFiles(item)
+		return nil, err
+	}
+	return created, nil
+}
+
+// saveMedia is the form upload counterpart of saveUpload for files that
+// belong in the media library.
+func (s *Server) saveMedia(file *multipart.FileHeader, folder, uploader string) (string, error) {
+	item, err := s.createMedia(file, folder, uploader, storeOptions{})
+	if err != nil {
+		return "", err
+	}
+	return item.URL, nil
+}
+
+// discardUploads removes files uploaded during a request that failed before
+// anything referenced them.
+func (s *Server) discardUploads(urls ...string) {
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		item, err := s.Store.DiscardMediaByURL(url)
+		if err != nil {
+			log.Printf("failed discarding media %s: %v", url, err)
+		}
+		if item != nil {
+			s.deleteMediaFiles(item)
+			continue
+		}
+		s.deleteStaticFile(url)
+	}
+}
+
+// releaseUploads is called when an entity stops using files. Library items
+// are left for the media collector since they may be referenced elsewhere.
+func (s *Server) releaseUploads(urls ...string) {
+	for _, url := range urls {
+		if url == "" {
+			continue
+		}
+		if _, tracked := s.Store.MediaByURL(url); tracked {
+			continue
+		}
+		s.deleteStaticFile(url)
+	}
+}
+
+func droppedURLs(before, after []string) []string {
+	kept := make(map[string]bool, len(after))
+	for _, url := range after {
+		kept[url] = true
+	}
+	var out []string
+	for _, url := range before {
+		if url != "" && !kept[url] {
+			out = append(out, url)
+		}
+	}
+	return out
+}
+
+func serviceFileURLs(svc *models.Service) []string {
+	if svc == nil {
+		return nil
+	}
+	return append([]string{svc.Thumbnail}, svc.GalleryImages...)
+}
+
+func galleryFileURLs(item *models.GalleryItem) []string {
+	if item == nil {
+		return nil
+	}
+	out := []string{item.Thumbnail}
+	for _, asset := range item.Assets {
+		out = append(out, asset.URL)
+	}
+	return out
+}
+
+// mediaFromForm resolves library IDs given as repeated or comma separated
+// form values.
+func (s *Server) mediaFromForm(form *multipart.Form, key string) ([]*models.MediaItem, bool, error) {
+	values, provided := getAllFormValuesWithPresence(form, key, key+"[]")
+	if !provided {
+		return nil, false, nil
+	}
+	var items []*models.MediaItem
+	for _, value := range values {
+		for _, raw := range strings.Split(value, ",") {
+			raw = strings.TrimSpace(raw)
+			if raw == "" {
+				continue
+			}
+			item, err := s.mediaByRawID(raw)
+			if err != nil {
+				return nil, true, err
+			}
+			items = append(items, item)
+		}
+	}
+	return items, true, nil
+}
+
+func (s *Server) mediaByRawID(raw string) (*models.MediaItem, error) {
+	id, err := parseID(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid media id %q", raw)
+	}
+	return s.mediaByID(id)
+}
+
+func (s *Server) mediaByID(id uint) (*models.MediaItem, error) {
+	item, ok := s.Store.GetMediaItem(id)
+	if !ok {
+		return nil, fmt.Errorf("media %d not found", id)
+	}
+	return item, nil
+}
+
+func galleryAssetType(item *models.MediaItem) string {
+	if item.ContentType == "application/pdf" {
+		return "pdf"
+	}
+	return "image"
+}
+
+func (s *Server) handleAdminMedia(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		values := r.URL.Query()
+		q := storage.MediaQuery{
+			Text:   values.Get("q"),
+			Type:   strings.ToLower(strings.TrimSpace(values.Get("type"))),
+			Unused: values.Get("unused") == "true",
+			Limit:  defaultMediaPageSize,
+		}
+		if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+			limit, err := strconv.Atoi(raw)
+			if err != nil || limit <= 0 {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid limit")
+				return
+			}
+			q.Limit = min(limit, maxMediaPageSize)
+		}
+		if raw := strings.TrimSpace(values.Get("offset")); raw != "" {
+			offset, err := strconv.Atoi(raw)
+			if err != nil || offset < 0 {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid offset")
+				return
+			}
+			q.Offset = offset
+		}
+		items, total := s.Store.ListMedia(q)
+		s.writeJSON(w, http.StatusOK, map[string]any{
+			"items":  items,
+			"total":  total,
+			"limit":  q.Limit,
+			"offset": q.Offset,
+		})
+	case http.MethodPost:
+		if err := r.ParseMultipartForm(100 << 20); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		field := uploadField{name: "files", kinds: []string{utils.UploadImage, utils.UploadVideo, utils.UploadDocument}}
+		files := getFiles(r.MultipartForm, "files")
+		if len(files) == 0 {
+			s.writeErrorMsg(w, http.StatusBadRequest, "files are required")
+			return
+		}
+		if !s.checkUploads(w, r.MultipartForm, field) {
+			return
+		}
+		altText := getFormValue(r.MultipartForm, "alt_text")
+		caption := getFormValue(r.MultipartForm, "caption")
+		uploader := adminEmailFromContext(r.Context())
+		created := make([]*models.MediaItem, 0, len(files))
+		for _, file := range files {
+			item, err := s.createMedia(file, "media", uploader, storeOptions{})
+			if err == nil && (altText != "" || caption != "") {
+				item, err = s.Store.UpdateMediaItem(item.ID, storage.MediaUpdate{AltText: &altText, Caption: &caption})
+			}
+			if err != nil {
+				for _, done := range created {
+					s.discardUploads(done.URL)
+				}
+				s.writeUploadError(w, err, http.StatusInternalServerError)
+				return
+			}
+			created = append(created, item)
+		}
+		s.writeJSON(w, http.StatusCreated, map[string]any{"status": "created", "items": created})
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminMediaByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/media/"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid media id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		item, ok := s.Store.GetMediaItem(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, item)
+	case http.MethodPut, http.MethodPatch:
+		var payload struct {
+			AltText           *string `json:"alt_text"`
+			Caption           *string `json:"caption"`
+			PublicUseApproved *bool   `json:"public_use_approved"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		existing, ok := s.Store.GetMediaItem(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		item, err := s.Store.UpdateMediaItem(id, storage.MediaUpdate{AltText: payload.AltText, Caption: payload.Caption, PublicUseApproved: payload.PublicUseApproved})
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if item.OriginalKey != "" && item.PublicUseApproved != existing.PublicUseApproved {
+			if item, err = s.renderMedia(item); err != nil {
+				s.writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		s.writeJSON(w, http.StatusOK, item)
+	case http.MethodDelete:
+		deleted, err := s.Store.DeleteMediaItem(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				s.notFound(w)
+			case errors.Is(err, storage.ErrMediaInUse):
+				var refs []models.MediaReference
+				if item, ok := s.Store.GetMediaItem(id); ok {
+					refs = item.References
+				}
+				s.writeJSON(w, http.StatusConflict, map[string]any{"detail": err.Error(), "references": refs})
+			default:
+				s.writeError(w, http.StatusInternalServerError, err)
+			}
+			return
+		}
+		s.deleteMediaFiles(deleted)
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) startMediaGCLoop() {
+	if count, err := s.Store.BackfillMedia(s.fileKeyFromURL); err != nil {
+		log.Printf("media backfill failed: %v", err)
+	} else if count > 0 {
+		log.Printf("registered %d existing uploads in the media library", count)
+	}
+	if !envBool("MEDIA_GC_ENABLED", true) {
+		return
+	}
+	interval := envDurationMinutes("MEDIA_GC_INTERVAL_MINUTES", 6*time.Hour)
+	grace := time.Duration(envInt("MEDIA_GC_GRACE_HOURS", 168)) * time.Hour
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			removed, err := s.Store.CollectUnusedMedia(time.Now().UTC(), grace)
+			if err != nil {
+				log.Printf("media cleanup failed: %v", err)
+				continue
+			}
+			for i := range removed {
+				s.deleteMediaFiles(&removed[i])
+			}
+		}
+	}()
+}
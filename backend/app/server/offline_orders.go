@@ -0,0 +1,299 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+var adminOrderStatuses = map[string]struct{}{
+	"pending":     {},
+	"confirmed":   {},
+	"in_progress": {},
+	"done":        {},
+}
+
+type offlinePaymentPayload struct {
+	Mode            string `json:"mode"`
+	Method          string `json:"method"`
+	Reference       string `json:"reference"`
+	PaymentCategory string `json:"payment_category"`
+	PaymentChannel  string `json:"payment_channel"`
+}
+
+func (s *Server) handleAdminCreateOrder(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		ServiceID       uint                   `json:"service_id"`
+		Name            string                 `json:"customer_name"`
+		Email           string                 `json:"customer_email"`
+		Phone           string                 `json:"customer_phone"`
+		Notes           string                 `json:"notes"`
+		LineItems       []models.QuoteLineItem `json:"line_items"`
+		Amount          *float64               `json:"amount"`
+		DiscountAmount  float64                `json:"discount_amount"`
+		DiscountPercent float64                `json:"discount_percent"`
+		PromoCode       string                 `json:"promo_code"`
+		Status          string                 `json:"status"`
+		BookingStart    string                 `json:"booking_start"`
+		Payment         offlinePaymentPayload  `json:"payment"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Email = strings.TrimSpace(payload.Email)
+	if payload.Name == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "customer name is required")
+		return
+	}
+	if payload.Email != "" && !isValidEmail(payload.Email) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid customer email")
+		return
+	}
+	svc, ok := s.Store.GetServiceByID(payload.ServiceID)
+	if !ok {
+		s.writeErrorMsg(w, http.StatusNotFound, "Service not found")
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(payload.Status))
+	if status == "" {
+		status = "pending"
+	}
+	if _, ok := adminOrderStatuses[status]; !ok {
+		s.writeErrorMsg(w, http.StatusBadRequest, "unsupported order status")
+		return
+	}
+	items, total, err := normalizeLineItems(payload.LineItems)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	amount := svc.Price
+	switch {
+	case payload.Amount != nil:
+		amount = *payload.Amount
+	case len(items) > 0:
+		amount = total
+	}
+	if amount < 0 || payload.DiscountAmount < 0 || payload.DiscountPercent < 0 || payload.DiscountPercent > 100 {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid amount or discount")
+		return
+	}
+	discount := payload.DiscountAmount
+	if payload.DiscountPercent > 0 {
+		discount += math.Round(amount*payload.DiscountPercent) / 100
+	}
+	if discount > amount {
+		discount = amount
+	}
+
+	mode := strings.ToLower(strings.TrimSpace(payload.Payment.Mode))
+	var offlineMethod, paymentCategory, paymentChannel string
+	switch mode {
+	case "", "none":
+		mode = "none"
+	case "offline":
+		method, ok := storage.OfflinePaymentMethod(payload.Payment.Method)
+		if !ok {
+			s.writeErrorMsg(w, http.StatusBadRequest, "offline payment method must be cash or transfer")
+			return
+		}
+		offlineMethod = method
+	case "link":
+		if payload.Email == "" {
+			s.writeErrorMsg(w, http.StatusBadRequest, "customer email is required to send a payment link")
+			return
+		}
+		if strings.TrimSpace(payload.Payment.PaymentCategory) == "" {
+			s.writeErrorMsg(w, http.StatusBadRequest, "payment category is required")
+			return
+		}
+		paymentCategory, paymentChannel, err = normalizePaymentSelection(strings.TrimSpace(payload.Payment.PaymentCategory), strings.TrimSpace(payload.Payment.PaymentChannel))
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	default:
+		s.writeErrorMsg(w, http.StatusBadRequest, "payment mode must be none, offline or link")
+		return
+	}
+
+	order := &models.Order{
+		ServiceID:      svc.ID,
+		CustomerName:   payload.Name,
+		CustomerEmail:  payload.Email,
+		CustomerPhone:  strings.TrimSpace(payload.Phone),
+		Notes:          strings.TrimSpace(payload.Notes),
+		LineItems:      items,
+		Amount:         amount - discount,
+		DiscountAmount: discount,
+		PromoCode:      strings.TrimSpace(payload.PromoCode),
+		Status:         status,
+		Source:         storage.OrderSourceAdmin,
+	}
+	if raw := strings.TrimSpace(payload.BookingStart); raw != "" {
+		bookingStart, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid booking_start")
+			return
+		}
+		order.BookingStart = bookingStart.UTC()
+	}
+	created, err := s.Store.CreateOrder(order)
+	if err != nil {
+		code, msg := orderCreationError(err)
+		s.writeErrorMsg(w, code, msg)
+		return
+	}
+
+	var tx *models.PaymentTransaction
+	switch mode {
+	case "offline":
+		tx, created, err = s.recordOfflinePayment(created, offlineMethod, payload.Payment.Reference, status)
+		if err != nil {
+			log.Printf("failed to record offline payment for order %d: %v", created.ID, err)
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	case "link":
+		var updated *models.Order
+		tx, updated, err = s.createPaymentForOrder(r.Context(), created, paymentRequest{Category: paymentCategory, Channel: paymentChannel})
+		if err != nil {
+			log.Printf("failed to create payment link for order %d: %v", created.ID, err)
+			s.writeJSON(w, http.StatusCreated, map[string]any{
+				"order":         created,
+				"payment_error": "failed to create payment request",
+			})
+			return
+		}
+		if updated != nil {
+			created = updated
+		}
+		s.sendPaymentLinkEmail(created)
+	}
+	s.writeJSON(w, http.StatusCreated, s.orderPaymentResponse(created, tx))
+}
+
+func (s *Server) recordOfflinePayment(order *models.Order, method, reference, status string) (*models.PaymentTransaction, *models.Order, error) {
+	tx, updated, err := s.Store.RecordOfflinePayment(order.ID, method, reference)
+	if err != nil {
+		return nil, order, err
+	}
+	if status != "" && status != "pending" && !strings.EqualFold(updated.Status, status) {
+		if restored, err := s.Store.UpdateOrderStatus(order.ID, status); err == nil {
+			updated = restored
+		}
+	}
+	return tx, updated, nil
+}
+
+func (s *Server) sendPaymentLinkEmail(order *models.Order) {
+	if order == nil || strings.TrimSpace(order.CustomerEmail) == "" {
+		return
+	}
+	service, _ := s.Store.GetServiceByID(order.ServiceID)
+	subject, htmlBody, textBody, err := utils.BuildOrderConfirmationEmail(order, service, s.paymentPageURL(order.ID))
+	if err != nil {
+		log.Printf("Failed to build payment link email: %v", err)
+		return
+	}
+	go func() {
+		if err := utils.SendEmail(order.CustomerEmail, subject, htmlBody, textBody); err != nil {
+			log.Printf("Failed to send payment link email for order %d: %v", order.ID, err)
+		}
+	}()
+}
+
+func (s *Server) handleAdminOrderOfflinePayment(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload struct {
+		Method    string `json:"method"`
+		Reference string `json:"reference"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	method, ok := storage.OfflinePaymentMethod(payload.Method)
+	if !ok {
+		s.writeErrorMsg(w, http.StatusBadRequest, "offline payment method must be cash or transfer")
+		return
+	}
+	tx, order, err := s.Store.RecordOfflinePayment(id, method, payload.Reference)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			s.notFound(w)
+		case errors.Is(err, storage.ErrOrderAlreadyPaid):
+			s.writeErrorMsg(w, http.StatusConflict, err.Error())
+		default:
+			s.writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	s.processWaitlist()
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"order":       order,
+		"transaction": tx,
+	})
+}
+
+func (s *Server) handleAdminOrderPaymentLink(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload struct {
+		PaymentCategory string `json:"payment_category"`
+		PaymentChannel  string `json:"payment_channel"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	order, ok := s.Store.GetOrderByID(id)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if strings.TrimSpace(order.CustomerEmail) == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "order has no customer email")
+		return
+	}
+	if strings.TrimSpace(payload.PaymentCategory) == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "payment category is required")
+		return
+	}
+	category, channel, err := normalizePaymentSelection(strings.TrimSpace(payload.PaymentCategory), strings.TrimSpace(payload.PaymentChannel))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	tx, updated, err := s.createPaymentForOrder(r.Context(), order, paymentRequest{Category: category, Channel: channel})
+	if err != nil {
+		log.Printf("failed to create payment link for order %d: %v", order.ID, err)
+		if errors.Is(err, errPaymentWindowClosed) {
+			s.writeErrorMsg(w, http.StatusForbidden, "payment session is no longer available for this order")
+			return
+		}
+		s.writeErrorMsg(w, http.StatusBadGateway, "failed to create payment request")
+		return
+	}
+	if updated != nil {
+		order = updated
+	}
+	s.sendPaymentLinkEmail(order)
+	s.writeJSON(w, http.StatusOK, s.orderPaymentResponse(order, tx))
+}
@@ -0,0 +1,467 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const (
+	defaultOrderPageSize = 50
+	maxOrderPageSize     = 200
+)
+
+type adminOrderRow struct {
+	models.Order
+	Service           string                     `json:"service"`
+	LatestTransaction *models.PaymentTransaction `json:"latest_transaction,omitempty"`
+}
+
+type orderSortKey struct {
+	num  float64
+	text string
+}
+
+type orderSearchQuery struct {
+	Statuses        map[string]struct{}
+	PaymentStatuses map[string]struct{}
+	PaymentMethods  map[string]struct{}
+	ServiceIDs      map[uint]struct{}
+	Email           string
+	Name            string
+	Phone           string
+	PromoCode       string
+	Search          string
+	CreatedFrom     time.Time
+	CreatedTo       time.Time
+	UpdatedFrom     time.Time
+	UpdatedTo       time.Time
+	MinAmount       *float64
+	MaxAmount       *float64
+	Sort            string
+	Descending      bool
+	Limit           int
+	Cursor          *orderCursor
+	// All is set by ?all=true for older clients that expect the full
+	// filtered list as a bare array. Everyone else gets pages.
+	All bool
+}
+
+type orderCursor struct {
+	Sort string
+	ID   uint
+	Key  orderSortKey
+}
+
+var orderSortFields = map[string]struct{}{
+	"created_at":    {},
+	"updated_at":    {},
+	"amount":        {},
+	"customer_name": {},
+	"status":        {},
+	"id":            {},
+}
+
+func splitQueryList(values url.Values, key string) []string {
+	var out []string
+	for _, raw := range values[key] {
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+func lowerSet(items []string) map[string]struct{} {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		out[strings.ToLower(item)] = struct{}{}
+	}
+	return out
+}
+
+func parseOrderRangeTime(raw string, endOfDay bool) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", raw, storage.BookingLocation(nil))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", raw)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t.UTC(), nil
+}
+
+func parseOrderAmount(raw string) (*float64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		return nil, fmt.Errorf("invalid amount %q", raw)
+	}
+	return &v, nil
+}
+
+func parseOrderSearchQuery(values url.Values) (*orderSearchQuery, error) {
+	q := &orderSearchQuery{
+		Statuses:        lowerSet(splitQueryList(values, "status")),
+		PaymentStatuses: lowerSet(splitQueryList(values, "payment_status")),
+		PaymentMethods:  lowerSet(splitQueryList(values, "payment_method")),
+		Email:           strings.ToLower(strings.TrimSpace(values.Get("email"))),
+		Name:            strings.ToLower(strings.TrimSpace(values.Get("name"))),
+		Phone:           digitsOnly(values.Get("phone")),
+		PromoCode:       strings.TrimSpace(values.Get("promo_code")),
+		Search:          strings.ToLower(strings.TrimSpace(values.Get("q"))),
+		Sort:            strings.ToLower(strings.TrimSpace(values.Get("sort"))),
+		Descending:      !strings.EqualFold(strings.TrimSpace(values.Get("order")), "asc"),
+		Limit:           defaultOrderPageSize,
+	}
+	for _, raw := range splitQueryList(values, "service_id") {
+		id, err := parseID(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid service_id %q", raw)
+		}
+		if q.ServiceIDs == nil {
+			q.ServiceIDs = make(map[uint]struct{})
+		}
+		q.ServiceIDs[id] = struct{}{}
+	}
+	var err error
+	if q.CreatedFrom, err = parseOrderRangeTime(values.Get("created_from"), false); err != nil {
+		return nil, err
+	}
+	if q.CreatedTo, err = parseOrderRangeTime(values.Get("created_to"), true); err != nil {
+		return nil, err
+	}
+	if q.UpdatedFrom, err = parseOrderRangeTime(values.Get("updated_from"), false); err != nil {
+		return nil, err
+	}
+	if q.UpdatedTo, err = parseOrderRangeTime(values.Get("updated_to"), true); err != nil {
+		return nil, err
+	}
+	if q.MinAmount, err = parseOrderAmount(values.Get("amount_min")); err != nil {
+		return nil, err
+	}
+	if q.MaxAmount, err = parseOrderAmount(values.Get("amount_max")); err != nil {
+		return nil, err
+	}
+	if q.Sort == "" {
+		q.Sort = "created_at"
+	}
+	if _, ok := orderSortFields[q.Sort]; !ok {
+		return nil, fmt.Errorf("unsupported sort field %q", q.Sort)
+	}
+	if raw := strings.TrimSpace(values.Get("limit")); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q", raw)
+		}
+		if limit > maxOrderPageSize {
+			limit = maxOrderPageSize
+		}
+		q.Limit = limit
+	}
+	if raw := strings.TrimSpace(values.Get("cursor")); raw != "" {
+		cursor, err := decodeOrderCursor(raw)
+		if err != nil || cursor.Sort != q.Sort {
+			return nil, errors.New("invalid cursor")
+		}
+		q.Cursor = cursor
+	}
+	q.All = values.Get("all") == "true" && q.Cursor == nil && strings.TrimSpace(values.Get("limit")) == ""
+	return q, nil
+}
+
+func digitsOnly(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func orderSortValue(row *adminOrderRow, field string) orderSortKey {
+	switch field {
+	case "updated_at":
+		return orderSortKey{num: float64(row.UpdatedAt.UnixMicro())}
+	case "amount":
+		return orderSortKey{num: row.Amount}
+	case "customer_name":
+		return orderSortKey{text: strings.ToLower(strings.TrimSpace(row.CustomerName))}
+	case "status":
+		return orderSortKey{text: strings.ToLower(row.Status)}
+	case "id":
+		return orderSortKey{}
+	default:
+		return orderSortKey{num: float64(row.CreatedAt.UnixMicro())}
+	}
+}
+
+func compareOrderKeys(a orderSortKey, aID uint, b orderSortKey, bID uint) int {
+	switch {
+	case a.num < b.num:
+		return -1
+	case a.num > b.num:
+		return 1
+	case a.text < b.text:
+		return -1
+	case a.text > b.text:
+		return 1
+	case aID < bID:
+		return -1
+	case aID > bID:
+		return 1
+	}
+	return 0
+}
+
+func encodeOrderCursor(row *adminOrderRow, field string) string {
+	key := orderSortValue(row, field)
+	raw := fmt.Sprintf("%s|%d|%s|%s", field, row.ID, strconv.FormatFloat(key.num, 'f', -1, 64), key.text)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeOrderCursor(raw string) (*orderCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.SplitN(string(decoded), "|", 4)
+	if len(parts) != 4 {
+		return nil, errors.New("malformed cursor")
+	}
+	id, err := parseID(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	num, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return nil, err
+	}
+	return &orderCursor{Sort: parts[0], ID: id, Key: orderSortKey{num: num, text: parts[3]}}, nil
+}
+
+func (q *orderSearchQuery) matches(row *adminOrderRow, ignoreStatus bool) bool {
+	if !ignoreStatus && q.Statuses != nil {
+		if _, ok := q.Statuses[strings.ToLower(row.Status)]; !ok {
+			return false
+		}
+	}
+	if q.PaymentStatuses != nil {
+		paymentStatus := row.PaymentStatus
+		if paymentStatus == "" && row.LatestTransaction != nil {
+			paymentStatus = row.LatestTransaction.Status
+		}
+		if _, ok := q.PaymentStatuses[strings.ToLower(strings.TrimSpace(paymentStatus))]; !ok {
+			return false
+		}
+	}
+	if q.PaymentMethods != nil {
+		candidates := []string{row.PaymentMethod}
+		if row.LatestTransaction != nil {
+			candidates = append(candidates, row.LatestTransaction.Method, row.LatestTransaction.Channel)
+		}
+		found := false
+		for _, c := range candidates {
+			if _, ok := q.PaymentMethods[strings.ToLower(strings.TrimSpace(c))]; ok && c != "" {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.ServiceIDs != nil {
+		if _, ok := q.ServiceIDs[row.ServiceID]; !ok {
+			return false
+		}
+	}
+	if q.Email != "" && !strings.Contains(strings.ToLower(row.CustomerEmail), q.Email) {
+		return false
+	}
+	if q.Name != "" && !strings.Contains(strings.ToLower(row.CustomerName), q.Name) {
+		return false
+	}
+	if q.Phone != "" && !strings.Contains(digitsOnly(row.CustomerPhone), q.Phone) {
+		return false
+	}
+	if q.PromoCode != "" && !strings.EqualFold(row.PromoCode, q.PromoCode) {
+		return false
+	}
+	if !q.CreatedFrom.IsZero() && row.CreatedAt.Before(q.CreatedFrom) {
+		return false
+	}
+	if !q.CreatedTo.IsZero() && row.CreatedAt.After(q.CreatedTo) {
+		return false
+	}
+	if !q.UpdatedFrom.IsZero() && row.UpdatedAt.Before(q.UpdatedFrom) {
+		return false
+	}
+	if !q.UpdatedTo.IsZero() && row.UpdatedAt.After(q.UpdatedTo) {
+		return false
+	}
+	if q.MinAmount != nil && row.Amount < *q.MinAmount {
+		return false
+	}
+	if q.MaxAmount != nil && row.Amount > *q.MaxAmount {
+		return false
+	}
+	if q.Search != "" {
+		fields := []string{
+			strconv.FormatUint(uint64(row.ID), 10),
+			row.CustomerName,
+			row.CustomerEmail,
+			row.CustomerPhone,
+			row.Notes,
+			row.PromoCode,
+			row.PaymentReference,
+			row.Service,
+		}
+		if row.LatestTransaction != nil {
+			fields = append(fields, row.LatestTransaction.Reference, row.LatestTransaction.ExternalID)
+		}
+		found := false
+		for _, f := range fields {
+			if f != "" && strings.Contains(strings.ToLower(f), q.Search) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) buildAdminOrderRows(records []storage.OrderRecord) []adminOrderRow {
+	services := s.Store.ListServices()
+	svcMap := make(map[uint]string, len(services))
+	for _, svc := range services {
+		svcMap[svc.ID] = svc.Title
+	}
+	rows := make([]adminOrderRow, 0, len(records))
+	for _, rec := range records {
+		orderCopy := rec.Order
+		status, cancelReason := effectiveOrderStatus(&orderCopy, rec.LatestTransaction)
+		if status != "" && !strings.EqualFold(status, orderCopy.Status) {
+			orderCopy.Status = status
+		}
+		if cancelReason != "" && strings.TrimSpace(orderCopy.CancelReason) == "" {
+			orderCopy.CancelReason = cancelReason
+		}
+		rows = append(rows, adminOrderRow{
+			Order:             orderCopy,
+			Service:           svcMap[orderCopy.ServiceID],
+			LatestTransaction: rec.LatestTransaction,
+		})
+	}
+	return rows
+}
+
+func (s *Server) handleAdminOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		s.handleAdminCreateOrder(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	query, err := parseOrderSearchQuery(r.URL.Query())
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	rows := s.buildAdminOrderRows(s.Store.ListOrderRecords())
+	statusCounts := map[string]int{}
+	matched := make([]adminOrderRow, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		if !query.matches(row, true) {
+			continue
+		}
+		statusCounts["all"]++
+		statusCounts[strings.ToLower(row.Status)]++
+		if query.matches(row, false) {
+			matched = append(matched, *row)
+		}
+	}
+	keys := make([]orderSortKey, len(matched))
+	for i := range matched {
+		keys[i] = orderSortValue(&matched[i], query.Sort)
+	}
+	idx := make([]int, len(matched))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		cmp := compareOrderKeys(keys[idx[a]], matched[idx[a]].ID, keys[idx[b]], matched[idx[b]].ID)
+		if query.Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+	if query.All {
+		items := make([]adminOrderRow, 0, len(idx))
+		for _, i := range idx {
+			items = append(items, matched[i])
+		}
+		s.writeJSON(w, http.StatusOK, items)
+		return
+	}
+	start := 0
+	if query.Cursor != nil {
+		start = len(idx)
+		for pos, i := range idx {
+			cmp := compareOrderKeys(keys[i], matched[i].ID, query.Cursor.Key, query.Cursor.ID)
+			if (query.Descending && cmp < 0) || (!query.Descending && cmp > 0) {
+				start = pos
+				break
+			}
+		}
+	}
+	end := start + query.Limit
+	if end > len(idx) {
+		end = len(idx)
+	}
+	items := make([]adminOrderRow, 0, end-start)
+	for _, i := range idx[start:end] {
+		items = append(items, matched[i])
+	}
+	nextCursor := ""
+	if end < len(idx) && len(items) > 0 {
+		nextCursor = encodeOrderCursor(&items[len(items)-1], query.Sort)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"items":         items,
+		"total":         len(matched),
+		"next_cursor":   nextCursor,
+		"status_counts": statusCounts,
+	})
+}
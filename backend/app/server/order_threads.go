@@ -0,0 +1,636 @@
+package server
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"devara-creative-backend/app/auth"
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	orderMessageRoleCustomer = "customer"
+	orderMessageRoleAdmin    = "admin"
+	maxOrderMessageLength    = 5000
+	maxOrderMessageFiles     = 5
+)
+
+var attachmentField = uploadField{name: "attachments", kinds: []string{utils.UploadImage, utils.UploadDocument, utils.UploadVideo}}
+
+// orderThreadToken signs the order, the reader role and an expiry so reply
+// addresses and thread links stop working after ORDER_THREAD_TOKEN_TTL_DAYS.
+func (s *Server) orderThreadToken(orderID uint, role string) string {
+	ttl := time.Duration(envInt("ORDER_THREAD_TOKEN_TTL_DAYS", 90)) * 24 * time.Hour
+	return s.signOrderThreadToken(orderID, role, time.Now().Add(ttl).Unix())
+}
+
+func (s *Server) signOrderThreadToken(orderID uint, role string, expires int64) string {
+	roleCode := "c"
+	if role == orderMessageRoleAdmin {
+		roleCode = "a"
+	}
+	payload := fmt.Sprintf("%d-%s-%s", orderID, roleCode, strconv.FormatInt(expires, 36))
+	return payload + "-" + s.linkSignature("order-thread", payload)
+}
+
+func (s *Server) verifyOrderThreadToken(raw string) (uint, string, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(raw)), "-")
+	if len(parts) != 4 {
+		return 0, "", errors.New("invalid thread token")
+	}
+	id, err := parseID(parts[0])
+	if err != nil {
+		return 0, "", errors.New("invalid thread token")
+	}
+	role := ""
+	switch parts[1] {
+	case "c":
+		role = orderMessageRoleCustomer
+	case "a":
+		role = orderMessageRoleAdmin
+	default:
+		return 0, "", errors.New("invalid thread token")
+	}
+	expires, err := strconv.ParseInt(parts[2], 36, 64)
+	if err != nil {
+		return 0, "", errors.New("invalid thread token")
+	}
+	expected := s.signOrderThreadToken(id, role, expires)
+	if !hmac.Equal([]byte(expected), []byte(strings.Join(parts, "-"))) {
+		return 0, "", errors.New("thread token signature mismatch")
+	}
+	if time.Now().Unix() > expires {
+		return 0, "", errors.New("thread token has expired")
+	}
+	return id, role, nil
+}
+
+func orderThreadAdminName() string {
+	return envString("BRAND_NAME", envString("SITE_NAME", "Devara Creative"))
+}
+
+func (s *Server) orderReplyAddress(orderID uint, role string) string {
+	address := strings.TrimSpace(s.replyEmailAddress)
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return ""
+	}
+	local := address[:at]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	return local + "+" + s.orderThreadToken(orderID, role) + address[at:]
+}
+
+func (s *Server) orderThreadURL(orderID uint, role string) string {
+	if role == orderMessageRoleAdmin {
+		return s.frontendURL("/admin/orders", url.Values{"order_id": {strconv.FormatUint(uint64(orderID), 10)}})
+	}
+	return s.frontendURL(fmt.Sprintf("/orders/%d/status", orderID), url.Values{"token": {s.orderThreadToken(orderID, orderMessageRoleCustomer)}})
+}
+
+func (s *Server) authorizeOrderThread(r *http.Request, order *models.Order) bool {
+	if token := strings.TrimSpace(r.URL.Query().Get("token")); token != "" {
+		id, role, err := s.verifyOrderThreadToken(token)
+		return err == nil && id == order.ID && role == orderMessageRoleCustomer
+	}
+	token := s.accessTokenFromRequest(r)
+	if token == "" {
+		return false
+	}
+	claims, err := auth.ParseAccessToken(token, s.accessTokenSecret)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(strings.TrimSpace(claims.Email), strings.TrimSpace(order.CustomerEmail))
+}
+
+func (s *Server) handleOrderMessages(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/orders/")
+	markRead := strings.HasSuffix(path, "/messages/read")
+	idStr := strings.TrimSuffix(strings.TrimSuffix(path, "/read"), "/messages")
+	id, err := parseID(idStr)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid order id")
+		return
+	}
+	order, ok := s.Store.GetOrderByID(id)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if !s.authorizeOrderThread(r, order) {
+		s.writeErrorMsg(w, http.StatusForbidden, "access to this conversation is not allowed")
+		return
+	}
+	if markRead {
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.markOrderThreadRead(w, order.ID, orderMessageRoleCustomer)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.writeOrderThread(w, order.ID, orderMessageRoleCustomer, s.requestUserID(r))
+	case http.MethodPost:
+		msg, err := s.orderMessageFromRequest(r)
+		if err != nil {
+			s.writeUploadError(w, err, http.StatusBadRequest)
+			return
+		}
+		msg.OrderID = order.ID
+		msg.AuthorRole = orderMessageRoleCustomer
+		msg.AuthorName = order.CustomerName
+		msg.AuthorEmail = order.CustomerEmail
+		s.postOrderMessage(w, order, msg, s.requestUserID(r))
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminOrderMessages(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/orders/")
+	markRead := strings.HasSuffix(path, "/messages/read")
+	idStr := strings.TrimSuffix(strings.TrimSuffix(path, "/read"), "/messages")
+	id, err := parseID(idStr)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid order id")
+		return
+	}
+	order, ok := s.Store.GetOrderByID(id)
+	if !ok {
+		s.writeErrorMsg(w, http.StatusNotFound, "order not found")
+		return
+	}
+	if markRead {
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.markOrderThreadRead(w, order.ID, orderMessageRoleAdmin)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.writeOrderThread(w, order.ID, orderMessageRoleAdmin, 0)
+	case http.MethodPost:
+		msg, err := s.orderMessageFromRequest(r)
+		if err != nil {
+			s.writeUploadError(w, err, http.StatusBadRequest)
+			return
+		}
+		msg.OrderID = order.ID
+		msg.AuthorRole = orderMessageRoleAdmin
+		if msg.AuthorName == "" {
+			msg.AuthorName = orderThreadAdminName()
+		}
+		s.postOrderMessage(w, order, msg, 0)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminOrderThreads(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	threads := s.Store.ListOrderThreads(orderMessageRoleAdmin, r.URL.Query().Get("email"))
+	unread := 0
+	for _, thread := range threads {
+		unread += thread.UnreadCount
+		if thread.LastMessage != nil {
+			s.signMessageAttachments(thread.LastMessage, 0)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"threads":      threads,
+		"unread_total": unread,
+	})
+}
+
+// writeOrderThread responds with the conversation, attachment links signed
+// for the reader.
+func (s *Server) writeOrderThread(w http.ResponseWriter, orderID uint, readerRole string, userID uint) {
+	messages := s.Store.ListOrderMessages(orderID)
+	for i := range messages {
+		s.signMessageAttachments(&messages[i], userID)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"order_id":     orderID,
+		"messages":     messages,
+		"unread_count": s.Store.CountUnreadOrderMessages(orderID, readerRole),
+	})
+}
+
+func (s *Server) markOrderThreadRead(w http.ResponseWriter, orderID uint, readerRole string) {
+	updated, err := s.Store.MarkOrderMessagesRead(orderID, readerRole, time.Now().UTC())
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{"updated": updated})
+}
+
+func (s *Server) orderMessageFromRequest(r *http.Request) (*models.OrderMessage, error) {
+	msg := &models.OrderMessage{Source: "web"}
+	if !strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "multipart/") {
+		var payload struct {
+			Body       string `json:"body"`
+			AuthorName string `json:"author_name"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			return nil, err
+		}
+		msg.Body = strings.TrimSpace(payload.Body)
+		msg.AuthorName = strings.TrimSpace(payload.AuthorName)
+	} else {
+		if err := r.ParseMultipartForm(50 << 20); err != nil {
+			return nil, err
+		}
+		defer r.MultipartForm.RemoveAll()
+		msg.Body = strings.TrimSpace(getFormValue(r.MultipartForm, "body"))
+		msg.AuthorName = strings.TrimSpace(getFormValue(r.MultipartForm, "author_name"))
+		files := getFiles(r.MultipartForm, "attachments")
+		if len(files) > maxOrderMessageFiles {
+			return nil, fmt.Errorf("maksimal %d lampiran per pesan", maxOrderMessageFiles)
+		}
+		for _, file := range files {
+			if err := s.checkUpload(file, attachmentField); err != nil {
+				return nil, err
+			}
+		}
+		for _, file := range files {
+			attachment, err := s.saveOrderMessageAttachment(file)
+			if err != nil {
+				for _, saved := range msg.Attachments {
+					s.deleteStaticFile(saved.URL)
+				}
+				return nil, err
+			}
+			msg.Attachments = append(msg.Attachments, attachment)
+		}
+	}
+	if msg.Body == "" && len(msg.Attachments) == 0 {
+		return nil, errors.New("Pesan tidak boleh kosong.")
+	}
+	if utf8.RuneCountInString(msg.Body) > maxOrderMessageLength {
+		for _, saved := range msg.Attachments {
+			s.deleteStaticFile(saved.URL)
+		}
+		return nil, fmt.Errorf("Pesan terlalu panjang (maks %d karakter).", maxOrderMessageLength)
+	}
+	return msg, nil
+}
+
+func (s *Server) saveOrderMessageAttachment(file *multipart.FileHeader) (models.OrderMessageAttachment, error) {
+	sniffed, err := utils.SniffUpload(file)
+	if err != nil {
+		return models.OrderMessageAttachment{}, err
+	}
+	publicURL, err := s.saveUpload(file, "threads", nil)
+	if err != nil {
+		return models.OrderMessageAttachment{}, err
+	}
+	return models.OrderMessageAttachment{
+		URL:         publicURL,
+		Name:        filepath.Base(file.Filename),
+		ContentType: sniffed.ContentType,
+		Size:        file.Size,
+	}, nil
+}
+
+func (s *Server) postOrderMessage(w http.ResponseWriter, order *models.Order, msg *models.OrderMessage, userID uint) {
+	created, err := s.Store.CreateOrderMessage(msg)
+	if err != nil {
+		for _, saved := range msg.Attachments {
+			s.deleteStaticFile(saved.URL)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.notifyOrderMessage(order, created)
+	s.signMessageAttachments(created, userID)
+	s.writeJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) notifyOrderMessage(order *models.Order, msg *models.OrderMessage) {
+	recipient := strings.TrimSpace(order.CustomerEmail)
+	recipientRole := orderMessageRoleCustomer
+	if msg.AuthorRole != orderMessageRoleAdmin {
+		recipient = strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))
+		recipientRole = orderMessageRoleAdmin
+	}
+	if recipient == "" {
+		return
+	}
+	service, _ := s.Store.GetServiceByID(order.ServiceID)
+	subject, htmlBody, textBody, err := utils.BuildOrderMessageEmail(order, service, msg, s.orderThreadURL(order.ID, recipientRole))
+	if err != nil {
+		log.Printf("Failed to build order message email: %v", err)
+		return
+	}
+	replyTo := s.orderReplyAddress(order.ID, recipientRole)
+	go func() {
+		if err := utils.SendEmailWithReplyTo(recipient, replyTo, subject, htmlBody, textBody); err != nil {
+			log.Printf("Failed to send order message notification for order %d: %v", order.ID, err)
+		}
+	}()
+}
+
+type inboundMail struct {
+	To      []string
+	From    string
+	Subject string
+	Text    string
+	// AuthResults is the Authentication-Results header added by the
+	// receiving mail server.
+	AuthResults string
+}
+
+func (s *Server) handleInboundMail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	token := strings.TrimSpace(s.inboundMailToken)
+	if token == "" {
+		s.writeErrorMsg(w, http.StatusServiceUnavailable, "inbound mail is not configured")
+		return
+	}
+	if !hmac.Equal([]byte(strings.TrimSpace(r.Header.Get("X-Inbound-Token"))), []byte(token)) {
+		s.writeErrorMsg(w, http.StatusUnauthorized, "invalid inbound token")
+		return
+	}
+	inbound, err := s.decodeInboundMail(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var orderID uint
+	role := ""
+	for _, to := range inbound.To {
+		if token := replyTokenFromAddress(to); token != "" {
+			if id, tokenRole, err := s.verifyOrderThreadToken(token); err == nil {
+				orderID, role = id, tokenRole
+				break
+			}
+		}
+	}
+	if orderID == 0 {
+		s.writeErrorMsg(w, http.StatusNotFound, "no conversation matches the recipient address")
+		return
+	}
+	order, ok := s.Store.GetOrderByID(orderID)
+	if !ok {
+		s.writeErrorMsg(w, http.StatusNotFound, "order not found")
+		return
+	}
+	fromName, fromEmail := "", ""
+	if addr, err := mail.ParseAddress(inbound.From); err == nil {
+		fromName, fromEmail = strings.TrimSpace(addr.Name), strings.TrimSpace(addr.Address)
+	}
+	if !senderVerified(inbound.AuthResults, fromEmail) {
+		s.writeErrorMsg(w, http.StatusForbidden, "sender address could not be verified")
+		return
+	}
+	if role == orderMessageRoleCustomer && !strings.EqualFold(fromEmail, strings.TrimSpace(order.CustomerEmail)) {
+		s.writeErrorMsg(w, http.StatusForbidden, "sender does not match the order customer")
+		return
+	}
+	if role == orderMessageRoleAdmin && !s.isAdminAddress(fromEmail) {
+		s.writeErrorMsg(w, http.StatusForbidden, "sender is not a studio admin")
+		return
+	}
+	body := stripQuotedReply(inbound.Text)
+	if body == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "empty reply body")
+		return
+	}
+	if utf8.RuneCountInString(body) > maxOrderMessageLength {
+		body = string([]rune(body)[:maxOrderMessageLength])
+	}
+	msg := &models.OrderMessage{
+		OrderID:     order.ID,
+		AuthorRole:  role,
+		AuthorName:  fromName,
+		AuthorEmail: fromEmail,
+		Body:        body,
+		Source:      "email",
+	}
+	if role == orderMessageRoleCustomer && msg.AuthorName == "" {
+		msg.AuthorName = order.CustomerName
+	}
+	if role == orderMessageRoleAdmin && msg.AuthorName == "" {
+		msg.AuthorName = orderThreadAdminName()
+	}
+	s.postOrderMessage(w, order, msg, 0)
+}
+
+// isAdminAddress reports whether email belongs to an admin account or the
+// configured ADMIN_EMAIL.
+func (s *Server) isAdminAddress(email string) bool {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return false
+	}
+	if strings.EqualFold(email, strings.TrimSpace(os.Getenv("ADMIN_EMAIL"))) {
+		return true
+	}
+	_, ok := s.Store.FindAdminByEmail(email)
+	return ok
+}
+
+// senderVerified reports whether the receiving mail server authenticated the
+// From address: DMARC passed, or a DKIM signature of the sender's domain
+// verified. INBOUND_MAIL_REQUIRE_AUTH=false disables the check for providers
+// that do not forward authentication results.
+func senderVerified(results, fromEmail string) bool {
+	if !envBool("INBOUND_MAIL_REQUIRE_AUTH", true) {
+		return true
+	}
+	at := strings.LastIndex(fromEmail, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(fromEmail[at+1:])
+	for _, result := range strings.Split(strings.ToLower(results), ";") {
+		fields := strings.Fields(result)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "dmarc=pass":
+			return true
+		case "dkim=pass":
+			for _, field := range fields[1:] {
+				if d, ok := strings.CutPrefix(field, "header.d="); ok && (d == domain || strings.HasSuffix(domain, "."+d)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) decodeInboundMail(r *http.Request) (*inboundMail, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var payload struct {
+			To          string `json:"to"`
+			From        string `json:"from"`
+			Subject     string `json:"subject"`
+			Text        string `json:"text"`
+			AuthResults string `json:"authentication_results"`
+		}
+		defer r.Body.Close()
+		if err := json.NewDecoder(io.LimitReader(r.Body, 10<<20)).Decode(&payload); err != nil {
+			return nil, err
+		}
+		return &inboundMail{
+			To:          splitAddressList(payload.To),
+			From:        payload.From,
+			Subject:     payload.Subject,
+			Text:        payload.Text,
+			AuthResults: payload.AuthResults,
+		}, nil
+	}
+	defer r.Body.Close()
+	msg, err := mail.ReadMessage(bufio.NewReader(io.LimitReader(r.Body, 25<<20)))
+	if err != nil {
+		return nil, err
+	}
+	recipients := splitAddressList(msg.Header.Get("To"))
+	recipients = append(recipients, splitAddressList(msg.Header.Get("Cc"))...)
+	recipients = append(recipients, splitAddressList(msg.Header.Get("Delivered-To"))...)
+	text, err := plainTextFromMail(msg.Header.Get("Content-Type"), msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	subject := msg.Header.Get("Subject")
+	if decoded, err := new(mime.WordDecoder).DecodeHeader(subject); err == nil {
+		subject = decoded
+	}
+	return &inboundMail{
+		To:      recipients,
+		From:    msg.Header.Get("From"),
+		Subject: subject,
+		Text:    text,
+		// The topmost header is the one added by our own mail server.
+		AuthResults: msg.Header.Get("Authentication-Results"),
+	}, nil
+}
+
+func plainTextFromMail(contentType string, body io.Reader) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType == "" {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return "", nil
+			}
+			if err != nil {
+				return "", err
+			}
+			text, err := plainTextFromMail(part.Header.Get("Content-Type"), part)
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(text) != "" {
+				return text, nil
+			}
+		}
+	}
+	if mediaType != "text/plain" {
+		return "", nil
+	}
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+func splitAddressList(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	if list, err := mail.ParseAddressList(raw); err == nil {
+		out := make([]string, 0, len(list))
+		for _, addr := range list {
+			out = append(out, addr.Address)
+		}
+		return out
+	}
+	out := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+func replyTokenFromAddress(address string) string {
+	address = strings.TrimSpace(address)
+	at := strings.LastIndex(address, "@")
+	if at <= 0 {
+		return ""
+	}
+	local := address[:at]
+	plus := strings.Index(local, "+")
+	if plus < 0 {
+		return ""
+	}
+	return local[plus+1:]
+}
+
+func stripQuotedReply(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if strings.HasPrefix(lower, "-----original message-----") || trimmed == "--" || trimmed == "-- " {
+			break
+		}
+		if (strings.HasPrefix(lower, "on ") && strings.HasSuffix(lower, "wrote:")) ||
+			(strings.HasPrefix(lower, "pada ") && strings.HasSuffix(lower, "menulis:")) {
+			break
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/auth"
+	"devara-creative-backend/app/filestore"
+	"devara-creative-backend/app/models"
+)
+
+const privateFilePurpose = "private-file"
+
+// isPrivateKey reports whether key is in one of the private folders, which
+// are only served through signed URLs.
+func isPrivateKey(key string) bool {
+	return filestore.IsPrivateKey(key)
+}
+
+func (s *Server) fileSignature(key string, expires int64, userID uint) string {
+	return s.linkSignature(privateFilePurpose, fmt.Sprintf("%s|%d|%d", key, expires, userID))
+}
+
+// signedFileURL returns a temporary URL for a private file. A non-zero userID
+// restricts the URL to that signed-in user.
+func (s *Server) signedFileURL(key string, userID uint) string {
+	expires := time.Now().Add(envDurationMinutes("PRIVATE_FILE_URL_TTL_MINUTES", time.Hour)).Unix()
+	query := url.Values{
+		"expires": {strconv.FormatInt(expires, 10)},
+		"sig":     {s.fileSignature(key, expires, userID)},
+	}
+	if userID != 0 {
+		query.Set("user", strconv.FormatUint(uint64(userID), 10))
+	}
+	return "/api/files/" + key + "?" + query.Encode()
+}
+
+// signStoredURL swaps a stored upload URL for a signed one. URLs that do not
+// point into the file store are returned unchanged.
+func (s *Server) signStoredURL(stored string, userID uint) string {
+	key, ok := s.fileKeyFromURL(stored)
+	if !ok {
+		return stored
+	}
+	return s.signedFileURL(key, userID)
+}
+
+func (s *Server) signMessageAttachments(msg *models.OrderMessage, userID uint) {
+	for i := range msg.Attachments {
+		msg.Attachments[i].URL = s.signStoredURL(msg.Attachments[i].URL, userID)
+	}
+}
+
+// requestUserID identifies the signed-in customer from the access token or,
+// for plain browser requests, the session cookie.
+func (s *Server) requestUserID(r *http.Request) uint {
+	if token := s.accessTokenFromRequest(r); token != "" {
+		if claims, err := auth.ParseAccessToken(token, s.accessTokenSecret); err == nil {
+			return claims.UserID
+		}
+	}
+	if claims, _, err := s.readRefreshToken(r); err == nil {
+		return claims.UserID
+	}
+	return 0
+}
+
+func (s *Server) handlePrivateFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, "/api/files/")
+	query := r.URL.Query()
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	var userID uint
+	if raw := query.Get("user"); err == nil && raw != "" {
+		userID, err = parseID(raw)
+	}
+	if err != nil || key == "" || !hmac.Equal([]byte(s.fileSignature(key, expires, userID)), []byte(query.Get("sig"))) {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid file link")
+		return
+	}
+	remaining := time.Until(time.Unix(expires, 0))
+	if remaining <= 0 {
+		s.writeErrorMsg(w, http.StatusForbidden, "file link has expired")
+		return
+	}
+	if userID != 0 && s.requestUserID(r) != userID {
+		s.writeErrorMsg(w, http.StatusForbidden, "file link belongs to another account")
+		return
+	}
+	s.serveStoredFile(w, r, key, fmt.Sprintf("private, max-age=%d", int(remaining.Seconds())))
+}
+
+// serveStoredFile writes a file from the store with validators so clients can
+// revalidate instead of downloading it again.
+func (s *Server) serveStoredFile(w http.ResponseWriter, r *http.Request, key, cacheControl string) {
+	body, obj, err := s.Files.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) || errors.Is(err, filestore.ErrInvalidKey) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	defer body.Close()
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	etag := fileETag(obj)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !obj.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, obj.ModTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if obj.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		io.Copy(w, body)
+	}
+}
+
+func fileETag(obj *filestore.Object) string {
+	if obj.ModTime.IsZero() && obj.Size == 0 {
+		return ""
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, obj.ModTime.UnixNano(), obj.Size)
+}
+
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modTime.IsZero() && !modTime.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,665 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"devara-creative-backend/app/auth"
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	proofingPurpose       = "proofing"
+	proofingAccessPurpose = "proofing-access"
+	proofingAccessHeader  = "X-Proofing-Access"
+)
+
+var proofingImageField = uploadField{name: "images", kinds: []string{utils.UploadImage}, types: []string{"image/jpeg", "image/png"}}
+
+func (s *Server) proofingURL(id uint) string {
+	return s.frontendURL(fmt.Sprintf("/proofing/%d", id), url.Values{"token": {s.signLinkToken(proofingPurpose, id)}})
+}
+
+// proofingAccessKey is handed out after a successful password check. It is
+// bound to the password hash so changing the password revokes old keys.
+func (s *Server) proofingAccessKey(g *models.ProofingGallery) string {
+	return s.linkSignature(proofingAccessPurpose, fmt.Sprintf("%d|%s", g.ID, g.PasswordHash))
+}
+
+// proofingTokenValid reports whether the request carries the signed link
+// token of the gallery. Every gallery requires it; password protected ones
+// also need the access key from a successful unlock.
+func (s *Server) proofingTokenValid(r *http.Request, g *models.ProofingGallery) bool {
+	tokenID, err := s.verifyLinkToken(proofingPurpose, r.URL.Query().Get("token"))
+	return err == nil && tokenID == g.ID
+}
+
+func (s *Server) proofingAccessAllowed(r *http.Request, g *models.ProofingGallery) bool {
+	if !s.proofingTokenValid(r, g) {
+		return false
+	}
+	if g.PasswordHash != "" {
+		key := r.Header.Get(proofingAccessHeader)
+		if key == "" {
+			key = r.URL.Query().Get("access")
+		}
+		return key != "" && hmac.Equal([]byte(key), []byte(s.proofingAccessKey(g)))
+	}
+	return true
+}
+
+// attemptLimiter counts failed attempts per key within a sliding window.
+type attemptLimiter struct {
+	mu       sync.Mutex
+	failures map[string][]time.Time
+}
+
+func (l *attemptLimiter) recent(key string, window time.Duration, now time.Time) []time.Time {
+	kept := l.failures[key][:0]
+	for _, at := range l.failures[key] {
+		if now.Sub(at) < window {
+			kept = append(kept, at)
+		}
+	}
+	if len(kept) == 0 {
+		delete(l.failures, key)
+		return nil
+	}
+	l.failures[key] = kept
+	return kept
+}
+
+// allowed reports whether key has fewer than limit failures in the window.
+func (l *attemptLimiter) allowed(key string, limit int, window time.Duration, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.recent(key, window, now)) < limit
+}
+
+func (l *attemptLimiter) fail(key string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.failures == nil {
+		l.failures = make(map[string][]time.Time)
+	}
+	l.failures[key] = append(l.failures[key], now)
+}
+
+func (l *attemptLimiter) reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, key)
+}
+
+func (s *Server) proofingClientView(g *models.ProofingGallery) map[string]any {
+	view := *g
+	view.PasswordHash = ""
+	view.CreatedBy = ""
+	view.Images = make([]models.ProofingImage, len(g.Images))
+	selected := 0
+	for i, img := range g.Images {
+		img.PreviewURL = s.signedFileURL(img.PreviewKey, 0)
+		img.Key, img.URL, img.PreviewKey = "", "", ""
+		view.Images[i] = img
+		if img.Selected {
+			selected++
+		}
+	}
+	response := map[string]any{"gallery": &view, "selected": selected}
+	if g.SelectionLimit > 0 {
+		response["remaining"] = max(g.SelectionLimit-selected, 0)
+	}
+	return response
+}
+
+func (s *Server) proofingAdminView(g *models.ProofingGallery) map[string]any {
+	view := *g
+	view.PasswordHash = ""
+	view.Images = make([]models.ProofingImage, len(g.Images))
+	for i, img := range g.Images {
+		img.URL = s.signedFileURL(img.Key, 0)
+		img.PreviewURL = s.signedFileURL(img.PreviewKey, 0)
+		view.Images[i] = img
+	}
+	return map[string]any{"gallery": &view, "share_url": s.proofingURL(g.ID)}
+}
+
+func (s *Server) writeProofingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.notFound(w)
+	case errors.Is(err, storage.ErrProofingOrderNotFound):
+		s.writeErrorMsg(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, storage.ErrProofingExpired):
+		s.writeErrorMsg(w, http.StatusGone, err.Error())
+	case errors.Is(err, storage.ErrProofingNotOpen), errors.Is(err, storage.ErrProofingLimitReached):
+		s.writeErrorMsg(w, http.StatusConflict, err.Error())
+	case errors.Is(err, storage.ErrProofingEmptySelection):
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+	default:
+		s.writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (s *Server) handleProofingRoutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/proofing/"), "/"), "/")
+	id, err := parseID(parts[0])
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid gallery id")
+		return
+	}
+	gallery, ok := s.Store.GetProofingGallery(id)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	if len(parts) == 2 && parts[1] == "unlock" {
+		s.handleProofingUnlock(w, r, gallery)
+		return
+	}
+	if !s.proofingAccessAllowed(r, gallery) {
+		if gallery.PasswordProtected && s.proofingTokenValid(r, gallery) {
+			s.writeJSON(w, http.StatusUnauthorized, map[string]any{"detail": "password required", "password_required": true})
+			return
+		}
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid or missing proofing token")
+		return
+	}
+	now := time.Now().UTC()
+	switch {
+	case len(parts) == 1:
+		if r.Method != http.MethodGet {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		if !gallery.ExpiresAt.IsZero() && now.After(gallery.ExpiresAt) {
+			s.writeErrorMsg(w, http.StatusGone, storage.ErrProofingExpired.Error())
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.proofingClientView(gallery))
+	case len(parts) == 2 && parts[1] == "submit":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.handleProofingSubmit(w, r, id, now)
+	case len(parts) == 3 && parts[1] == "images":
+		if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		imageID, err := parseID(parts[2])
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid image id")
+			return
+		}
+		var payload struct {
+			Favorite *bool `json:"favorite"`
+			Selected *bool `json:"selected"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		updated, err := s.Store.MarkProofingImage(id, imageID, storage.ProofingMark{Favorite: payload.Favorite, Selected: payload.Selected}, now)
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.proofingClientView(updated))
+	case len(parts) == 4 && parts[1] == "images" && parts[3] == "comments":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		imageID, err := parseID(parts[2])
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid image id")
+			return
+		}
+		body, ok := s.decodeProofingComment(w, r)
+		if !ok {
+			return
+		}
+		author := "Klien"
+		if order, ok := s.Store.GetOrderByID(gallery.OrderID); ok && strings.TrimSpace(order.CustomerName) != "" {
+			author = order.CustomerName
+		}
+		updated, err := s.Store.AddProofingComment(id, imageID, models.ProofingComment{Author: author, Body: body, CreatedAt: now})
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, s.proofingClientView(updated))
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) handleProofingUnlock(w http.ResponseWriter, r *http.Request, gallery *models.ProofingGallery) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload struct {
+		Password string `json:"password"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.proofingTokenValid(r, gallery) {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid or missing proofing token")
+		return
+	}
+	if !gallery.PasswordProtected {
+		s.writeErrorMsg(w, http.StatusBadRequest, "gallery is not password protected")
+		return
+	}
+	now := time.Now()
+	limitKey := fmt.Sprintf("%d|%s", gallery.ID, clientIPFromRequest(r))
+	window := envDurationMinutes("PROOFING_UNLOCK_WINDOW_MINUTES", 15*time.Minute)
+	if !s.proofingUnlocks.allowed(limitKey, envInt("PROOFING_UNLOCK_MAX_ATTEMPTS", 5), window, now) {
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(window.Seconds())))
+		s.writeErrorMsg(w, http.StatusTooManyRequests, "too many unlock attempts, try again later")
+		return
+	}
+	if !auth.CheckPassword(gallery.PasswordHash, payload.Password) {
+		s.proofingUnlocks.fail(limitKey, now)
+		s.writeErrorMsg(w, http.StatusUnauthorized, "incorrect password")
+		return
+	}
+	s.proofingUnlocks.reset(limitKey)
+	s.writeJSON(w, http.StatusOK, map[string]string{"access": s.proofingAccessKey(gallery)})
+}
+
+func (s *Server) handleProofingSubmit(w http.ResponseWriter, r *http.Request, id uint, now time.Time) {
+	var payload struct {
+		Note string `json:"note"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	gallery, order, err := s.Store.SubmitProofingSelection(id, payload.Note, now)
+	if err != nil {
+		s.writeProofingError(w, err)
+		return
+	}
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" && order != nil {
+		subject, htmlBody, textBody, err := utils.BuildProofingSubmittedEmail(order, gallery)
+		if err != nil {
+			log.Printf("Failed to build proofing submission email: %v", err)
+		} else {
+			go func() {
+				if err := utils.SendEmail(adminEmail, subject, htmlBody, textBody); err != nil {
+					log.Printf("Failed to send proofing submission email for gallery %d: %v", gallery.ID, err)
+				}
+			}()
+		}
+	}
+	s.writeJSON(w, http.StatusOK, s.proofingClientView(gallery))
+}
+
+func (s *Server) decodeProofingComment(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var payload struct {
+		Body string `json:"body"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return "", false
+	}
+	body := strings.TrimSpace(payload.Body)
+	if body == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "comment body is required")
+		return "", false
+	}
+	return body, true
+}
+
+func (s *Server) handleAdminProofing(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var orderID uint
+		if raw := strings.TrimSpace(r.URL.Query().Get("order_id")); raw != "" {
+			id, err := parseID(raw)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid order_id")
+				return
+			}
+			orderID = id
+		}
+		galleries := s.Store.ListProofingGalleries(orderID)
+		for i := range galleries {
+			galleries[i].PasswordHash = ""
+		}
+		s.writeJSON(w, http.StatusOK, galleries)
+	case http.MethodPost:
+		var payload struct {
+			OrderID        uint   `json:"order_id"`
+			Title          string `json:"title"`
+			Message        string `json:"message"`
+			SelectionLimit int    `json:"selection_limit"`
+			Password       string `json:"password"`
+			ExpiresAt      string `json:"expires_at"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if payload.OrderID == 0 {
+			s.writeErrorMsg(w, http.StatusBadRequest, "order_id is required")
+			return
+		}
+		gallery := &models.ProofingGallery{
+			OrderID:        payload.OrderID,
+			Title:          payload.Title,
+			Message:        payload.Message,
+			SelectionLimit: payload.SelectionLimit,
+			CreatedBy:      adminEmailFromContext(r.Context()),
+		}
+		if payload.Password != "" {
+			gallery.PasswordHash = auth.HashPassword(payload.Password)
+		}
+		if raw := strings.TrimSpace(payload.ExpiresAt); raw != "" {
+			expiresAt, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid expires_at")
+				return
+			}
+			gallery.ExpiresAt = expiresAt.UTC()
+		}
+		created, err := s.Store.CreateProofingGallery(gallery)
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, s.proofingAdminView(created))
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminProofingByID(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/proofing/"), "/"), "/")
+	id, err := parseID(parts[0])
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid gallery id")
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		s.handleAdminProofingGallery(w, r, id)
+	case len(parts) == 2 && parts[1] == "send":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.handleAdminProofingSend(w, id)
+	case len(parts) == 2 && parts[1] == "images":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.handleAdminProofingUpload(w, r, id)
+	case len(parts) == 3 && parts[1] == "images":
+		if r.Method != http.MethodDelete {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		imageID, err := parseID(parts[2])
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid image id")
+			return
+		}
+		removed, err := s.Store.RemoveProofingImage(id, imageID)
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.deleteProofingFiles(*removed)
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	case len(parts) == 4 && parts[1] == "images" && parts[3] == "comments":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		imageID, err := parseID(parts[2])
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid image id")
+			return
+		}
+		body, ok := s.decodeProofingComment(w, r)
+		if !ok {
+			return
+		}
+		updated, err := s.Store.AddProofingComment(id, imageID, models.ProofingComment{
+			Author:     adminEmailFromContext(r.Context()),
+			FromStudio: true,
+			Body:       body,
+			CreatedAt:  time.Now().UTC(),
+		})
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusCreated, s.proofingAdminView(updated))
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) handleAdminProofingGallery(w http.ResponseWriter, r *http.Request, id uint) {
+	switch r.Method {
+	case http.MethodGet:
+		gallery, ok := s.Store.GetProofingGallery(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.proofingAdminView(gallery))
+	case http.MethodPut, http.MethodPatch:
+		var payload struct {
+			Title          *string `json:"title"`
+			Message        *string `json:"message"`
+			Status         *string `json:"status"`
+			SelectionLimit *int    `json:"selection_limit"`
+			Password       *string `json:"password"`
+			ExpiresAt      *string `json:"expires_at"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		update := storage.ProofingGalleryUpdate{
+			Title:          payload.Title,
+			Message:        payload.Message,
+			Status:         payload.Status,
+			SelectionLimit: payload.SelectionLimit,
+		}
+		if payload.Password != nil {
+			hash := ""
+			if *payload.Password != "" {
+				hash = auth.HashPassword(*payload.Password)
+			}
+			update.PasswordHash = &hash
+		}
+		if payload.ExpiresAt != nil {
+			var expiresAt time.Time
+			if raw := strings.TrimSpace(*payload.ExpiresAt); raw != "" {
+				parsed, err := time.Parse(time.RFC3339, raw)
+				if err != nil {
+					s.writeErrorMsg(w, http.StatusBadRequest, "invalid expires_at")
+					return
+				}
+				expiresAt = parsed.UTC()
+			}
+			update.ExpiresAt = &expiresAt
+		}
+		updated, err := s.Store.UpdateProofingGallery(id, update)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeJSON(w, http.StatusOK, s.proofingAdminView(updated))
+	case http.MethodDelete:
+		deleted, err := s.Store.DeleteProofingGallery(id)
+		if err != nil {
+			s.writeProofingError(w, err)
+			return
+		}
+		s.deleteProofingFiles(deleted.Images...)
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) handleAdminProofingSend(w http.ResponseWriter, id uint) {
+	gallery, ok := s.Store.GetProofingGallery(id)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	order, ok := s.Store.GetOrderByID(gallery.OrderID)
+	if !ok {
+		s.writeErrorMsg(w, http.StatusNotFound, storage.ErrProofingOrderNotFound.Error())
+		return
+	}
+	if !isValidEmail(order.CustomerEmail) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "order has no customer email")
+		return
+	}
+	if len(gallery.Images) == 0 {
+		s.writeErrorMsg(w, http.StatusBadRequest, "gallery has no images")
+		return
+	}
+	shareURL := s.proofingURL(gallery.ID)
+	subject, htmlBody, textBody, err := utils.BuildProofingReadyEmail(order, gallery, shareURL)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := utils.SendEmail(order.CustomerEmail, subject, htmlBody, textBody); err != nil {
+		s.writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	if err := s.Store.MarkProofingGallerySent(gallery.ID, time.Now().UTC()); err != nil {
+		log.Printf("failed to mark proofing gallery %d as sent: %v", gallery.ID, err)
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "sent", "share_url": shareURL})
+}
+
+func (s *Server) handleAdminProofingUpload(w http.ResponseWriter, r *http.Request, id uint) {
+	if _, ok := s.Store.GetProofingGallery(id); !ok {
+		s.notFound(w)
+		return
+	}
+	if err := r.ParseMultipartForm(100 << 20); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	files := getFiles(r.MultipartForm, proofingImageField.name)
+	if len(files) == 0 {
+		s.writeErrorMsg(w, http.StatusBadRequest, "images are required")
+		return
+	}
+	if !s.checkUploads(w, r.MultipartForm, proofingImageField) {
+		return
+	}
+	images := make([]models.ProofingImage, 0, len(files))
+	for _, file := range files {
+		img, err := s.storeProofingImage(id, file)
+		if err != nil {
+			s.deleteProofingFiles(images...)
+			s.writeUploadError(w, err, http.StatusInternalServerError)
+			return
+		}
+		images = append(images, *img)
+	}
+	updated, err := s.Store.AddProofingImages(id, images)
+	if err != nil {
+		s.deleteProofingFiles(images...)
+		s.writeProofingError(w, err)
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, s.proofingAdminView(updated))
+}
+
+// storeProofingImage keeps the untouched original next to a watermarked
+// preview. Clients only ever see the preview.
+func (s *Server) storeProofingImage(galleryID uint, file *multipart.FileHeader) (*models.ProofingImage, error) {
+	sniffed, err := utils.SniffUpload(file)
+	if err != nil {
+		return nil, err
+	}
+	src, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(src)
+	src.Close()
+	if err != nil {
+		return nil, err
+	}
+	preview, err := s.proofPreview(data)
+	if err != nil {
+		return nil, &utils.UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s: image could not be decoded", file.Filename)}
+	}
+	ctx := context.Background()
+	base := fmt.Sprintf("proofing/%d/%s", galleryID, utils.RandomName(16))
+	img := &models.ProofingImage{
+		Key:        base + sniffed.Ext,
+		PreviewKey: base + preview.Suffix + preview.Ext,
+		FileName:   file.Filename,
+		Width:      sniffed.Width,
+		Height:     sniffed.Height,
+	}
+	if err := s.Files.Put(ctx, img.Key, bytes.NewReader(data), int64(len(data)), sniffed.ContentType); err != nil {
+		return nil, err
+	}
+	if err := s.Files.Put(ctx, img.PreviewKey, bytes.NewReader(preview.Data), int64(len(preview.Data)), preview.ContentType); err != nil {
+		s.Files.Delete(ctx, img.Key)
+		return nil, err
+	}
+	img.URL = s.publicFileURL(img.Key)
+	img.PreviewURL = s.publicFileURL(img.PreviewKey)
+	return img, nil
+}
+
+func (s *Server) proofPreview(data []byte) (*utils.ImageFile, error) {
+	return utils.RenderProofPreview(data, envInt("PROOFING_PREVIEW_MAX_DIMENSION", 1600), s.images.JPEGQuality, s.watermark)
+}
+
+func (s *Server) deleteProofingFiles(images ...models.ProofingImage) {
+	ctx := context.Background()
+	for _, img := range images {
+		for _, key := range []string{img.Key, img.PreviewKey} {
+			if key == "" {
+				continue
+			}
+			if err := s.Files.Delete(ctx, key); err != nil {
+				log.Printf("failed deleting %s: %v", key, err)
+			}
+		}
+	}
+}
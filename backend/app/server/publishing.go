@@ -0,0 +1,215 @@
+package server
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+)
+
+const (
+	previewKindService    = "service"
+	previewKindGallery    = "gallery"
+	previewKindExperience = "experience"
+	previewKindCaseStudy  = "case_study"
+)
+
+type publishingPayload struct {
+	Status      string `json:"status"`
+	PublishAt   string `json:"publish_at"`
+	UnpublishAt string `json:"unpublish_at"`
+}
+
+func (p publishingPayload) toPublishing() (models.Publishing, error) {
+	out := models.Publishing{PublishStatus: p.Status}
+	if raw := strings.TrimSpace(p.PublishAt); raw != "" {
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return out, errors.New("invalid publish_at")
+		}
+		out.PublishAt = ts
+	}
+	if raw := strings.TrimSpace(p.UnpublishAt); raw != "" {
+		ts, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return out, errors.New("invalid unpublish_at")
+		}
+		out.UnpublishAt = ts
+	}
+	return storage.NormalizePublishing(out)
+}
+
+func publishingFromForm(form *multipart.Form) (models.Publishing, error) {
+	return publishingPayload{
+		Status:      getFormValue(form, "publish_status"),
+		PublishAt:   getFormValue(form, "publish_at"),
+		UnpublishAt: getFormValue(form, "unpublish_at"),
+	}.toPublishing()
+}
+
+func (s *Server) signPreviewToken(kind string, id uint, expires time.Time) string {
+	payload := fmt.Sprintf("%d.%d", id, expires.Unix())
+	return payload + "." + s.linkSignature("preview:"+kind, payload)
+}
+
+func (s *Server) previewAllowed(r *http.Request, kind string, id uint) bool {
+	raw := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("preview")))
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return false
+	}
+	tokenID, err := parseID(parts[0])
+	if err != nil || tokenID != id {
+		return false
+	}
+	exp, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(s.linkSignature("preview:"+kind, parts[0]+"."+parts[1])), []byte(parts[2]))
+}
+
+func (s *Server) visibleServiceBySlug(r *http.Request, slug string) (*models.Service, bool) {
+	svc, ok := s.Store.GetServiceBySlug(slug)
+	if !ok {
+		return nil, false
+	}
+	if !storage.IsLive(svc.Publishing, time.Now().UTC()) && !s.previewAllowed(r, previewKindService, svc.ID) {
+		return nil, false
+	}
+	return svc, true
+}
+
+func (s *Server) handleAdminPublishing(w http.ResponseWriter, r *http.Request, kind string, id uint) {
+	if r.Method != http.MethodPut {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload publishingPayload
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	p, err := payload.toPublishing()
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var updated any
+	switch kind {
+	case previewKindService:
+		updated, err = s.Store.SetServicePublishing(id, p, adminEmailFromContext(r.Context()))
+	case previewKindGallery:
+		updated, err = s.Store.SetGalleryItemPublishing(id, p, adminEmailFromContext(r.Context()))
+	case previewKindCaseStudy:
+		updated, err = s.Store.SetCaseStudyPublishing(id, p, adminEmailFromContext(r.Context()))
+	default:
+		updated, err = s.Store.SetExperiencePublishing(id, p, adminEmailFromContext(r.Context()))
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleAdminPreviewToken(w http.ResponseWriter, r *http.Request, kind string, id uint) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	path := "/"
+	switch kind {
+	case previewKindService:
+		svc, ok := s.Store.GetServiceByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		path = "/services/" + svc.Slug
+	case previewKindGallery:
+		if _, ok := s.Store.GetGalleryItemByID(id); !ok {
+			s.notFound(w)
+			return
+		}
+		path = "/gallery"
+	case previewKindCaseStudy:
+		cs, ok := s.Store.GetCaseStudyByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		path = "/case-studies/" + cs.Slug
+	default:
+		if _, ok := s.Store.GetExperienceByID(id); !ok {
+			s.notFound(w)
+			return
+		}
+	}
+	expires := time.Now().UTC().Add(envDurationMinutes("PREVIEW_TOKEN_TTL_MINUTES", 24*time.Hour))
+	token := s.signPreviewToken(kind, id, expires)
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"token":       token,
+		"preview_url": s.frontendURL(path, url.Values{"preview": {token}}),
+		"expires_at":  expires,
+	})
+}
+
+func (s *Server) routeAdminPublishing(w http.ResponseWriter, r *http.Request, prefix, kind string) bool {
+	rest := strings.TrimPrefix(r.URL.Path, prefix)
+	var handler func(http.ResponseWriter, *http.Request, string, uint)
+	switch {
+	case strings.HasSuffix(rest, "/publishing"):
+		rest = strings.TrimSuffix(rest, "/publishing")
+		handler = s.handleAdminPublishing
+	case strings.HasSuffix(rest, "/preview"):
+		rest = strings.TrimSuffix(rest, "/preview")
+		handler = s.handleAdminPreviewToken
+	default:
+		return false
+	}
+	id, err := parseID(rest)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid id")
+		return true
+	}
+	handler(w, r, kind, id)
+	return true
+}
+
+func (s *Server) archiveService(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.URL.Query().Get("purge") == "true" {
+		if err := s.Store.DeleteService(id); err != nil {
+			if errors.Is(err, storage.ErrServiceHasOrders) {
+				s.writeErrorMsg(w, http.StatusConflict, "service has order history; archive it instead")
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		return
+	}
+	if _, err := s.Store.SetServicePublishing(id, models.Publishing{PublishStatus: storage.PublishArchived}, adminEmailFromContext(r.Context())); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"status": "archived"})
+}
@@ -0,0 +1,418 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+const defaultQuoteValidity = 14 * 24 * time.Hour
+
+func (s *Server) quoteOfferURL(quoteID uint) string {
+	return s.frontendURL(fmt.Sprintf("/quotes/%d", quoteID), url.Values{"token": {s.signLinkToken("quote", quoteID)}})
+}
+
+func (s *Server) handleQuotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	var payload struct {
+		ServiceSlug  string `json:"service_slug"`
+		CategorySlug string `json:"category_slug"`
+		Name         string `json:"customer_name"`
+		Email        string `json:"customer_email"`
+		Phone        string `json:"customer_phone"`
+		Details      string `json:"details"`
+		Budget       string `json:"budget"`
+		Deadline     string `json:"deadline"`
+		BookingStart string `json:"booking_start"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	payload.ServiceSlug = strings.TrimSpace(payload.ServiceSlug)
+	payload.CategorySlug = strings.TrimSpace(payload.CategorySlug)
+	payload.Name = strings.TrimSpace(payload.Name)
+	payload.Email = strings.TrimSpace(payload.Email)
+	payload.Phone = strings.TrimSpace(payload.Phone)
+	payload.Details = strings.TrimSpace(payload.Details)
+	payload.Budget = strings.TrimSpace(payload.Budget)
+	payload.Deadline = strings.TrimSpace(payload.Deadline)
+	if payload.Name == "" || !isValidEmail(payload.Email) || payload.Details == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "name, email and details are required")
+		return
+	}
+	if payload.ServiceSlug == "" && payload.CategorySlug == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "service slug or category slug is required")
+		return
+	}
+	quote := &models.QuoteRequest{
+		CustomerName:  payload.Name,
+		CustomerEmail: payload.Email,
+		CustomerPhone: payload.Phone,
+		Details:       payload.Details,
+		Budget:        payload.Budget,
+		Deadline:      payload.Deadline,
+	}
+	subjectTitle := ""
+	if payload.ServiceSlug != "" {
+		svc, ok := s.visibleServiceBySlug(r, payload.ServiceSlug)
+		if !ok {
+			s.writeErrorMsg(w, http.StatusNotFound, "Service not found")
+			return
+		}
+		quote.ServiceID = svc.ID
+		quote.CategoryID = svc.CategoryID
+		subjectTitle = svc.Title
+		if raw := strings.TrimSpace(payload.BookingStart); raw != "" && svc.Booking != nil && svc.Booking.Enabled {
+			bookingStart, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid booking_start")
+				return
+			}
+			quote.BookingStart = bookingStart.UTC()
+		}
+	} else {
+		cat, ok := s.Store.GetCategoryBySlug(payload.CategorySlug)
+		if !ok {
+			s.writeErrorMsg(w, http.StatusNotFound, "Category not found")
+			return
+		}
+		quote.CategoryID = cat.ID
+		subjectTitle = cat.Name
+	}
+	created, err := s.Store.CreateQuoteRequest(quote)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if adminEmail := os.Getenv("ADMIN_EMAIL"); adminEmail != "" {
+		subject, htmlBody, textBody, err := utils.BuildQuoteRequestEmail(created, subjectTitle)
+		if err != nil {
+			log.Printf("Failed to build quote request email: %v", err)
+		} else {
+			go func() {
+				if err := utils.SendEmail(adminEmail, subject, htmlBody, textBody); err != nil {
+					log.Printf("Failed to send quote request notification email: %v", err)
+				}
+			}()
+		}
+	}
+	s.writeJSON(w, http.StatusCreated, map[string]any{
+		"id":     created.ID,
+		"status": created.Status,
+	})
+}
+
+func (s *Server) handleQuoteRoutes(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/quotes/"), "/")
+	action := ""
+	if idx := strings.Index(path, "/"); idx >= 0 {
+		path, action = path[:idx], path[idx+1:]
+	}
+	id, err := parseID(path)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid quote id")
+		return
+	}
+	tokenID, err := s.verifyLinkToken("quote", r.URL.Query().Get("token"))
+	if err != nil || tokenID != id {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid or missing quote token")
+		return
+	}
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		quote, ok := s.Store.GetQuoteRequestByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		if quote.Status == "offered" && !quote.OfferExpiresAt.IsZero() && time.Now().After(quote.OfferExpiresAt) {
+			quote.Status = "expired"
+		}
+		response := map[string]any{"quote": quote}
+		if svc, ok := s.Store.GetServiceByID(quote.ServiceID); ok {
+			response["service"] = svc
+		}
+		s.writeJSON(w, http.StatusOK, response)
+	case "accept":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.handleQuoteAccept(w, r, id)
+	case "decline":
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		var payload struct {
+			Reason string `json:"reason"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		quote, err := s.Store.DeclineQuoteOffer(id, strings.TrimSpace(payload.Reason), time.Now().UTC())
+		if err != nil {
+			s.writeQuoteError(w, err)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, quote)
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) handleQuoteAccept(w http.ResponseWriter, r *http.Request, id uint) {
+	var payload struct {
+		PaymentCategory string `json:"payment_category"`
+		PaymentChannel  string `json:"payment_channel"`
+		CardTokenID     string `json:"card_token_id"`
+		BookingStart    string `json:"booking_start"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var bookingStart time.Time
+	if raw := strings.TrimSpace(payload.BookingStart); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid booking_start")
+			return
+		}
+		bookingStart = parsed
+	}
+	if strings.TrimSpace(payload.PaymentCategory) == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "payment category is required")
+		return
+	}
+	category, channel, err := normalizePaymentSelection(payload.PaymentCategory, payload.PaymentChannel)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	quote, order, err := s.Store.AcceptQuoteOffer(id, bookingStart, time.Now().UTC())
+	if err != nil {
+		s.writeQuoteError(w, err)
+		return
+	}
+	tx, updatedOrder, err := s.createPaymentForOrder(r.Context(), order, paymentRequest{
+		Category:  category,
+		Channel:   channel,
+		CardToken: strings.TrimSpace(payload.CardTokenID),
+	})
+	if err != nil {
+		log.Printf("failed to create payment for quote %d order %d: %v", quote.ID, order.ID, err)
+		if deleteErr := s.Store.DeleteOrder(order.ID); deleteErr != nil {
+			log.Printf("failed to rollback order %d after payment error: %v", order.ID, deleteErr)
+		}
+		if revertErr := s.Store.RevertQuoteAcceptance(quote.ID); revertErr != nil {
+			log.Printf("failed to reopen quote %d after payment error: %v", quote.ID, revertErr)
+		}
+		status := http.StatusBadGateway
+		msg := "failed to create payment request"
+		if errors.Is(err, errPaymentWindowClosed) {
+			status = http.StatusForbidden
+			msg = "payment session is no longer available for this order"
+		}
+		s.writeErrorMsg(w, status, msg)
+		return
+	}
+	if updatedOrder != nil {
+		order = updatedOrder
+	}
+	response := s.orderPaymentResponse(order, tx)
+	response["quote"] = quote
+	s.writeJSON(w, http.StatusCreated, response)
+}
+
+func (s *Server) writeQuoteError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		s.notFound(w)
+	case errors.Is(err, storage.ErrQuoteOfferExpired):
+		s.writeErrorMsg(w, http.StatusGone, "quote offer expired")
+	case errors.Is(err, storage.ErrQuoteAlreadyClosed):
+		s.writeErrorMsg(w, http.StatusConflict, "quote already closed")
+	case errors.Is(err, storage.ErrQuoteNotOffered):
+		s.writeErrorMsg(w, http.StatusConflict, "quote has no open offer")
+	case errors.Is(err, storage.ErrQuoteServiceNeeded):
+		s.writeErrorMsg(w, http.StatusBadRequest, "service_id is required for this offer")
+	case errors.Is(err, storage.ErrQuoteSlotRequired):
+		s.writeErrorMsg(w, http.StatusBadRequest, "booking_start is required for this service")
+	case errors.Is(err, storage.ErrSlotUnavailable):
+		s.writeErrorMsg(w, http.StatusConflict, "selected booking slot is no longer available")
+	case errors.Is(err, storage.ErrServiceSoldOut):
+		s.writeErrorMsg(w, http.StatusConflict, "service is sold out")
+	default:
+		s.writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (s *Server) handleAdminQuotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.Store.ListQuoteRequests(r.URL.Query().Get("status")))
+}
+
+func (s *Server) handleAdminQuoteByID(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/quotes/")
+	if strings.HasSuffix(path, "/offer") {
+		id, err := parseID(strings.TrimSuffix(path, "/offer"))
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid quote id")
+			return
+		}
+		if r.Method != http.MethodPost {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		s.handleAdminQuoteOffer(w, r, id)
+		return
+	}
+	id, err := parseID(path)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid quote id")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		quote, ok := s.Store.GetQuoteRequestByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, quote)
+	case http.MethodDelete:
+		if err := s.Store.DeleteQuoteRequest(id); err != nil {
+			s.writeQuoteError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func normalizeLineItems(raw []models.QuoteLineItem) ([]models.QuoteLineItem, float64, error) {
+	items := make([]models.QuoteLineItem, 0, len(raw))
+	total := 0.0
+	for _, item := range raw {
+		item.Title = strings.TrimSpace(item.Title)
+		item.Description = strings.TrimSpace(item.Description)
+		if item.Title == "" {
+			return nil, 0, errors.New("line item title is required")
+		}
+		if item.Quantity <= 0 {
+			item.Quantity = 1
+		}
+		if item.UnitPrice < 0 || item.Amount < 0 {
+			return nil, 0, errors.New("line item amounts must not be negative")
+		}
+		if item.Amount == 0 {
+			item.Amount = math.Round(float64(item.Quantity)*item.UnitPrice*100) / 100
+		}
+		total += item.Amount
+		items = append(items, item)
+	}
+	return items, total, nil
+}
+
+func (s *Server) handleAdminQuoteOffer(w http.ResponseWriter, r *http.Request, id uint) {
+	var payload struct {
+		ServiceID uint                   `json:"service_id"`
+		Items     []models.QuoteLineItem `json:"items"`
+		Amount    float64                `json:"amount"`
+		Terms     string                 `json:"terms"`
+		Notes     string                 `json:"notes"`
+		ExpiresAt string                 `json:"expires_at"`
+		ValidDays int                    `json:"valid_days"`
+	}
+	if err := s.decodeJSON(r.Body, &payload); err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	items, total, err := normalizeLineItems(payload.Items)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	amount := payload.Amount
+	if amount == 0 {
+		amount = total
+	}
+	if amount <= 0 {
+		s.writeErrorMsg(w, http.StatusBadRequest, "offer amount must be greater than zero")
+		return
+	}
+	expiresAt := time.Now().UTC().Add(defaultQuoteValidity)
+	if payload.ValidDays > 0 {
+		expiresAt = time.Now().UTC().Add(time.Duration(payload.ValidDays) * 24 * time.Hour)
+	}
+	if raw := strings.TrimSpace(payload.ExpiresAt); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid expires_at")
+			return
+		}
+		expiresAt = parsed.UTC()
+	}
+	if !expiresAt.After(time.Now()) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+	if payload.ServiceID != 0 {
+		if _, ok := s.Store.GetServiceByID(payload.ServiceID); !ok {
+			s.writeErrorMsg(w, http.StatusNotFound, "Service not found")
+			return
+		}
+	}
+	quote, err := s.Store.SetQuoteOffer(id, storage.QuoteOfferInput{
+		ServiceID: payload.ServiceID,
+		Items:     items,
+		Amount:    amount,
+		Terms:     strings.TrimSpace(payload.Terms),
+		Notes:     strings.TrimSpace(payload.Notes),
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		s.writeQuoteError(w, err)
+		return
+	}
+	offerURL := s.quoteOfferURL(quote.ID)
+	service, _ := s.Store.GetServiceByID(quote.ServiceID)
+	subject, htmlBody, textBody, err := utils.BuildQuoteOfferEmail(quote, service, offerURL)
+	if err != nil {
+		log.Printf("Failed to build quote offer email: %v", err)
+	} else {
+		go func() {
+			if err := utils.SendEmail(quote.CustomerEmail, subject, htmlBody, textBody); err != nil {
+				log.Printf("Failed to send quote offer email for quote %d: %v", quote.ID, err)
+			}
+		}()
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"quote":     quote,
+		"offer_url": offerURL,
+	})
+}
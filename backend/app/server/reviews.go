@@ -0,0 +1,347 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+const (
+	reviewPurpose         = "review"
+	maxReviewPhotos       = 4
+	maxReviewPhotoSize    = 8 << 20
+	maxReviewCommentChars = 2000
+)
+
+type publicReview struct {
+	ID          uint      `json:"id"`
+	Rating      int       `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Photos      []string  `json:"photos,omitempty"`
+	Reply       string    `json:"reply,omitempty"`
+	RepliedAt   time.Time `json:"replied_at,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (s *Server) reviewURL(orderID uint) string {
+	return s.frontendURL("/review", url.Values{"token": {s.signLinkToken(reviewPurpose, orderID)}})
+}
+
+func (s *Server) startReviewRequestLoop() {
+	if !envBool("REVIEW_REQUEST_ENABLED", true) {
+		return
+	}
+	delay := envDurationMinutes("REVIEW_REQUEST_DELAY_MINUTES", 3*24*time.Hour)
+	interval := envDurationMinutes("REVIEW_REQUEST_INTERVAL_MINUTES", time.Hour)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.processReviewRequests(time.Now().UTC(), delay)
+		}
+	}()
+}
+
+func (s *Server) processReviewRequests(now time.Time, delay time.Duration) {
+	if s.Store == nil {
+		return
+	}
+	for _, order := range s.Store.DueReviewRequests(now.Add(-delay)) {
+		if err := s.Store.MarkReviewRequested(order.ID, now); err != nil {
+			log.Printf("failed to mark review request for order %d: %v", order.ID, err)
+			continue
+		}
+		service, _ := s.Store.GetServiceByID(order.ServiceID)
+		subject, htmlBody, textBody, err := utils.BuildReviewRequestEmail(&order, service, s.reviewURL(order.ID))
+		if err != nil {
+			log.Printf("Failed to build review request email: %v", err)
+			continue
+		}
+		go func() {
+			if err := utils.SendEmail(order.CustomerEmail, subject, htmlBody, textBody); err != nil {
+				log.Printf("Failed to send review request email for order %d: %v", order.ID, err)
+			}
+		}()
+	}
+}
+
+func (s *Server) handleReviewRoutes(w http.ResponseWriter, r *http.Request) {
+	token := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/reviews/"), "/")
+	orderID, err := s.verifyLinkToken(reviewPurpose, token)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid review link")
+		return
+	}
+	order, ok := s.Store.GetOrderByID(orderID)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		s.writeReviewForm(w, order)
+	case http.MethodPost:
+		s.submitReview(w, r, order)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
+
+func (s *Server) writeReviewForm(w http.ResponseWriter, order *models.Order) {
+	response := map[string]any{
+		"order_id":      order.ID,
+		"customer_name": order.CustomerName,
+		"can_review":    order.Status == "done",
+	}
+	if svc, ok := s.Store.GetServiceByID(order.ServiceID); ok {
+		response["service"] = map[string]any{
+			"id":        svc.ID,
+			"title":     svc.Title,
+			"slug":      svc.Slug,
+			"thumbnail": svc.Thumbnail,
+		}
+	}
+	if review, ok := s.Store.GetReviewByOrderID(order.ID); ok {
+		response["review"] = review
+	}
+	s.writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) submitReview(w http.ResponseWriter, r *http.Request, order *models.Order) {
+	var sub storage.ReviewSubmission
+	var photoFiles []*multipart.FileHeader
+	if strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "multipart/") {
+		if err := r.ParseMultipartForm(maxReviewPhotos*maxReviewPhotoSize + (1 << 20)); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+		rating, err := strconv.Atoi(strings.TrimSpace(getFormValue(r.MultipartForm, "rating")))
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "rating must be between 1 and 5")
+			return
+		}
+		sub.Rating = rating
+		sub.Comment = getFormValue(r.MultipartForm, "review")
+		sub.DisplayName = getFormValue(r.MultipartForm, "display_name")
+		photoFiles = getFiles(r.MultipartForm, "photos")
+	} else {
+		var payload struct {
+			Rating      int    `json:"rating"`
+			Review      string `json:"review"`
+			DisplayName string `json:"display_name"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		sub.Rating = payload.Rating
+		sub.Comment = payload.Review
+		sub.DisplayName = payload.DisplayName
+	}
+	if len([]rune(strings.TrimSpace(sub.Comment))) > maxReviewCommentChars {
+		s.writeErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("Ulasan terlalu panjang (maks %d karakter).", maxReviewCommentChars))
+		return
+	}
+	if len(photoFiles) > maxReviewPhotos {
+		s.writeErrorMsg(w, http.StatusBadRequest, fmt.Sprintf("maksimal %d foto per ulasan", maxReviewPhotos))
+		return
+	}
+	for _, file := range photoFiles {
+		if file.Size > maxReviewPhotoSize {
+			s.writeErrorMsg(w, http.StatusRequestEntityTooLarge, "foto ulasan harus berupa gambar maksimal 8MB")
+			return
+		}
+		if err := s.checkUpload(file, imageField("photos")); err != nil {
+			s.writeUploadError(w, err, http.StatusBadRequest)
+			return
+		}
+	}
+	previous, hadPrevious := s.Store.GetReviewByOrderID(order.ID)
+	if len(photoFiles) > 0 {
+		sub.Photos = make([]string, 0, len(photoFiles))
+		for _, file := range photoFiles {
+			publicPath, err := s.saveUpload(file, "reviews", nil)
+			if err != nil {
+				for _, saved := range sub.Photos {
+					s.deleteStaticFile(saved)
+				}
+				s.writeUploadError(w, err, http.StatusInternalServerError)
+				return
+			}
+			sub.Photos = append(sub.Photos, publicPath)
+		}
+	}
+	_, review, err := s.Store.SubmitOrderReview(order.ID, sub)
+	if err != nil {
+		for _, saved := range sub.Photos {
+			s.deleteStaticFile(saved)
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			s.notFound(w)
+			return
+		}
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if hadPrevious && sub.Photos != nil {
+		for _, old := range previous.Photos {
+			s.deleteStaticFile(old)
+		}
+	}
+	if hadPrevious && previous.Status == storage.ReviewStatusApproved && review.Status == storage.ReviewStatusPending {
+		s.notifyReviewNeedsModeration(order, review)
+	}
+	s.writeJSON(w, http.StatusOK, review)
+}
+
+// notifyReviewNeedsModeration tells the studio that an approved review was
+// edited and is hidden until it is approved again.
+func (s *Server) notifyReviewNeedsModeration(order *models.Order, review *models.Review) {
+	adminEmail := os.Getenv("ADMIN_EMAIL")
+	if adminEmail == "" {
+		return
+	}
+	service, _ := s.Store.GetServiceByID(order.ServiceID)
+	subject, htmlBody, textBody, err := utils.BuildReviewResubmittedEmail(order, service, review)
+	if err != nil {
+		log.Printf("Failed to build review moderation email: %v", err)
+		return
+	}
+	go func() {
+		if err := utils.SendEmail(adminEmail, subject, htmlBody, textBody); err != nil {
+			log.Printf("Failed to send review moderation email for review %d: %v", review.ID, err)
+		}
+	}()
+}
+
+func (s *Server) handleServiceReviews(w http.ResponseWriter, r *http.Request, slug string) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	svc, ok := s.visibleServiceBySlug(r, slug)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	query := r.URL.Query()
+	limit := 20
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 && n <= 50 {
+		limit = n
+	}
+	offset := 0
+	if n, err := strconv.Atoi(query.Get("offset")); err == nil && n > 0 {
+		offset = n
+	}
+	reviews := s.Store.ListReviews(storage.ReviewStatusApproved, svc.ID)
+	breakdown := map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	sum := 0
+	for _, review := range reviews {
+		breakdown[review.Rating]++
+		sum += review.Rating
+	}
+	items := make([]publicReview, 0, limit)
+	for i := offset; i < len(reviews) && len(items) < limit; i++ {
+		review := reviews[i]
+		items = append(items, publicReview{
+			ID:          review.ID,
+			Rating:      review.Rating,
+			Comment:     review.Comment,
+			DisplayName: review.DisplayName,
+			Photos:      review.Photos,
+			Reply:       review.Reply,
+			RepliedAt:   review.RepliedAt,
+			CreatedAt:   review.CreatedAt,
+		})
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"items":          items,
+		"total":          len(reviews),
+		"average_rating": averageRating(sum, len(reviews)),
+		"breakdown":      breakdown,
+	})
+}
+
+func (s *Server) handleAdminReviews(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	status := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("status")))
+	if status != "" && !storage.IsReviewStatus(status) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "unsupported review status")
+		return
+	}
+	var serviceID uint
+	if raw := r.URL.Query().Get("service_id"); raw != "" {
+		id, err := parseID(raw)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid service id")
+			return
+		}
+		serviceID = id
+	}
+	s.writeJSON(w, http.StatusOK, s.Store.ListReviews(status, serviceID))
+}
+
+func (s *Server) handleAdminReviewByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/reviews/"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid review id")
+		return
+	}
+	switch r.Method {
+	case http.MethodPut, http.MethodPatch:
+		var payload struct {
+			Status *string `json:"status"`
+			Reply  *string `json:"reply"`
+		}
+		if err := s.decodeJSON(r.Body, &payload); err != nil {
+			s.writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if payload.Status != nil {
+			status := strings.ToLower(strings.TrimSpace(*payload.Status))
+			payload.Status = &status
+		}
+		review, err := s.Store.ModerateReview(id, storage.ReviewModeration{Status: payload.Status, Reply: payload.Reply})
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.writeJSON(w, http.StatusOK, review)
+	case http.MethodDelete:
+		review, err := s.Store.DeleteReview(id)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				s.notFound(w)
+				return
+			}
+			s.writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, photo := range review.Photos {
+			s.deleteStaticFile(photo)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s.methodNotAllowed(w, r)
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"devara-creative-backend/app/storage"
+)
+
+func (s *Server) handleAdminRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	query := r.URL.Query()
+	entity := strings.ToLower(strings.TrimSpace(query.Get("entity")))
+	if !storage.IsRevisionEntity(entity) {
+		s.writeErrorMsg(w, http.StatusBadRequest, "unsupported revision entity")
+		return
+	}
+	id, err := parseID(query.Get("id"))
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, s.Store.ListRevisions(entity, id))
+}
+
+func (s *Server) handleAdminRevisionRoutes(w http.ResponseWriter, r *http.Request) {
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/revisions/"), "/")
+	action := ""
+	if idx := strings.Index(rest, "/"); idx >= 0 {
+		rest, action = rest[:idx], rest[idx+1:]
+	}
+	id, err := parseID(rest)
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, "invalid revision id")
+		return
+	}
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			s.methodNotAllowed(w, r)
+			return
+		}
+		rev, ok := s.Store.GetRevision(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, rev)
+	case "diff":
+		s.handleRevisionDiff(w, r, id)
+	case "restore":
+		s.handleRevisionRestore(w, r, id)
+	default:
+		s.notFound(w)
+	}
+}
+
+func (s *Server) handleRevisionDiff(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	rev, ok := s.Store.GetRevision(id)
+	if !ok {
+		s.notFound(w)
+		return
+	}
+	var againstID uint
+	if raw := r.URL.Query().Get("against"); raw != "" {
+		parsed, err := parseID(raw)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid against id")
+			return
+		}
+		againstID = parsed
+	} else {
+		for _, candidate := range s.Store.ListRevisions(rev.EntityType, rev.EntityID) {
+			if candidate.Version < rev.Version {
+				againstID = candidate.ID
+				break
+			}
+		}
+		if againstID == 0 {
+			s.writeErrorMsg(w, http.StatusBadRequest, "no earlier revision to compare against")
+			return
+		}
+	}
+	changes, err := s.Store.DiffRevisions(againstID, rev.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			s.notFound(w)
+		case errors.Is(err, storage.ErrRevisionMismatch):
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		default:
+			s.writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"from":    againstID,
+		"to":      rev.ID,
+		"changes": changes,
+	})
+}
+
+func (s *Server) handleRevisionRestore(w http.ResponseWriter, r *http.Request, id uint) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	restored, err := s.Store.RestoreRevision(id, adminEmailFromContext(r.Context()))
+	if err != nil {
+		switch {
+		case errors.Is(err, os.ErrNotExist), errors.Is(err, storage.ErrPromoNotFound):
+			s.notFound(w)
+		case errors.Is(err, storage.ErrPromoDuplicate), errors.Is(err, storage.ErrRevisionSlugTaken), errors.Is(err, storage.ErrCaseStudySlugTaken):
+			s.writeErrorMsg(w, http.StatusConflict, err.Error())
+		default:
+			s.writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	s.writeJSON(w, http.StatusOK, restored)
+}
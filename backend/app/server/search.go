@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/storage"
+)
+
+const maxSearchQueryChars = 200
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	query := r.URL.Query()
+	text := strings.TrimSpace(query.Get("q"))
+	if text == "" {
+		s.writeErrorMsg(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len([]rune(text)) > maxSearchQueryChars {
+		s.writeErrorMsg(w, http.StatusBadRequest, "query too long")
+		return
+	}
+	var kinds []string
+	for _, raw := range strings.Split(query.Get("type"), ",") {
+		kind := strings.ToLower(strings.TrimSpace(raw))
+		switch kind {
+		case "":
+		case storage.SearchKindService, storage.SearchKindGallery, storage.SearchKindExperience, storage.SearchKindCaseStudy:
+			kinds = append(kinds, kind)
+		default:
+			s.writeErrorMsg(w, http.StatusBadRequest, "unsupported search type")
+			return
+		}
+	}
+	limit := 20
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 && n <= 50 {
+		limit = n
+	}
+	results := s.Store.Search(storage.SearchQuery{
+		Text:  text,
+		Kinds: kinds,
+		Limit: limit,
+		Now:   time.Now().UTC(),
+	})
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"query": text,
+		"items": results,
+		"total": len(results),
+	})
+}
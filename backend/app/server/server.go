@@ -24,9 +24,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"devara-creative-backend/app/auth"
+	"devara-creative-backend/app/filestore"
 	"devara-creative-backend/app/models"
 	"devara-creative-backend/app/repository"
 	"devara-creative-backend/app/storage"
@@ -39,8 +41,11 @@ import (
 
 type Server struct {
 	Store     *storage.Store
+	Files     filestore.FileStore
 	UploadDir string
 
+	filesBaseURL string
+
 	googleOAuthConfig   *oauth2.Config
 	googleStateSecret   []byte
 	frontendBaseURL     string
@@ -75,6 +80,20 @@ type Server struct {
 	xenditRedirectURL   string
 
 	paymentSyncInterval time.Duration
+
+	linkSecret        []byte
+	replyEmailAddress string
+	inboundMailToken  string
+
+	recovery checkoutRecoveryConfig
+	images   utils.ImageOptions
+
+	uploadPolicy utils.UploadPolicy
+
+	watermark       *utils.Watermark
+	rerenderRunning atomic.Bool
+
+	proofingUnlocks attemptLimiter
 }
 
 var (
@@ -114,6 +133,40 @@ type serviceMetrics struct {
 	ratingSum      int
 	ratingCount    int
 	completedCount int
+	packages       map[string]*packageMetrics
+}
+
+type packageMetrics struct {
+	OrderCount     int     `json:"order_count"`
+	CompletedCount int     `json:"completed_count"`
+	AverageRating  float64 `json:"average_rating"`
+	RatingCount    int     `json:"rating_count"`
+	ratingSum      int
+}
+
+func (m serviceMetrics) packageSummary() map[string]packageMetrics {
+	if len(m.packages) == 0 {
+		return nil
+	}
+	out := make(map[string]packageMetrics, len(m.packages))
+	for key, pm := range m.packages {
+		summary := *pm
+		summary.AverageRating = averageRating(pm.ratingSum, pm.RatingCount)
+		out[key] = summary
+	}
+	return out
+}
+
+func (m *serviceMetrics) pkg(key string) *packageMetrics {
+	if m.packages == nil {
+		m.packages = make(map[string]*packageMetrics)
+	}
+	pm := m.packages[key]
+	if pm == nil {
+		pm = &packageMetrics{}
+		m.packages[key] = pm
+	}
+	return pm
 }
 
 type analyticsEventPayload struct {
@@ -144,6 +197,10 @@ type experiencePayload struct {
 	Company     *string `json:"company"`
 	Description *string `json:"description"`
 	Order       *int    `json:"order"`
+
+	PublishStatus string `json:"publish_status"`
+	PublishAt     string `json:"publish_at"`
+	UnpublishAt   string `json:"unpublish_at"`
 }
 
 type paymentRequest struct {
@@ -203,6 +260,7 @@ const (
 const (
 	ctxKeyPortalRole   contextKey = "portal_role"
 	ctxKeyPortalUserID contextKey = "portal_user_id"
+	ctxKeyAdminEmail   contextKey = "admin_email"
 )
 
 func envString(key, fallback string) string {
@@ -239,6 +297,28 @@ func envDurationMinutes(key string, fallback time.Duration) time.Duration {
 	return fallback
 }
 
+func envInt(key string, fallback int) int {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	if n, err := strconv.Atoi(val); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
+
+func envFloat(key string, fallback float64) float64 {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return fallback
+	}
+	if f, err := strconv.ParseFloat(val, 64); err == nil && f > 0 {
+		return f
+	}
+	return fallback
+}
+
 func parseSameSite(value string) http.SameSite {
 	switch strings.ToLower(strings.TrimSpace(value)) {
 	case "none":
@@ -512,17 +592,34 @@ func (p analyticsEventPayload) toModel() *models.AnalyticsEvent {
 func (s *Server) computeServiceMetrics() map[uint]serviceMetrics {
 	orders := s.Store.ListOrders()
 	metrics := make(map[uint]serviceMetrics, len(orders))
+	orderPackages := make(map[uint]string)
 	for _, order := range orders {
 		m := metrics[order.ServiceID]
+		var pm *packageMetrics
+		if order.Package != nil {
+			orderPackages[order.ID] = order.Package.Key
+			pm = m.pkg(order.Package.Key)
+			pm.OrderCount++
+		}
 		if order.Status == "done" {
 			m.completedCount++
-		}
-		if order.RatingValue > 0 {
-			m.ratingSum += order.RatingValue
-			m.ratingCount++
+			if pm != nil {
+				pm.CompletedCount++
+			}
 		}
 		metrics[order.ServiceID] = m
 	}
+	for _, review := range s.Store.ListReviews(storage.ReviewStatusApproved, 0) {
+		m := metrics[review.ServiceID]
+		m.ratingSum += review.Rating
+		m.ratingCount++
+		if key, ok := orderPackages[review.OrderID]; ok {
+			pm := m.pkg(key)
+			pm.ratingSum += review.Rating
+			pm.RatingCount++
+		}
+		metrics[review.ServiceID] = m
+	}
 	return metrics
 }
 
@@ -546,6 +643,43 @@ func containsString(list []string, target string) bool {
 	return false
 }
 
+func normalizePaymentSelection(category, channel string) (string, string, error) {
+	normalizedCategory := strings.ToUpper(strings.TrimSpace(category))
+	normalizedChannel := strings.ToUpper(strings.TrimSpace(channel))
+	if normalizedCategory != "QRIS" && normalizedCategory != "CARD" && normalizedChannel == "" {
+		return "", "", errors.New("payment channel is required for selected category")
+	}
+	switch normalizedCategory {
+	case "QRIS":
+	case "VIRTUAL_ACCOUNT":
+		if !isValidBankCode(normalizedChannel) {
+			return "", "", errors.New("invalid virtual account bank code")
+		}
+	case "EWALLET":
+		if !isValidEWalletChannel(normalizedChannel) {
+			return "", "", errors.New("invalid e-wallet channel")
+		}
+	case "RETAIL_OUTLET":
+		if !isValidRetailOutlet(normalizedChannel) {
+			return "", "", errors.New("invalid retail outlet channel")
+		}
+	case "PAYLATER":
+		if !isValidPayLaterChannel(normalizedChannel) {
+			return "", "", errors.New("invalid paylater channel")
+		}
+	case "CARD":
+		if normalizedChannel == "" {
+			normalizedChannel = "CARD"
+		}
+		if normalizedChannel != "CARD" && !isValidCardBrand(normalizedChannel) {
+			return "", "", errors.New("unsupported card brand")
+		}
+	default:
+		return "", "", errors.New("unsupported payment category")
+	}
+	return normalizedCategory, normalizedChannel, nil
+}
+
 func isValidBankCode(code string) bool {
 	return containsString(xenditBankTransferCodes, code)
 }
@@ -571,7 +705,7 @@ func isValidCardBrand(code string) bool {
 	}
 }
 
-func New(store *storage.Store, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, uploadDir string) *Server {
+func New(store *storage.Store, userRepo repository.UserRepository, sessionRepo repository.SessionRepository, files filestore.FileStore, uploadDir string) *Server {
 	if uploadDir == "" {
 		uploadDir = filepath.Join("storage", "uploads")
 	}
@@ -614,7 +748,9 @@ func New(store *storage.Store, userRepo repository.UserRepository, sessionRepo r
 
 	srv := &Server{
 		Store:                 store,
+		Files:                 files,
 		UploadDir:             uploadDir,
+		filesBaseURL:          strings.TrimRight(envString("FILES_CDN_BASE_URL", ""), "/"),
 		userRepo:              userRepo,
 		sessionRepo:           sessionRepo,
 		frontendBaseURL:       frontendBase,
@@ -655,6 +791,14 @@ func New(store *storage.Store, userRepo repository.UserRepository, sessionRepo r
 	}
 	srv.xenditRedirectURL = strings.TrimRight(redirectURL, "/")
 
+	linkSecret := []byte(envString("LINK_TOKEN_SECRET", envString("MAIL_REPLY_SECRET", os.Getenv("JWT_SECRET"))))
+	if len(linkSecret) == 0 {
+		linkSecret = []byte("signed-link-token")
+	}
+	srv.linkSecret = linkSecret
+	srv.replyEmailAddress = strings.TrimSpace(os.Getenv("REPLY_EMAIL_ADDRESS"))
+	srv.inboundMailToken = strings.TrimSpace(os.Getenv("INBOUND_MAIL_TOKEN"))
+
 	clientID := strings.TrimSpace(os.Getenv("GOOGLE_CLIENT_ID"))
 	clientSecret := strings.TrimSpace(os.Getenv("GOOGLE_CLIENT_SECRET"))
 	googleRedirectURL := envString("GOOGLE_REDIRECT_URL", backendBase+"/api/auth/google/callback")
@@ -680,6 +824,20 @@ func New(store *storage.Store, userRepo repository.UserRepository, sessionRepo r
 	}
 	srv.paymentSyncInterval = syncInterval
 	srv.startPaymentSyncLoop()
+	srv.startWaitlistLoop()
+	srv.startExportCleanupLoop()
+	srv.recovery = loadCheckoutRecoveryConfig()
+	srv.images = loadImageOptions()
+	srv.uploadPolicy = loadUploadPolicy()
+	srv.watermark = loadWatermark()
+	srv.startMediaGCLoop()
+	if count, err := srv.Store.BackfillGalleryEmbeds(utils.ParseEmbedLink); err != nil {
+		log.Printf("gallery embed backfill failed: %v", err)
+	} else if count > 0 {
+		log.Printf("parsed video and link embeds for %d gallery items", count)
+	}
+	srv.startCheckoutRecoveryLoop()
+	srv.startReviewRequestLoop()
 
 	return srv
 }
@@ -724,12 +882,20 @@ func (s *Server) Handler() http.Handler {
 	mux.Handle("/api/healthz", http.HandlerFunc(s.handleHealth))
 	mux.Handle("/api/services", s.wrapCORS(http.HandlerFunc(s.handleServices)))
 	mux.Handle("/api/services/", s.wrapCORS(http.HandlerFunc(s.handleServiceBySlug)))
+	mux.Handle("/api/search", s.wrapCORS(http.HandlerFunc(s.handleSearch)))
 	mux.Handle("/api/gallery", s.wrapCORS(http.HandlerFunc(s.handleGallery)))
 	mux.Handle("/api/experiences", s.wrapCORS(http.HandlerFunc(s.handleExperiences)))
+	mux.Handle("/api/case-studies", s.wrapCORS(http.HandlerFunc(s.handleCaseStudies)))
+	mux.Handle("/api/case-studies/", s.wrapCORS(http.HandlerFunc(s.handleCaseStudyBySlug)))
 	mux.Handle("/api/categories", s.wrapCORS(http.HandlerFunc(s.handleCategories)))
 	mux.Handle("/api/xendit/webhook", http.HandlerFunc(s.handleXenditWebhook))
+	mux.Handle("/api/mail/inbound", http.HandlerFunc(s.handleInboundMail))
+	mux.Handle("/api/bookings/calendar.ics", http.HandlerFunc(s.handleBookingCalendarFeed))
 	mux.Handle("/api/orders", s.wrapCORS(http.HandlerFunc(s.handleOrders)))
 	mux.Handle("/api/orders/", s.wrapCORS(http.HandlerFunc(s.handleOrderRoutes)))
+	mux.Handle("/api/quotes", s.wrapCORS(http.HandlerFunc(s.handleQuotes)))
+	mux.Handle("/api/quotes/", s.wrapCORS(http.HandlerFunc(s.handleQuoteRoutes)))
+	mux.Handle("/api/proofing/", s.wrapCORS(http.HandlerFunc(s.handleProofingRoutes)))
 	mux.Handle("/api/promocode/validate", s.wrapCORS(http.HandlerFunc(s.handlePromoValidate)))
 	mux.Handle("/api/contact", s.wrapCORS(http.HandlerFunc(s.handleContact)))
 	mux.Handle("/api/analytics/events", s.wrapCORS(http.HandlerFunc(s.handleAnalyticsEvent)))
@@ -748,12 +914,40 @@ func (s *Server) Handler() http.Handler {
 	mux.Handle("/api/admin/services/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminServiceByID))))
 	mux.Handle("/api/admin/gallery", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminGallery))))
 	mux.Handle("/api/admin/gallery/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminGalleryByID))))
+	mux.Handle("/api/admin/gallery/reorder", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminGalleryReorder))))
 	mux.Handle("/api/admin/experiences", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminExperiences))))
 	mux.Handle("/api/admin/experiences/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminExperienceByID))))
+	mux.Handle("/api/admin/case-studies", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminCaseStudies))))
+	mux.Handle("/api/admin/case-studies/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminCaseStudyByID))))
+	mux.Handle("/api/admin/media", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminMedia))))
+	mux.Handle("/api/admin/media/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminMediaByID))))
+	mux.Handle("/api/admin/watermark", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminWatermark))))
+	mux.Handle("/api/admin/watermark/regenerate", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminWatermarkRegenerate))))
 	mux.Handle("/api/admin/categories", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminCategories))))
 	mux.Handle("/api/admin/categories/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminCategoryByID))))
 	mux.Handle("/api/admin/orders", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminOrders))))
 	mux.Handle("/api/admin/orders/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminOrderActions))))
+	mux.Handle("/api/admin/order-threads", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminOrderThreads))))
+	mux.Handle("/api/admin/bookings", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminBookings))))
+	mux.Handle("/api/admin/bookings/feed/rotate", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminBookingFeedRotate))))
+	mux.Handle("/api/admin/blackout-dates", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminBlackoutDates))))
+	mux.Handle("/api/admin/blackout-dates/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminBlackoutDateByID))))
+	mux.Handle("/api/admin/quotes", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminQuotes))))
+	mux.Handle("/api/admin/quotes/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminQuoteByID))))
+	mux.Handle("/api/admin/proofing", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminProofing))))
+	mux.Handle("/api/admin/proofing/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminProofingByID))))
+	mux.Handle("/api/admin/waitlist", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminWaitlist))))
+	mux.Handle("/api/admin/waitlist/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminWaitlistByID))))
+	mux.Handle("/api/admin/exports", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminExports))))
+	mux.Handle("/api/admin/exports/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminExportByPath))))
+	mux.Handle("/api/exports/", http.HandlerFunc(s.handleExportDownload))
+	mux.Handle("/api/recovery/", http.HandlerFunc(s.handleRecoveryRoutes))
+	mux.Handle("/api/reviews/", s.wrapCORS(http.HandlerFunc(s.handleReviewRoutes)))
+	mux.Handle("/api/admin/reviews", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminReviews))))
+	mux.Handle("/api/admin/reviews/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminReviewByID))))
+	mux.Handle("/api/admin/checkout-recovery", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminCheckoutRecovery))))
+	mux.Handle("/api/admin/revisions", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminRevisions))))
+	mux.Handle("/api/admin/revisions/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminRevisionRoutes))))
 	mux.Handle("/api/admin/messages", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminMessages))))
 	mux.Handle("/api/admin/promocodes", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminPromoCodes))))
 	mux.Handle("/api/admin/promocodes/", s.wrapCORS(s.requireAdmin(http.HandlerFunc(s.handleAdminPromoCodeByID))))
@@ -763,8 +957,8 @@ func (s *Server) Handler() http.Handler {
 	if paymentRouter := s.newPaymentRouter(); paymentRouter != nil {
 		mux.Handle("/api/payments/", s.wrapCORS(paymentRouter))
 	}
-	staticFS := http.FileServer(http.Dir(s.UploadDir))
-	mux.Handle("/api/static/", http.StripPrefix("/api/static/", staticFS))
+	mux.Handle("/api/static/", staticFileHandler(s.staticFiles()))
+	mux.Handle("/api/files/", staticFileHandler(http.HandlerFunc(s.handlePrivateFile)))
 	return mux
 }
 
@@ -914,33 +1108,29 @@ func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
 		s.methodNotAllowed(w, r)
 		return
 	}
+	query, err := parseCatalogQuery(r.URL.Query())
+	if err != nil {
+		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now().UTC()
 	services := s.Store.ListServices()
+	live := services[:0]
+	for _, svc := range services {
+		if storage.IsLive(svc.Publishing, now) {
+			live = append(live, svc)
+		}
+	}
+	services = live
 	categories := s.Store.ListCategories()
 	catMap := make(map[uint]models.Category, len(categories))
 	for _, c := range categories {
 		catMap[c.ID] = c
 	}
+	tree := newCategoryTree(categories)
+	query.categories = tree.expandFilter(query.categories)
 	metrics := s.computeServiceMetrics()
-	filter := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("category")))
-	if filter != "" {
-		filtered := services[:0]
-		for _, svc := range services {
-			if cat, ok := catMap[svc.CategoryID]; ok {
-				if cat.Slug == filter || strings.ToLower(cat.Name) == filter {
-					filtered = append(filtered, svc)
-				}
-			}
-		}
-		services = filtered
-	}
-	type serviceResponse struct {
-		models.Service
-		Category       string  `json:"category"`
-		CategorySlug   string  `json:"category_slug"`
-		AverageRating  float64 `json:"average_rating"`
-		RatingCount    int     `json:"rating_count"`
-		CompletedCount int     `json:"completed_count"`
-	}
+	availability := s.Store.ListServiceAvailability(time.Now().UTC())
 	var out []serviceResponse
 	for _, svc := range services {
 		categoryName := ""
@@ -950,16 +1140,32 @@ func (s *Server) handleServices(w http.ResponseWriter, r *http.Request) {
 			categorySlug = cat.Slug
 		}
 		m := metrics[svc.ID]
-		out = append(out, serviceResponse{
+		item := serviceResponse{
 			Service:        svc,
 			Category:       categoryName,
 			CategorySlug:   categorySlug,
 			AverageRating:  averageRating(m.ratingSum, m.ratingCount),
 			RatingCount:    m.ratingCount,
 			CompletedCount: m.completedCount,
-		})
+			PackageMetrics: m.packageSummary(),
+			StartingPrice:  storage.ServiceStartingPrice(&svc),
+		}
+		if a, ok := availability[svc.ID]; ok {
+			item.Availability = &a
+		}
+		out = append(out, item)
+	}
+	if query.envelope {
+		s.writeJSON(w, http.StatusOK, s.browseCatalog(out, query, tree))
+		return
+	}
+	filtered := out[:0]
+	for _, item := range out {
+		if query.matches(item, false, false) {
+			filtered = append(filtered, item)
+		}
 	}
-	s.writeJSON(w, http.StatusOK, out)
+	s.writeJSON(w, http.StatusOK, filtered)
 }
 
 func (s *Server) handleGallery(w http.ResponseWriter, r *http.Request) {
@@ -967,22 +1173,42 @@ func (s *Server) handleGallery(w http.ResponseWriter, r *http.Request) {
 		s.methodNotAllowed(w, r)
 		return
 	}
+	query, err := s.galleryQueryFromRequest(r)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	now := time.Now().UTC()
 	allItems := s.Store.ListGalleryItems("")
-	section := strings.TrimSpace(strings.ToLower(r.URL.Query().Get("section")))
-	items := make([]models.GalleryItem, 0, len(allItems))
-	if section == "" {
-		items = allItems
+	visible := allItems[:0]
+	for _, item := range allItems {
+		if storage.IsLive(item.Publishing, now) || s.previewAllowed(r, previewKindGallery, item.ID) {
+			visible = append(visible, item)
+		}
+	}
+	allItems = visible
+	sectionItems := make([]models.GalleryItem, 0, len(allItems))
+	if query.Section == "" {
+		sectionItems = allItems
 	} else {
 		for _, item := range allItems {
-			if strings.TrimSpace(strings.ToLower(item.Section)) == section {
-				items = append(items, item)
+			if strings.TrimSpace(strings.ToLower(item.Section)) == query.Section {
+				sectionItems = append(sectionItems, item)
 			}
 		}
 	}
-	filters := buildGalleryFilters(allItems)
+	items := make([]models.GalleryItem, 0, len(sectionItems))
+	for _, item := range sectionItems {
+		if query.matches(item) {
+			items = append(items, item)
+		}
+	}
 	s.writeJSON(w, http.StatusOK, map[string]any{
-		"items":   items,
-		"filters": filters,
+		"items":   query.page(items),
+		"filters": buildGalleryFilters(sectionItems),
+		"total":   len(items),
+		"limit":   query.Limit,
+		"offset":  query.Offset,
 	})
 }
 
@@ -991,40 +1217,71 @@ func (s *Server) handleExperiences(w http.ResponseWriter, r *http.Request) {
 		s.methodNotAllowed(w, r)
 		return
 	}
+	now := time.Now().UTC()
 	experiences := s.Store.ListExperiences()
-	s.writeJSON(w, http.StatusOK, experiences)
+	visible := experiences[:0]
+	for _, exp := range experiences {
+		if storage.IsLive(exp.Publishing, now) || s.previewAllowed(r, previewKindExperience, exp.ID) {
+			visible = append(visible, exp)
+		}
+	}
+	s.writeJSON(w, http.StatusOK, visible)
 }
 
 func (s *Server) handleServiceBySlug(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimPrefix(r.URL.Path, "/api/services/")
+	if strings.HasSuffix(slug, "/waitlist") {
+		s.handleServiceWaitlist(w, r, strings.TrimSuffix(slug, "/waitlist"))
+		return
+	}
+	if strings.HasSuffix(slug, "/reviews") {
+		s.handleServiceReviews(w, r, strings.TrimSuffix(slug, "/reviews"))
+		return
+	}
 	if r.Method != http.MethodGet {
 		s.methodNotAllowed(w, r)
 		return
 	}
-	slug := strings.TrimPrefix(r.URL.Path, "/api/services/")
 	if slug == "" {
 		s.notFound(w)
 		return
 	}
-	svc, ok := s.Store.GetServiceBySlug(slug)
+	if strings.HasSuffix(slug, "/slots") {
+		s.handleServiceSlots(w, r, strings.TrimSuffix(slug, "/slots"))
+		return
+	}
+	svc, ok := s.visibleServiceBySlug(r, slug)
 	if !ok {
 		s.notFound(w)
 		return
 	}
 	category, _ := s.Store.GetCategoryByID(svc.CategoryID)
+	tree := newCategoryTree(s.Store.ListCategories())
 	metrics := s.computeServiceMetrics()
 	m := metrics[svc.ID]
+	availability, _ := s.Store.GetServiceAvailability(svc.ID, time.Now().UTC())
 	response := struct {
 		*models.Service
-		Category       *models.Category `json:"category,omitempty"`
-		AverageRating  float64          `json:"average_rating"`
-		RatingCount    int              `json:"rating_count"`
-		CompletedCount int              `json:"completed_count"`
+		Category       *models.Category             `json:"category,omitempty"`
+		Breadcrumbs    []categoryCrumb              `json:"breadcrumbs,omitempty"`
+		AverageRating  float64                      `json:"average_rating"`
+		RatingCount    int                          `json:"rating_count"`
+		CompletedCount int                          `json:"completed_count"`
+		PackageMetrics map[string]packageMetrics    `json:"package_metrics,omitempty"`
+		Availability   *storage.ServiceAvailability `json:"availability,omitempty"`
+		StartingPrice  float64                      `json:"starting_price"`
+		RelatedWork    []models.GalleryItem         `json:"related_work"`
 	}{
 		Service:        svc,
 		Category:       category,
+		Breadcrumbs:    tree.breadcrumbs(svc.CategoryID),
 		AverageRating:  averageRating(m.ratingSum, m.ratingCount),
 		RatingCount:    m.ratingCount,
 		CompletedCount: m.completedCount,
+		PackageMetrics: m.packageSummary(),
+		Availability:   availability,
+		StartingPrice:  storage.ServiceStartingPrice(svc),
+		RelatedWork:    s.relatedGalleryItems(svc.ID),
 	}
 	s.writeJSON(w, http.StatusOK, response)
 }
@@ -1034,7 +1291,15 @@ func (s *Server) handleCategories(w http.ResponseWriter, r *http.Request) {
 		s.methodNotAllowed(w, r)
 		return
 	}
-	s.writeJSON(w, http.StatusOK, s.Store.ListCategories())
+	now := time.Now().UTC()
+	counts := make(map[uint]int)
+	for _, svc := range s.Store.ListServices() {
+		if storage.IsLive(svc.Publishing, now) {
+			counts[svc.CategoryID]++
+		}
+	}
+	tree := newCategoryTree(s.Store.ListCategories())
+	s.writeJSON(w, http.StatusOK, tree.nodes(counts, r.URL.Query().Get("tree") == "true"))
 }
 
 func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
@@ -1060,15 +1325,20 @@ func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
 		s.writeJSON(w, http.StatusOK, out)
 	case http.MethodPost:
 		var payload struct {
-			ServiceSlug     string `json:"service_slug"`
-			Name            string `json:"customer_name"`
-			Email           string `json:"customer_email"`
-			Phone           string `json:"customer_phone"`
-			Notes           string `json:"notes"`
-			PromoCode       string `json:"promo_code"`
-			PaymentCategory string `json:"payment_category"`
-			PaymentChannel  string `json:"payment_channel"`
-			CardTokenID     string `json:"card_token_id"`
+			ServiceSlug     string   `json:"service_slug"`
+			Name            string   `json:"customer_name"`
+			Email           string   `json:"customer_email"`
+			Phone           string   `json:"customer_phone"`
+			Notes           string   `json:"notes"`
+			PromoCode       string   `json:"promo_code"`
+			PaymentCategory string   `json:"payment_category"`
+			PaymentChannel  string   `json:"payment_channel"`
+			CardTokenID     string   `json:"card_token_id"`
+			Package         string   `json:"package"`
+			AddOns          []string `json:"add_ons"`
+			BookingStart    string   `json:"booking_start"`
+			WaitlistToken   string   `json:"waitlist_token"`
+			RecoveryToken   string   `json:"recovery_token"`
 		}
 		if err := s.decodeJSON(r.Body, &payload); err != nil {
 			s.writeError(w, http.StatusBadRequest, err)
@@ -1091,49 +1361,19 @@ func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
 			s.writeErrorMsg(w, http.StatusBadRequest, "payment category is required")
 			return
 		}
-		svc, ok := s.Store.GetServiceBySlug(payload.ServiceSlug)
+		svc, ok := s.visibleServiceBySlug(r, payload.ServiceSlug)
 		if !ok {
 			s.writeErrorMsg(w, http.StatusNotFound, "Service not found")
 			return
 		}
-		normalizedCategory := strings.ToUpper(payload.PaymentCategory)
-		normalizedChannel := strings.ToUpper(payload.PaymentChannel)
-		if normalizedCategory != "QRIS" && normalizedCategory != "CARD" && normalizedChannel == "" {
-			s.writeErrorMsg(w, http.StatusBadRequest, "payment channel is required for selected category")
+		normalizedCategory, normalizedChannel, err := normalizePaymentSelection(payload.PaymentCategory, payload.PaymentChannel)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
 			return
 		}
-		switch normalizedCategory {
-		case "QRIS":
-		case "VIRTUAL_ACCOUNT":
-			if !isValidBankCode(normalizedChannel) {
-				s.writeErrorMsg(w, http.StatusBadRequest, "invalid virtual account bank code")
-				return
-			}
-		case "EWALLET":
-			if !isValidEWalletChannel(normalizedChannel) {
-				s.writeErrorMsg(w, http.StatusBadRequest, "invalid e-wallet channel")
-				return
-			}
-		case "RETAIL_OUTLET":
-			if !isValidRetailOutlet(normalizedChannel) {
-				s.writeErrorMsg(w, http.StatusBadRequest, "invalid retail outlet channel")
-				return
-			}
-		case "PAYLATER":
-			if !isValidPayLaterChannel(normalizedChannel) {
-				s.writeErrorMsg(w, http.StatusBadRequest, "invalid paylater channel")
-				return
-			}
-		case "CARD":
-			if normalizedChannel == "" {
-				normalizedChannel = "CARD"
-			}
-			if normalizedChannel != "CARD" && !isValidCardBrand(normalizedChannel) {
-				s.writeErrorMsg(w, http.StatusBadRequest, "unsupported card brand")
-				return
-			}
-		default:
-			s.writeErrorMsg(w, http.StatusBadRequest, "unsupported payment category")
+		selection, err := storage.ResolveServiceSelection(svc, payload.Package, payload.AddOns)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
 			return
 		}
 		order := &models.Order{
@@ -1142,31 +1382,37 @@ func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
 			CustomerEmail: payload.Email,
 			CustomerPhone: payload.Phone,
 			Notes:         payload.Notes,
-			Amount:        svc.Price,
+			LineItems:     selection.LineItems,
+			Package:       selection.Package,
+			AddOns:        selection.AddOns,
+			Amount:        selection.Amount,
 			PromoCode:     payload.PromoCode,
 			Status:        "pending",
 		}
+		if svc.Booking != nil && svc.Booking.Enabled {
+			bookingStart, err := time.Parse(time.RFC3339, strings.TrimSpace(payload.BookingStart))
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "booking_start is required for this service")
+				return
+			}
+			order.BookingStart = bookingStart.UTC()
+		}
+		if token := strings.TrimSpace(payload.WaitlistToken); token != "" {
+			entryID, err := s.verifyLinkToken("waitlist", token)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid waitlist reservation")
+				return
+			}
+			order.WaitlistEntryID = entryID
+		}
+		if token := strings.TrimSpace(payload.RecoveryToken); token != "" {
+			if recoveredFrom, err := s.verifyLinkToken(recoveryPurpose, token); err == nil {
+				order.RecoveredFromID = recoveredFrom
+			}
+		}
 		created, err := s.Store.CreateOrder(order)
 		if err != nil {
-			status := http.StatusInternalServerError
-			msg := err.Error()
-			switch {
-			case errors.Is(err, storage.ErrPromoNotFound):
-				status = http.StatusNotFound
-				msg = "promo code not found"
-			case errors.Is(err, storage.ErrPromoInactive):
-				status = http.StatusBadRequest
-				msg = "promo code inactive"
-			case errors.Is(err, storage.ErrPromoNotStarted):
-				status = http.StatusBadRequest
-				msg = "promo code not yet valid"
-			case errors.Is(err, storage.ErrPromoExpired):
-				status = http.StatusBadRequest
-				msg = "promo code expired"
-			case errors.Is(err, storage.ErrPromoUsageExceeded):
-				status = http.StatusBadRequest
-				msg = "promo code usage limit reached"
-			}
+			status, msg := orderCreationError(err)
 			s.writeErrorMsg(w, status, msg)
 			return
 		}
@@ -1197,36 +1443,50 @@ func (s *Server) handleOrders(w http.ResponseWriter, r *http.Request) {
 		if updatedOrder != nil {
 			created = updatedOrder
 		}
-		response := map[string]any{
-			"order": created,
-		}
-		if paymentURL := s.paymentPageURL(created.ID); paymentURL != "" {
-			response["payment_page_url"] = paymentURL
-		}
-		if tx != nil {
-			response["transaction"] = tx
-			if tx.InvoiceURL != "" {
-				response["invoice_url"] = tx.InvoiceURL
-			}
-			if tx.CheckoutURL != "" {
-				response["checkout_url"] = tx.CheckoutURL
-			}
-			if tx.QRCodeURL != "" {
-				response["qr_code_url"] = tx.QRCodeURL
-			}
-			if tx.VirtualAccountNumber != "" {
-				response["virtual_account_number"] = tx.VirtualAccountNumber
-			}
-			if tx.PaymentCode != "" {
-				response["payment_code"] = tx.PaymentCode
-			}
-		}
-		s.writeJSON(w, http.StatusCreated, response)
+		s.writeJSON(w, http.StatusCreated, s.orderPaymentResponse(created, tx))
 	default:
 		s.methodNotAllowed(w, r)
 	}
 }
 
+func orderCreationError(err error) (int, string) {
+	status := http.StatusInternalServerError
+	msg := err.Error()
+	switch {
+	case errors.Is(err, storage.ErrSlotUnavailable):
+		status = http.StatusConflict
+		msg = "selected booking slot is no longer available"
+	case errors.Is(err, storage.ErrServiceNotBookable):
+		status = http.StatusBadRequest
+		msg = "service is not bookable"
+	case errors.Is(err, storage.ErrServiceSoldOut):
+		status = http.StatusConflict
+		msg = "service is sold out"
+	case errors.Is(err, storage.ErrReservationInvalid):
+		status = http.StatusGone
+		msg = "waitlist reservation is invalid or expired"
+	case errors.Is(err, storage.ErrPromoNotFound):
+		status = http.StatusNotFound
+		msg = "promo code not found"
+	case errors.Is(err, storage.ErrPromoInactive):
+		status = http.StatusBadRequest
+		msg = "promo code inactive"
+	case errors.Is(err, storage.ErrPromoNotStarted):
+		status = http.StatusBadRequest
+		msg = "promo code not yet valid"
+	case errors.Is(err, storage.ErrPromoExpired):
+		status = http.StatusBadRequest
+		msg = "promo code expired"
+	case errors.Is(err, storage.ErrPromoUsageExceeded):
+		status = http.StatusBadRequest
+		msg = "promo code usage limit reached"
+	case errors.Is(err, storage.ErrPromoNotEligible):
+		status = http.StatusForbidden
+		msg = "promo code is not valid for this customer"
+	}
+	return status, msg
+}
+
 func (s *Server) createInvoiceForOrder(ctx context.Context, order *models.Order) (*models.PaymentTransaction, *models.Order, error) {
 	if order == nil {
 		return nil, nil, errors.New("order is required")
@@ -1420,6 +1680,34 @@ func (s *Server) invoiceRedirectURL(orderID uint, status string) string {
 	return base + separator + params.Encode()
 }
 
+func (s *Server) orderPaymentResponse(order *models.Order, tx *models.PaymentTransaction) map[string]any {
+	response := map[string]any{
+		"order": order,
+	}
+	if paymentURL := s.paymentPageURL(order.ID); paymentURL != "" {
+		response["payment_page_url"] = paymentURL
+	}
+	if tx != nil {
+		response["transaction"] = tx
+		if tx.InvoiceURL != "" {
+			response["invoice_url"] = tx.InvoiceURL
+		}
+		if tx.CheckoutURL != "" {
+			response["checkout_url"] = tx.CheckoutURL
+		}
+		if tx.QRCodeURL != "" {
+			response["qr_code_url"] = tx.QRCodeURL
+		}
+		if tx.VirtualAccountNumber != "" {
+			response["virtual_account_number"] = tx.VirtualAccountNumber
+		}
+		if tx.PaymentCode != "" {
+			response["payment_code"] = tx.PaymentCode
+		}
+	}
+	return response
+}
+
 func (s *Server) paymentPageURL(orderID uint) string {
 	base := strings.TrimSpace(s.frontendBaseURL)
 	if base == "" {
@@ -2759,6 +3047,7 @@ func (s *Server) handlePromoValidate(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Code  string  `json:"code"`
 		Total float64 `json:"total"`
+		Email string  `json:"email"`
 	}
 	if err := s.decodeJSON(r.Body, &payload); err != nil {
 		s.writeError(w, http.StatusBadRequest, err)
@@ -2773,7 +3062,7 @@ func (s *Server) handlePromoValidate(w http.ResponseWriter, r *http.Request) {
 	if total < 0 {
 		total = 0
 	}
-	promo, err := s.Store.ValidatePromoCode(code, time.Now().UTC())
+	promo, err := s.Store.ValidatePromoCode(code, strings.TrimSpace(payload.Email), time.Now().UTC())
 	if err != nil {
 		status := http.StatusInternalServerError
 		msg := err.Error()
@@ -2793,6 +3082,9 @@ func (s *Server) handlePromoValidate(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, storage.ErrPromoUsageExceeded):
 			status = http.StatusBadRequest
 			msg = "promo code usage limit reached"
+		case errors.Is(err, storage.ErrPromoNotEligible):
+			status = http.StatusForbidden
+			msg = "promo code is not valid for this customer"
 		}
 		s.writeErrorMsg(w, status, msg)
 		return
@@ -2943,6 +3235,10 @@ func (s *Server) handleAnalyticsEvent(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleOrderRoutes(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/orders/")
+	if strings.HasSuffix(path, "/messages") || strings.HasSuffix(path, "/messages/read") {
+		s.handleOrderMessages(w, r)
+		return
+	}
 	if r.Method == http.MethodPost && strings.HasSuffix(path, "/request") {
 		s.handleOrderRequest(w, r)
 		return
@@ -2962,6 +3258,9 @@ func (s *Server) handleOrderRoutes(w http.ResponseWriter, r *http.Request) {
 	s.notFound(w)
 }
 
+// handleOrderRating accepts a review for an order through the legacy rating
+// route. It needs the same signed review token as /api/reviews/, passed as the
+// token query parameter.
 func (s *Server) handleOrderRating(w http.ResponseWriter, r *http.Request) {
 	path := strings.TrimPrefix(r.URL.Path, "/api/orders/")
 	idStr := strings.TrimSuffix(path, "/rating")
@@ -2970,29 +3269,34 @@ func (s *Server) handleOrderRating(w http.ResponseWriter, r *http.Request) {
 		s.writeErrorMsg(w, http.StatusBadRequest, "invalid order id")
 		return
 	}
-
-	var payload struct {
-		Rating int    `json:"rating"`
-		Review string `json:"review"`
+	order, ok := s.Store.GetOrderByID(id)
+	if !ok {
+		s.notFound(w)
+		return
 	}
-	if err := s.decodeJSON(r.Body, &payload); err != nil {
-		s.writeError(w, http.StatusBadRequest, err)
+	// The review link from the email works for anyone holding it; in the app
+	// the signed-in owner of the order may rate it without one.
+	tokenID, err := s.verifyLinkToken(reviewPurpose, strings.TrimSpace(r.URL.Query().Get("token")))
+	if (err != nil || tokenID != id) && !s.requestOwnsOrder(r, order) {
+		s.writeErrorMsg(w, http.StatusForbidden, "invalid review link")
 		return
 	}
+	s.submitReview(w, r, order)
+}
 
-	payload.Review = strings.TrimSpace(payload.Review)
-
-	updatedOrder, err := s.Store.SetOrderRating(id, payload.Rating, payload.Review)
-	if err != nil {
-		if errors.Is(err, os.ErrNotExist) {
-			s.notFound(w)
-			return
+// requestOwnsOrder reports whether the signed-in customer, identified by the
+// access token or the session cookie, placed the order.
+func (s *Server) requestOwnsOrder(r *http.Request, order *models.Order) bool {
+	email := ""
+	if token := s.accessTokenFromRequest(r); token != "" {
+		if claims, err := auth.ParseAccessToken(token, s.accessTokenSecret); err == nil {
+			email = claims.Email
 		}
-		s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
-		return
+	} else if _, _, user, err := s.parseRefreshSession(r.Context(), r); err == nil {
+		email = user.Email
 	}
-
-	s.writeJSON(w, http.StatusOK, updatedOrder)
+	email = strings.TrimSpace(email)
+	return email != "" && strings.EqualFold(email, strings.TrimSpace(order.CustomerEmail))
 }
 
 func (s *Server) handleOrderCardCharge(w http.ResponseWriter, r *http.Request) {
@@ -3686,7 +3990,9 @@ func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
 			Summary     string                    `json:"summary"`
 			Description string                    `json:"description"`
 			AddOns      []models.AddOn            `json:"add_ons"`
+			Packages    []models.ServicePackage   `json:"packages,omitempty"`
 			Highlights  []models.ServiceHighlight `json:"highlights"`
+			models.Publishing
 		}
 		var out []adminService
 		for _, svc := range services {
@@ -3700,7 +4006,9 @@ func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
 				Summary:     svc.Summary,
 				Description: svc.Description,
 				AddOns:      append([]models.AddOn(nil), svc.AddOns...),
+				Packages:    svc.Packages,
 				Highlights:  append([]models.ServiceHighlight(nil), svc.Highlights...),
+				Publishing:  svc.Publishing,
 			})
 		}
 		s.writeJSON(w, http.StatusOK, out)
@@ -3717,6 +4025,11 @@ func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
 			s.writeError(w, http.StatusBadRequest, err)
 			return
 		}
+		service.Publishing, err = publishingFromForm(r.MultipartForm)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if service.Title == "" {
 			s.writeErrorMsg(w, http.StatusBadRequest, "title is required")
 			return
@@ -3733,36 +4046,37 @@ func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
 			s.writeErrorMsg(w, http.StatusBadRequest, "invalid category")
 			return
 		}
+		if !s.checkUploads(w, r.MultipartForm, imageField("thumbnail"), imageField("gallery_images")) {
+			return
+		}
 
+		uploader := adminEmailFromContext(r.Context())
+		var uploaded []string
 		if file := getFile(r.MultipartForm, "thumbnail"); file != nil {
-			name, err := utils.SaveUploadedFile(file, s.UploadDir)
+			publicPath, err := s.saveMedia(file, "", uploader)
 			if err != nil {
-				s.writeError(w, http.StatusInternalServerError, err)
+				s.writeUploadError(w, err, http.StatusInternalServerError)
 				return
 			}
-			service.Thumbnail = "/api/static/" + name
+			service.Thumbnail = publicPath
+			uploaded = append(uploaded, publicPath)
 		}
 
 		if files := getFiles(r.MultipartForm, "gallery_images"); len(files) > 0 {
 			for _, file := range files {
-				name, err := utils.SaveUploadedFile(file, s.UploadDir)
+				publicPath, err := s.saveMedia(file, "", uploader)
 				if err != nil {
-					s.deleteStaticFile(service.Thumbnail)
-					for _, p := range service.GalleryImages {
-						s.deleteStaticFile(p)
-					}
-					s.writeError(w, http.StatusInternalServerError, err)
+					s.discardUploads(uploaded...)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
 					return
 				}
-				service.GalleryImages = append(service.GalleryImages, "/api/static/"+name)
+				service.GalleryImages = append(service.GalleryImages, publicPath)
+				uploaded = append(uploaded, publicPath)
 			}
 		}
 		created, err := s.Store.CreateService(service)
 		if err != nil {
-			s.deleteStaticFile(service.Thumbnail)
-			for _, p := range service.GalleryImages {
-				s.deleteStaticFile(p)
-			}
+			s.discardUploads(uploaded...)
 			s.writeError(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -3773,6 +4087,17 @@ func (s *Server) handleAdminServices(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleAdminServiceByID(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/booking") {
+		s.handleAdminServiceBooking(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/capacity") {
+		s.handleAdminServiceCapacity(w, r)
+		return
+	}
+	if s.routeAdminPublishing(w, r, "/api/admin/services/", previewKindService) {
+		return
+	}
 	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/services/"))
 	if err != nil {
 		s.writeErrorMsg(w, http.StatusBadRequest, "invalid service id")
@@ -3808,31 +4133,35 @@ func (s *Server) handleAdminServiceByID(w http.ResponseWriter, r *http.Request)
 			s.writeErrorMsg(w, http.StatusBadRequest, "invalid category")
 			return
 		}
+		if !s.checkUploads(w, r.MultipartForm, imageField("thumbnail"), imageField("gallery_images")) {
+			return
+		}
+		uploader := adminEmailFromContext(r.Context())
+		var uploaded []string
 		if file := getFile(r.MultipartForm, "thumbnail"); file != nil {
-			name, _ := utils.SaveUploadedFile(file, s.UploadDir)
-			update.Thumbnail = "/api/static/" + name
-			if existing != nil {
-				s.deleteStaticFile(existing.Thumbnail)
+			publicPath, err := s.saveMedia(file, "", uploader)
+			if err != nil {
+				s.writeUploadError(w, err, http.StatusInternalServerError)
+				return
 			}
+			update.Thumbnail = publicPath
+			uploaded = append(uploaded, publicPath)
 		}
 		if files := getFiles(r.MultipartForm, "gallery_images"); len(files) > 0 {
-			var galleryPaths []string
 			for _, file := range files {
-				name, _ := utils.SaveUploadedFile(file, s.UploadDir)
-				galleryPaths = append(galleryPaths, "/api/static/"+name)
-			}
-			update.GalleryImages = galleryPaths
-			if existing != nil {
-				for _, p := range existing.GalleryImages {
-					s.deleteStaticFile(p)
+				publicPath, err := s.saveMedia(file, "", uploader)
+				if err != nil {
+					s.discardUploads(uploaded...)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
+					return
 				}
+				update.GalleryImages = append(update.GalleryImages, publicPath)
+				uploaded = append(uploaded, publicPath)
 			}
 		}
-		if _, err := s.Store.UpdateService(id, update); err != nil {
-			s.deleteStaticFile(update.Thumbnail)
-			for _, p := range update.GalleryImages {
-				s.deleteStaticFile(p)
-			}
+		updated, err := s.Store.UpdateService(id, update, uploader)
+		if err != nil {
+			s.discardUploads(uploaded...)
 			status := http.StatusInternalServerError
 			if errors.Is(err, os.ErrNotExist) {
 				status = http.StatusNotFound
@@ -3840,13 +4169,10 @@ func (s *Server) handleAdminServiceByID(w http.ResponseWriter, r *http.Request)
 			s.writeError(w, status, err)
 			return
 		}
+		s.releaseUploads(droppedURLs(serviceFileURLs(existing), serviceFileURLs(updated))...)
 		s.writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 	case http.MethodDelete:
-		if err := s.Store.DeleteService(id); err != nil {
-			s.writeError(w, http.StatusInternalServerError, err)
-			return
-		}
-		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		s.archiveService(w, r, id)
 	default:
 		s.methodNotAllowed(w, r)
 	}
@@ -3871,27 +4197,32 @@ func (s *Server) handleAdminGallery(w http.ResponseWriter, r *http.Request) {
 		}
 
 		item := formData.Item
+		item.Publishing, err = publishingFromForm(r.MultipartForm)
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		normalizeGalleryItemFields(item)
-		savedPaths := make([]string, 0, 1)
-		cleanup := func() {
-			for _, path := range savedPaths {
-				s.deleteStaticFile(path)
-			}
+		if !s.checkUploads(w, r.MultipartForm, imageField("thumbnail"), imageField("asset_images"), pdfField("asset_pdfs")) {
+			return
 		}
+		uploader := adminEmailFromContext(r.Context())
+		publicUse := getFormValue(r.MultipartForm, "public_use_approved") == "true"
+		savedPaths := make([]string, 0, 1)
 		success := false
 		defer func() {
 			if !success {
-				cleanup()
+				s.discardUploads(savedPaths...)
 			}
 		}()
 
 		if file := getFile(r.MultipartForm, "thumbnail"); file != nil {
-			name, err := utils.SaveUploadedFile(file, s.UploadDir)
+			publicPath, err := s.saveGalleryMedia(file, uploader, publicUse)
 			if err != nil {
-				s.writeError(w, http.StatusInternalServerError, err)
+				s.writeUploadError(w, err, http.StatusInternalServerError)
 				return
 			}
-			item.Thumbnail = "/api/static/" + name
+			item.Thumbnail = publicPath
 			savedPaths = append(savedPaths, item.Thumbnail)
 		} else if formData.ExistingThumbnail != "" {
 			item.Thumbnail = strings.TrimSpace(formData.ExistingThumbnail)
@@ -3903,24 +4234,22 @@ func (s *Server) handleAdminGallery(w http.ResponseWriter, r *http.Request) {
 		}
 		if files := getFiles(r.MultipartForm, "asset_images"); len(files) > 0 {
 			for _, file := range files {
-				name, err := utils.SaveUploadedFile(file, s.UploadDir)
+				publicPath, err := s.saveGalleryMedia(file, uploader, publicUse)
 				if err != nil {
-					s.writeError(w, http.StatusInternalServerError, err)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
 					return
 				}
-				publicPath := "/api/static/" + name
 				savedPaths = append(savedPaths, publicPath)
 				assets = append(assets, models.GalleryAsset{URL: publicPath, Type: "image"})
 			}
 		}
 		if files := getFiles(r.MultipartForm, "asset_pdfs"); len(files) > 0 {
 			for _, file := range files {
-				name, err := utils.SaveUploadedFile(file, s.UploadDir)
+				publicPath, err := s.saveMedia(file, "", uploader)
 				if err != nil {
-					s.writeError(w, http.StatusInternalServerError, err)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
 					return
 				}
-				publicPath := "/api/static/" + name
 				savedPaths = append(savedPaths, publicPath)
 				assets = append(assets, models.GalleryAsset{URL: publicPath, Type: "pdf"})
 			}
@@ -3964,6 +4293,9 @@ func (s *Server) handleAdminGallery(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request) {
+	if s.routeAdminPublishing(w, r, "/api/admin/gallery/", previewKindGallery) {
+		return
+	}
 	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/gallery/"))
 	if err != nil {
 		s.writeErrorMsg(w, http.StatusBadRequest, "invalid gallery id")
@@ -4001,31 +4333,42 @@ func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request)
 		if !formData.FiltersProvided {
 			item.Filters = existing.Filters
 		}
+		if !formData.SortOrderProvided {
+			item.SortOrder = existing.SortOrder
+		}
+		if !formData.FeaturedProvided {
+			item.Featured = existing.Featured
+		}
+		if !formData.PinnedProvided {
+			item.Pinned = existing.Pinned
+		}
+		if !formData.ServiceIDsProvided {
+			item.ServiceIDs = existing.ServiceIDs
+		}
 		normalizeGalleryItemFields(item)
+		if !s.checkUploads(w, r.MultipartForm, imageField("thumbnail"), imageField("asset_images"), pdfField("asset_pdfs")) {
+			return
+		}
 
+		uploader := adminEmailFromContext(r.Context())
+		publicUse := getFormValue(r.MultipartForm, "public_use_approved") == "true"
 		savedPaths := make([]string, 0, 1)
-		pathsToRemove := make([]string, 0)
 		success := false
 		defer func() {
 			if !success {
-				for _, path := range savedPaths {
-					s.deleteStaticFile(path)
-				}
+				s.discardUploads(savedPaths...)
 			}
 		}()
 
 		thumbnailPath := existing.Thumbnail
 		if file := getFile(r.MultipartForm, "thumbnail"); file != nil {
-			name, err := utils.SaveUploadedFile(file, s.UploadDir)
+			publicPath, err := s.saveGalleryMedia(file, uploader, publicUse)
 			if err != nil {
-				s.writeError(w, http.StatusInternalServerError, err)
+				s.writeUploadError(w, err, http.StatusInternalServerError)
 				return
 			}
-			thumbnailPath = "/api/static/" + name
+			thumbnailPath = publicPath
 			savedPaths = append(savedPaths, thumbnailPath)
-			if existing.Thumbnail != "" && existing.Thumbnail != thumbnailPath {
-				pathsToRemove = append(pathsToRemove, existing.Thumbnail)
-			}
 		} else if formData.ExistingThumbnailProvided && formData.ExistingThumbnail != "" {
 			thumbnailPath = strings.TrimSpace(formData.ExistingThumbnail)
 		}
@@ -4041,42 +4384,29 @@ func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request)
 		if assets == nil {
 			assets = []models.GalleryAsset{}
 		}
-		assetURLs := make(map[string]struct{})
-		for _, asset := range assets {
-			assetURLs[asset.URL] = struct{}{}
-		}
 		if files := getFiles(r.MultipartForm, "asset_images"); len(files) > 0 {
 			for _, file := range files {
-				name, err := utils.SaveUploadedFile(file, s.UploadDir)
+				publicPath, err := s.saveGalleryMedia(file, uploader, publicUse)
 				if err != nil {
-					s.writeError(w, http.StatusInternalServerError, err)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
 					return
 				}
-				publicPath := "/api/static/" + name
 				savedPaths = append(savedPaths, publicPath)
 				assets = append(assets, models.GalleryAsset{URL: publicPath, Type: "image"})
-				assetURLs[publicPath] = struct{}{}
 			}
 		}
 		if files := getFiles(r.MultipartForm, "asset_pdfs"); len(files) > 0 {
 			for _, file := range files {
-				name, err := utils.SaveUploadedFile(file, s.UploadDir)
+				publicPath, err := s.saveMedia(file, "", uploader)
 				if err != nil {
-					s.writeError(w, http.StatusInternalServerError, err)
+					s.writeUploadError(w, err, http.StatusInternalServerError)
 					return
 				}
-				publicPath := "/api/static/" + name
 				savedPaths = append(savedPaths, publicPath)
 				assets = append(assets, models.GalleryAsset{URL: publicPath, Type: "pdf"})
-				assetURLs[publicPath] = struct{}{}
 			}
 		}
 		item.Assets = normalizeGalleryAssetsFromForm(assets)
-		for _, asset := range existing.Assets {
-			if _, ok := assetURLs[asset.URL]; !ok && asset.URL != "" {
-				pathsToRemove = append(pathsToRemove, asset.URL)
-			}
-		}
 
 		if item.VideoURL == "" {
 			item.VideoURL = existing.VideoURL
@@ -4112,7 +4442,7 @@ func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		updated, err := s.Store.UpdateGalleryItem(id, item)
+		updated, err := s.Store.UpdateGalleryItem(id, item, adminEmailFromContext(r.Context()))
 		if err != nil {
 			status := http.StatusInternalServerError
 			if errors.Is(err, os.ErrNotExist) {
@@ -4122,14 +4452,24 @@ func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request)
 			return
 		}
 		success = true
-		for _, path := range pathsToRemove {
-			s.deleteStaticFile(path)
-		}
+		s.releaseUploads(droppedURLs(galleryFileURLs(existing), galleryFileURLs(updated))...)
 		s.writeJSON(w, http.StatusOK, map[string]any{
 			"status": "updated",
 			"item":   updated,
 		})
 	case http.MethodDelete:
+		if r.URL.Query().Get("purge") != "true" {
+			if _, err := s.Store.SetGalleryItemPublishing(id, models.Publishing{PublishStatus: storage.PublishArchived}, adminEmailFromContext(r.Context())); err != nil {
+				status := http.StatusInternalServerError
+				if errors.Is(err, os.ErrNotExist) {
+					status = http.StatusNotFound
+				}
+				s.writeError(w, status, err)
+				return
+			}
+			s.writeJSON(w, http.StatusOK, map[string]string{"status": "archived"})
+			return
+		}
 		deleted, err := s.Store.DeleteGalleryItem(id)
 		if err != nil {
 			status := http.StatusInternalServerError
@@ -4139,12 +4479,7 @@ func (s *Server) handleAdminGalleryByID(w http.ResponseWriter, r *http.Request)
 			s.writeError(w, status, err)
 			return
 		}
-		if deleted != nil {
-			s.deleteStaticFile(deleted.Thumbnail)
-			for _, asset := range deleted.Assets {
-				s.deleteStaticFile(asset.URL)
-			}
-		}
+		s.releaseUploads(galleryFileURLs(deleted)...)
 		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 	default:
 		s.methodNotAllowed(w, r)
@@ -4195,12 +4530,23 @@ func (s *Server) handleAdminExperiences(w http.ResponseWriter, r *http.Request)
 			orderVal = *payload.Order
 		}
 
+		publishing, err := publishingPayload{
+			Status:      payload.PublishStatus,
+			PublishAt:   payload.PublishAt,
+			UnpublishAt: payload.UnpublishAt,
+		}.toPublishing()
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
 		experience := &models.Experience{
 			Period:      *payload.Period,
 			Title:       *payload.Title,
 			Company:     company,
 			Description: *payload.Description,
 			Order:       orderVal,
+			Publishing:  publishing,
 		}
 
 		created, err := s.Store.CreateExperience(experience)
@@ -4219,6 +4565,9 @@ func (s *Server) handleAdminExperiences(w http.ResponseWriter, r *http.Request)
 }
 
 func (s *Server) handleAdminExperienceByID(w http.ResponseWriter, r *http.Request) {
+	if s.routeAdminPublishing(w, r, "/api/admin/experiences/", previewKindExperience) {
+		return
+	}
 	id, err := parseID(strings.TrimPrefix(r.URL.Path, "/api/admin/experiences/"))
 	if err != nil {
 		s.writeErrorMsg(w, http.StatusBadRequest, "ID pengalaman tidak valid.")
@@ -4296,7 +4645,7 @@ func (s *Server) handleAdminExperienceByID(w http.ResponseWriter, r *http.Reques
 			Order:       orderVal,
 		}
 
-		updated, err := s.Store.UpdateExperience(id, update)
+		updated, err := s.Store.UpdateExperience(id, update, adminEmailFromContext(r.Context()))
 		if err != nil {
 			status := http.StatusInternalServerError
 			if errors.Is(err, os.ErrNotExist) {
@@ -4311,7 +4660,14 @@ func (s *Server) handleAdminExperienceByID(w http.ResponseWriter, r *http.Reques
 			"experience": updated,
 		})
 	case http.MethodDelete:
-		_, err := s.Store.DeleteExperience(id)
+		var err error
+		result := "archived"
+		if r.URL.Query().Get("purge") == "true" {
+			_, err = s.Store.DeleteExperience(id)
+			result = "deleted"
+		} else {
+			_, err = s.Store.SetExperiencePublishing(id, models.Publishing{PublishStatus: storage.PublishArchived}, adminEmailFromContext(r.Context()))
+		}
 		if err != nil {
 			status := http.StatusInternalServerError
 			if errors.Is(err, os.ErrNotExist) {
@@ -4320,7 +4676,7 @@ func (s *Server) handleAdminExperienceByID(w http.ResponseWriter, r *http.Reques
 			s.writeError(w, status, err)
 			return
 		}
-		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		s.writeJSON(w, http.StatusOK, map[string]string{"status": result})
 	default:
 		s.methodNotAllowed(w, r)
 	}
@@ -4329,25 +4685,53 @@ func (s *Server) handleAdminExperienceByID(w http.ResponseWriter, r *http.Reques
 func (s *Server) handleAdminCategories(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		s.writeJSON(w, http.StatusOK, s.Store.ListCategories())
-	case http.MethodPost:
-		var payload struct {
-			Name string `json:"name"`
-			Slug string `json:"slug"`
+		counts := make(map[uint]int)
+		for _, svc := range s.Store.ListServices() {
+			counts[svc.CategoryID]++
 		}
-		if err := s.decodeJSON(r.Body, &payload); err != nil {
-			s.writeError(w, http.StatusBadRequest, err)
+		tree := newCategoryTree(s.Store.ListCategories())
+		s.writeJSON(w, http.StatusOK, tree.nodes(counts, r.URL.Query().Get("tree") == "true"))
+	case http.MethodPost:
+		payload, cover, err := s.decodeCategoryPayload(r)
+		if err != nil {
+			s.writeUploadError(w, err, http.StatusBadRequest)
 			return
 		}
-		payload.Name = strings.TrimSpace(payload.Name)
-		payload.Slug = strings.TrimSpace(payload.Slug)
 		if payload.Name == "" {
 			s.writeErrorMsg(w, http.StatusBadRequest, "name is required")
 			return
 		}
 		cat := &models.Category{Name: payload.Name, Slug: payload.Slug}
+		if payload.ParentID != nil {
+			cat.ParentID = *payload.ParentID
+		}
+		if payload.SortOrder != nil {
+			cat.SortOrder = *payload.SortOrder
+		}
+		if payload.Description != nil {
+			cat.Description = *payload.Description
+		}
+		if payload.Icon != nil {
+			cat.Icon = *payload.Icon
+		}
+		if payload.CoverImage != nil {
+			cat.CoverImage = strings.TrimSpace(*payload.CoverImage)
+		}
+		if cover != nil {
+			if cat.CoverImage, err = s.saveCategoryCover(cover, adminEmailFromContext(r.Context())); err != nil {
+				s.writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
 		created, err := s.Store.CreateCategory(cat)
 		if err != nil {
+			if cover != nil {
+				s.discardUploads(cat.CoverImage)
+			}
+			if errors.Is(err, storage.ErrCategoryParentNotFound) {
+				s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+				return
+			}
 			s.writeError(w, http.StatusInternalServerError, err)
 			return
 		}
@@ -4365,78 +4749,106 @@ func (s *Server) handleAdminCategoryByID(w http.ResponseWriter, r *http.Request)
 	}
 	switch r.Method {
 	case http.MethodPut:
-		var payload struct {
-			Name string `json:"name"`
-			Slug string `json:"slug"`
-		}
-		if err := s.decodeJSON(r.Body, &payload); err != nil {
-			s.writeError(w, http.StatusBadRequest, err)
+		payload, cover, err := s.decodeCategoryPayload(r)
+		if err != nil {
+			s.writeUploadError(w, err, http.StatusBadRequest)
 			return
 		}
-		payload.Name = strings.TrimSpace(payload.Name)
-		payload.Slug = strings.TrimSpace(payload.Slug)
 		if payload.Name == "" {
 			s.writeErrorMsg(w, http.StatusBadRequest, "name is required")
 			return
 		}
-		if _, err := s.Store.UpdateCategory(id, payload.Name, payload.Slug); err != nil {
-			status := http.StatusInternalServerError
-			if errors.Is(err, os.ErrNotExist) {
-				status = http.StatusNotFound
+		existing, ok := s.Store.GetCategoryByID(id)
+		if !ok {
+			s.notFound(w)
+			return
+		}
+		update := storage.CategoryUpdate{
+			Name:        payload.Name,
+			Slug:        payload.Slug,
+			ParentID:    payload.ParentID,
+			SortOrder:   payload.SortOrder,
+			Description: payload.Description,
+			Icon:        payload.Icon,
+			CoverImage:  payload.CoverImage,
+		}
+		if cover != nil {
+			coverURL, err := s.saveCategoryCover(cover, adminEmailFromContext(r.Context()))
+			if err != nil {
+				s.writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			update.CoverImage = &coverURL
+		}
+		updated, err := s.Store.UpdateCategory(id, update, adminEmailFromContext(r.Context()))
+		if err != nil {
+			if cover != nil {
+				s.discardUploads(*update.CoverImage)
+			}
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				s.notFound(w)
+			case errors.Is(err, storage.ErrCategoryParentNotFound), errors.Is(err, storage.ErrCategoryCycle):
+				s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			default:
+				s.writeError(w, http.StatusInternalServerError, err)
 			}
-			s.writeError(w, status, err)
 			return
 		}
-		s.writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+		if existing.CoverImage != "" && existing.CoverImage != updated.CoverImage {
+			s.releaseUploads(existing.CoverImage)
+		}
+		s.writeJSON(w, http.StatusOK, map[string]any{"status": "updated", "category": updated})
 	case http.MethodDelete:
-		if err := s.Store.DeleteCategory(id); err != nil {
-			s.writeError(w, http.StatusInternalServerError, err)
+		var reassignTo *uint
+		if raw := strings.TrimSpace(r.URL.Query().Get("reassign_to")); raw != "" {
+			target, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				s.writeErrorMsg(w, http.StatusBadRequest, "invalid reassign_to")
+				return
+			}
+			targetID := uint(target)
+			reassignTo = &targetID
+		}
+		deleted, err := s.Store.DeleteCategory(id, reassignTo)
+		if err != nil {
+			switch {
+			case errors.Is(err, os.ErrNotExist):
+				s.notFound(w)
+			case errors.Is(err, storage.ErrCategoryReassignInvalid):
+				s.writeErrorMsg(w, http.StatusBadRequest, err.Error())
+			default:
+				s.writeError(w, http.StatusInternalServerError, err)
+			}
 			return
 		}
+		s.releaseUploads(deleted.CoverImage)
 		s.writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 	default:
 		s.methodNotAllowed(w, r)
 	}
 }
 
-func (s *Server) handleAdminOrders(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.methodNotAllowed(w, r)
+func (s *Server) handleAdminOrderActions(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/orders/")
+	if strings.HasSuffix(path, "/messages") || strings.HasSuffix(path, "/messages/read") {
+		s.handleAdminOrderMessages(w, r)
 		return
 	}
-	orders := s.Store.ListOrders()
-	services := s.Store.ListServices()
-	svcMap := make(map[uint]string)
-	for _, svc := range services {
-		svcMap[svc.ID] = svc.Title
-	}
-	type response struct {
-		models.Order
-		Service           string                     `json:"service"`
-		LatestTransaction *models.PaymentTransaction `json:"latest_transaction,omitempty"`
-	}
-	var out []response
-	for _, o := range orders {
-		latestTx, _ := s.Store.GetLatestPaymentTransactionByOrder(o.ID)
-		orderCopy := o
-		status, cancelReason := effectiveOrderStatus(&orderCopy, latestTx)
-		if status != "" && !strings.EqualFold(status, orderCopy.Status) {
-			orderCopy.Status = status
-		}
-		if cancelReason != "" && strings.TrimSpace(orderCopy.CancelReason) == "" {
-			orderCopy.CancelReason = cancelReason
-		}
-		out = append(out, response{
-			Order:             orderCopy,
-			Service:           svcMap[o.ServiceID],
-			LatestTransaction: latestTx,
-		})
+	if strings.HasSuffix(path, "/offline-payment") || strings.HasSuffix(path, "/payment-link") {
+		action := path[strings.LastIndex(path, "/")+1:]
+		id, err := parseID(strings.TrimSuffix(path, "/"+action))
+		if err != nil {
+			s.writeErrorMsg(w, http.StatusBadRequest, "invalid order id")
+			return
+		}
+		if action == "offline-payment" {
+			s.handleAdminOrderOfflinePayment(w, r, id)
+		} else {
+			s.handleAdminOrderPaymentLink(w, r, id)
+		}
+		return
 	}
-	s.writeJSON(w, http.StatusOK, out)
-}
-
-func (s *Server) handleAdminOrderActions(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api/admin/orders/")
 	if strings.HasSuffix(path, "/status") {
 		idStr := strings.TrimSuffix(path, "/status")
 		id, err := parseID(idStr)
@@ -4463,6 +4875,7 @@ func (s *Server) handleAdminOrderActions(w http.ResponseWriter, r *http.Request)
 			s.writeError(w, status, err)
 			return
 		}
+		s.processWaitlist()
 		s.writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
 		return
 	}
@@ -4670,7 +5083,7 @@ func (s *Server) handleAdminPromoCodeByID(w http.ResponseWriter, r *http.Request
 			active := *payload.Active
 			update.Active = &active
 		}
-		updated, err := s.Store.UpdatePromoCode(id, update)
+		updated, err := s.Store.UpdatePromoCode(id, update, adminEmailFromContext(r.Context()))
 		if err != nil {
 			status := http.StatusInternalServerError
 			msg := err.Error()
@@ -4967,6 +5380,10 @@ type galleryFormData struct {
 	ExistingAssetsProvided    bool
 	ExistingThumbnailProvided bool
 	FiltersProvided           bool
+	SortOrderProvided         bool
+	FeaturedProvided          bool
+	PinnedProvided            bool
+	ServiceIDsProvided        bool
 }
 
 func getAllFormValuesWithPresence(form *multipart.Form, keys ...string) ([]string, bool) {
@@ -5050,37 +5467,37 @@ func normalizeGalleryItemFields(item *models.GalleryItem) {
 
 func convertDriveLinkToPreview(link string) string {
 	link = strings.TrimSpace(link)
-	if link == "" {
-		return ""
+	if embed, err := utils.ParseEmbedLink(link); err == nil && embed.Provider == utils.EmbedGoogleDrive {
+		return embed.EmbedURL
 	}
-	parsed, err := url.Parse(link)
-	if err != nil {
-		return link
-	}
-	host := strings.ToLower(parsed.Host)
-	if !strings.Contains(host, "drive.google.com") {
-		return link
-	}
-	pathParts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
-	var fileID string
-	for i := 0; i < len(pathParts); i++ {
-		part := pathParts[i]
-		if part == "file" && i+2 < len(pathParts) && pathParts[i+1] == "d" {
-			fileID = pathParts[i+2]
-			break
+	return link
+}
+
+// resolveGalleryEmbeds parses the video and link URLs of a gallery item.
+// Web items may link to any site; everything else must use a supported host.
+func resolveGalleryEmbeds(item *models.GalleryItem) error {
+	item.VideoEmbed, item.LinkEmbed = nil, nil
+	if item.VideoURL != "" {
+		embed, err := utils.ParseEmbedLink(item.VideoURL)
+		if err != nil {
+			return fmt.Errorf("video url: %w", err)
 		}
-		if part == "d" && i+1 < len(pathParts) {
-			fileID = pathParts[i+1]
-			break
+		if embed.Provider == utils.EmbedBehance {
+			return errors.New("video url must point to a video")
 		}
+		item.VideoEmbed = embed
 	}
-	if fileID == "" {
-		fileID = parsed.Query().Get("id")
-	}
-	if fileID == "" {
-		return link
+	if item.LinkURL != "" {
+		embed, err := utils.ParseEmbedLink(item.LinkURL)
+		switch {
+		case err == nil:
+			item.LinkEmbed = embed
+		case item.Section == "web" && errors.Is(err, utils.ErrUnsupportedEmbed):
+		default:
+			return fmt.Errorf("link url: %w", err)
+		}
 	}
-	return fmt.Sprintf("https://drive.google.com/file/d/%s/preview", fileID)
+	return nil
 }
 
 func buildGalleryFilters(items []models.GalleryItem) []string {
@@ -5181,7 +5598,7 @@ func validateGalleryItem(item *models.GalleryItem) error {
 			return errors.New("description is required for web items")
 		}
 	}
-	return nil
+	return resolveGalleryEmbeds(item)
 }
 
 func (s *Server) decodeGalleryForm(form *multipart.Form) (*galleryFormData, error) {
@@ -5204,12 +5621,46 @@ func (s *Server) decodeGalleryForm(form *multipart.Form) (*galleryFormData, erro
 			data.Item.Filters = filterValues
 		}
 	}
+	if raw, ok := form.Value["sort_order"]; ok {
+		data.SortOrderProvided = true
+		if len(raw) > 0 && strings.TrimSpace(raw[0]) != "" {
+			order, err := strconv.Atoi(strings.TrimSpace(raw[0]))
+			if err != nil || order < 0 {
+				return nil, errors.New("invalid sort_order")
+			}
+			data.Item.SortOrder = order
+		}
+	}
+	if raw, ok := form.Value["featured"]; ok {
+		data.FeaturedProvided = true
+		data.Item.Featured = len(raw) > 0 && strings.TrimSpace(raw[0]) == "true"
+	}
+	if raw, ok := form.Value["pinned"]; ok {
+		data.PinnedProvided = true
+		data.Item.Pinned = len(raw) > 0 && strings.TrimSpace(raw[0]) == "true"
+	}
+	if values, provided := getAllFormValuesWithPresence(form, "service_ids", "service_ids[]"); provided {
+		data.ServiceIDsProvided = true
+		ids, err := s.galleryServiceIDs(values)
+		if err != nil {
+			return nil, err
+		}
+		data.Item.ServiceIDs = ids
+	}
 	if rawThumb, ok := form.Value["existing_thumbnail"]; ok {
 		data.ExistingThumbnailProvided = true
 		if len(rawThumb) > 0 {
 			data.ExistingThumbnail = strings.TrimSpace(rawThumb[0])
 		}
 	}
+	if raw := strings.TrimSpace(getFormValue(form, "thumbnail_media_id")); raw != "" {
+		item, err := s.mediaByRawID(raw)
+		if err != nil {
+			return nil, err
+		}
+		data.ExistingThumbnail = item.URL
+		data.ExistingThumbnailProvided = true
+	}
 	if rawAssets, ok := form.Value["existing_assets"]; ok {
 		data.ExistingAssetsProvided = true
 		if len(rawAssets) > 0 && strings.TrimSpace(rawAssets[0]) != "" {
@@ -5218,6 +5669,27 @@ func (s *Server) decodeGalleryForm(form *multipart.Form) (*galleryFormData, erro
 			}
 		}
 	}
+	for i, asset := range data.ExistingAssets {
+		if asset.MediaID == 0 {
+			continue
+		}
+		item, err := s.mediaByID(asset.MediaID)
+		if err != nil {
+			return nil, err
+		}
+		data.ExistingAssets[i].URL = item.URL
+		data.ExistingAssets[i].Type = galleryAssetType(item)
+	}
+	items, provided, err := s.mediaFromForm(form, "asset_media_ids")
+	if err != nil {
+		return nil, err
+	}
+	if provided {
+		data.ExistingAssetsProvided = true
+	}
+	for _, item := range items {
+		data.ExistingAssets = append(data.ExistingAssets, models.GalleryAsset{URL: item.URL, Type: galleryAssetType(item), MediaID: item.ID})
+	}
 	return data, nil
 }
 
@@ -5235,6 +5707,20 @@ func (s *Server) decodeServiceForm(form *multipart.Form) (*models.Service, error
 			return nil, fmt.Errorf("invalid addons format: %w", err)
 		}
 	}
+	var packages []models.ServicePackage
+	if raw, ok := form.Value["packages"]; ok {
+		packages = []models.ServicePackage{}
+		if len(raw) > 0 && strings.TrimSpace(raw[0]) != "" {
+			if err := json.Unmarshal([]byte(raw[0]), &packages); err != nil {
+				return nil, fmt.Errorf("invalid packages format: %w", err)
+			}
+		}
+		normalized, err := storage.NormalizeServicePackages(packages)
+		if err != nil {
+			return nil, err
+		}
+		packages = normalized
+	}
 	var highlights []models.ServiceHighlight
 	highlightsJSON := getFormValue(form, "highlights")
 	if highlightsJSON != "" {
@@ -5242,6 +5728,22 @@ func (s *Server) decodeServiceForm(form *multipart.Form) (*models.Service, error
 			return nil, fmt.Errorf("invalid highlights format: %w", err)
 		}
 	}
+	thumbnail := ""
+	if raw := strings.TrimSpace(getFormValue(form, "thumbnail_media_id")); raw != "" {
+		item, err := s.mediaByRawID(raw)
+		if err != nil {
+			return nil, err
+		}
+		thumbnail = item.URL
+	}
+	galleryImages := []string{}
+	items, _, err := s.mediaFromForm(form, "gallery_media_ids")
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		galleryImages = append(galleryImages, item.URL)
+	}
 	service := &models.Service{
 		Title:         title,
 		Slug:          slug,
@@ -5250,8 +5752,10 @@ func (s *Server) decodeServiceForm(form *multipart.Form) (*models.Service, error
 		Price:         price,
 		CategoryID:    uint(catID),
 		AddOns:        addOns,
+		Packages:      packages,
 		Highlights:    highlights,
-		GalleryImages: []string{},
+		Thumbnail:     thumbnail,
+		GalleryImages: galleryImages,
 	}
 	return service, nil
 }
@@ -5268,7 +5772,7 @@ func (s *Server) wrapCORS(next http.Handler) http.Handler {
 		} else if origin == "" && s.allowAllOrigins {
 			w.Header().Set("Access-Control-Allow-Origin", "*")
 		}
-		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, Accept, X-Proofing-Access")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		if s.allowCredentials {
 			w.Header().Add("Vary", "Access-Control-Request-Method")
@@ -5351,8 +5855,9 @@ func (s *Server) requireAuth(next http.Handler) http.Handler {
 			return
 		}
 
-		if _, err := auth.ValidateToken(token); err == nil {
+		if subject, err := auth.ValidateToken(token); err == nil {
 			ctx = context.WithValue(ctx, ctxKeyPortalRole, portalRoleAdmin)
+			ctx = context.WithValue(ctx, ctxKeyAdminEmail, subject)
 			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
@@ -5448,6 +5953,13 @@ func portalUserIDFromContext(ctx context.Context) uint {
 	return 0
 }
 
+func adminEmailFromContext(ctx context.Context) string {
+	if email, ok := ctx.Value(ctxKeyAdminEmail).(string); ok {
+		return email
+	}
+	return ""
+}
+
 func parseID(raw string) (uint, error) {
 	raw = strings.Trim(raw, "/")
 	if raw == "" {
@@ -5695,17 +6207,6 @@ func clientIP(r *http.Request) string {
 	}
 	return ip
 }
-func (s *Server) deleteStaticFile(publicPath string) {
-	if !strings.HasPrefix(publicPath, "/api/static/") {
-		return
-	}
-	rel := strings.TrimPrefix(publicPath, "/api/static/")
-	if rel == "" {
-		return
-	}
-	abs := filepath.Join(s.UploadDir, filepath.FromSlash(rel))
-	_ = os.Remove(abs)
-}
 
 func (s *Server) recordPaymentChannelAvailability(category, channel string, available bool, message string) {
 	if s.Store == nil {
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+func (s *Server) linkSignature(purpose, payload string) string {
+	mac := hmac.New(sha256.New, s.linkSecret)
+	mac.Write([]byte(purpose + "|" + payload))
+	return hex.EncodeToString(mac.Sum(nil)[:12])
+}
+
+func (s *Server) signLinkToken(purpose string, id uint) string {
+	payload := fmt.Sprintf("%d", id)
+	return payload + "-" + s.linkSignature(purpose, payload)
+}
+
+func (s *Server) verifyLinkToken(purpose, raw string) (uint, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	sep := strings.LastIndex(raw, "-")
+	if sep <= 0 {
+		return 0, errors.New("invalid link token")
+	}
+	id, err := parseID(raw[:sep])
+	if err != nil {
+		return 0, errors.New("invalid link token")
+	}
+	if !hmac.Equal([]byte(s.linkSignature(purpose, raw[:sep])), []byte(raw[sep+1:])) {
+		return 0, errors.New("link token signature mismatch")
+	}
+	return id, nil
+}
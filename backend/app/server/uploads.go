@@ -0,0 +1,90 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"devara-creative-backend/app/utils"
+)
+
+func loadUploadPolicy() utils.UploadPolicy {
+	policy := utils.DefaultUploadPolicy()
+	image := policy[utils.UploadImage]
+	image.MaxBytes = int64(envInt("UPLOAD_IMAGE_MAX_MB", int(image.MaxBytes>>20))) << 20
+	image.MaxPixels = envInt("UPLOAD_IMAGE_MAX_MEGAPIXELS", image.MaxPixels/1_000_000) * 1_000_000
+	image.MaxDimension = envInt("UPLOAD_IMAGE_MAX_DIMENSION", image.MaxDimension)
+	policy[utils.UploadImage] = image
+	video := policy[utils.UploadVideo]
+	video.MaxBytes = int64(envInt("UPLOAD_VIDEO_MAX_MB", int(video.MaxBytes>>20))) << 20
+	policy[utils.UploadVideo] = video
+	document := policy[utils.UploadDocument]
+	document.MaxBytes = int64(envInt("UPLOAD_DOCUMENT_MAX_MB", int(document.MaxBytes>>20))) << 20
+	policy[utils.UploadDocument] = document
+	return policy
+}
+
+type uploadField struct {
+	name  string
+	kinds []string
+	types []string
+}
+
+func imageField(name string) uploadField {
+	return uploadField{name: name, kinds: []string{utils.UploadImage}}
+}
+
+func pdfField(name string) uploadField {
+	return uploadField{name: name, kinds: []string{utils.UploadDocument}, types: []string{"application/pdf"}}
+}
+
+// checkUploads validates every file in the listed form fields before anything
+// is written to disk and reports the first violation to the client.
+func (s *Server) checkUploads(w http.ResponseWriter, form *multipart.Form, fields ...uploadField) bool {
+	for _, field := range fields {
+		for _, file := range getFiles(form, field.name) {
+			if err := s.checkUpload(file, field); err != nil {
+				s.writeUploadError(w, err, http.StatusBadRequest)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *Server) checkUpload(file *multipart.FileHeader, field uploadField) error {
+	sniffed, err := s.uploadPolicy.Check(file, field.kinds...)
+	if err != nil {
+		return err
+	}
+	if len(field.types) > 0 && !slices.Contains(field.types, sniffed.ContentType) {
+		return &utils.UploadError{
+			Status:  http.StatusUnsupportedMediaType,
+			Message: fmt.Sprintf("%s: file type %s is not allowed here (expected %s)", file.Filename, sniffed.ContentType, strings.Join(field.types, ", ")),
+		}
+	}
+	return nil
+}
+
+func (s *Server) writeUploadError(w http.ResponseWriter, err error, fallback int) {
+	var uploadErr *utils.UploadError
+	if errors.As(err, &uploadErr) {
+		s.writeErrorMsg(w, uploadErr.Status, uploadErr.Message)
+		return
+	}
+	s.writeError(w, fallback, err)
+}
+
+func staticFileHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !strings.EqualFold(path.Ext(r.URL.Path), ".pdf") {
+			w.Header().Set("Content-Security-Policy", "default-src 'none'; img-src 'self' data:; media-src 'self'; style-src 'unsafe-inline'; sandbox")
+		}
+		h.ServeHTTP(w, r)
+	})
+}
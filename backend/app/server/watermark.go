@@ -0,0 +1,159 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"devara-creative-backend/app/models"
+	"devara-creative-backend/app/storage"
+	"devara-creative-backend/app/utils"
+)
+
+func loadWatermark() *utils.Watermark {
+	mark, err := utils.LoadWatermark(
+		envString("WATERMARK_TEXT", ""),
+		envString("WATERMARK_LOGO", ""),
+		envString("WATERMARK_POSITION", "bottom-right"),
+		envFloat("WATERMARK_OPACITY", 0.35),
+		envFloat("WATERMARK_SCALE", 0.25),
+	)
+	if err != nil {
+		log.Printf("watermark logo could not be loaded: %v", err)
+	}
+	return mark
+}
+
+// saveGalleryMedia stores a portfolio image with the watermark applied and
+// keeps the original so it can be rendered again. Images the client approved
+// for public use are left unmarked.
+func (s *Server) saveGalleryMedia(file *multipart.FileHeader, uploader string, publicUseApproved bool) (string, error) {
+	opts := storeOptions{keepOriginal: true, publicUseApproved: publicUseApproved}
+	if !publicUseApproved {
+		opts.watermark = s.watermark
+	}
+	item, err := s.createMedia(file, "", uploader, opts)
+	if err != nil {
+		return "", err
+	}
+	return item.URL, nil
+}
+
+func (s *Server) readStoredFile(key string) ([]byte, error) {
+	body, _, err := s.Files.Get(context.Background(), key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// renderMedia processes a media item again from its original, overwriting
+// the public variants with the current watermark settings.
+func (s *Server) renderMedia(item *models.MediaItem) (*models.MediaItem, error) {
+	data, err := s.readStoredFile(item.OriginalKey)
+	if err != nil {
+		return nil, err
+	}
+	opts := s.images
+	if !item.PublicUseApproved {
+		opts.Watermark = s.watermark
+	}
+	processed, err := utils.ProcessImage(data, opts)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	base := strings.TrimSuffix(item.Key, path.Ext(item.Key))
+	for _, f := range processed.Files {
+		if err := s.Files.Put(ctx, base+f.Suffix+f.Ext, bytes.NewReader(f.Data), int64(len(f.Data)), f.ContentType); err != nil {
+			return nil, err
+		}
+	}
+	asset := processed.Asset(func(suffix, ext string) string {
+		return s.publicFileURL(base + suffix + ext)
+	})
+	return s.Store.SetMediaImage(item.ID, asset, opts.Watermark.Enabled())
+}
+
+func (s *Server) renderProofingPreview(img models.ProofingImage) error {
+	data, err := s.readStoredFile(img.Key)
+	if err != nil {
+		return err
+	}
+	preview, err := s.proofPreview(data)
+	if err != nil {
+		return err
+	}
+	return s.Files.Put(context.Background(), img.PreviewKey, bytes.NewReader(preview.Data), int64(len(preview.Data)), preview.ContentType)
+}
+
+func (s *Server) handleAdminWatermark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	mark := s.watermark
+	if mark == nil {
+		mark = &utils.Watermark{}
+	}
+	s.writeJSON(w, http.StatusOK, map[string]any{
+		"enabled":      mark.Enabled(),
+		"text":         mark.Text,
+		"logo":         mark.LogoPath,
+		"logo_loaded":  mark.Logo != nil,
+		"position":     mark.Position,
+		"opacity":      mark.Opacity,
+		"scale":        mark.Scale,
+		"positions":    utils.WatermarkPositions,
+		"regenerating": s.rerenderRunning.Load(),
+	})
+}
+
+// handleAdminWatermarkRegenerate renders portfolio images and proofing
+// previews again from their originals, typically after the branding changed.
+// The work runs in the background.
+func (s *Server) handleAdminWatermarkRegenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.methodNotAllowed(w, r)
+		return
+	}
+	if !s.rerenderRunning.CompareAndSwap(false, true) {
+		s.writeErrorMsg(w, http.StatusConflict, "regeneration is already running")
+		return
+	}
+	items, _ := s.Store.ListMedia(storage.MediaQuery{Type: storage.MediaImage})
+	var media []models.MediaItem
+	for _, item := range items {
+		if item.OriginalKey != "" {
+			media = append(media, item)
+		}
+	}
+	var proofs []models.ProofingImage
+	for _, g := range s.Store.ListProofingGalleries(0) {
+		proofs = append(proofs, g.Images...)
+	}
+	go func() {
+		defer s.rerenderRunning.Store(false)
+		failed := 0
+		for i := range media {
+			if _, err := s.renderMedia(&media[i]); err != nil {
+				log.Printf("failed rendering media %d: %v", media[i].ID, err)
+				failed++
+			}
+		}
+		for _, img := range proofs {
+			if err := s.renderProofingPreview(img); err != nil {
+				log.Printf("failed rendering proofing preview %s: %v", img.PreviewKey, err)
+				failed++
+			}
+		}
+		log.Printf("watermark regeneration finished: %d media, %d proofing previews, %d failed", len(media), len(proofs), failed)
+	}()
+	s.writeJSON(w, http.StatusAccepted, map[string]any{"status": "started", "media": len(media), "proofing": len(proofs)})
+}
@@ -0,0 +1,420 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrServiceNotBookable = errors.New("service is not bookable")
+	ErrSlotUnavailable    = errors.New("booking slot is not available")
+)
+
+const (
+	defaultBookingHold     = 30 * time.Minute
+	defaultBookingTimezone = "Asia/Jakarta"
+	maxSlotRangeDays       = 62
+)
+
+type BookingSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func BookingLocation(cfg *models.ServiceBooking) *time.Location {
+	name := defaultBookingTimezone
+	if cfg != nil && strings.TrimSpace(cfg.Timezone) != "" {
+		name = strings.TrimSpace(cfg.Timezone)
+	}
+	if loc, err := time.LoadLocation(name); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*60*60)
+}
+
+func ParseClock(value string) (int, bool) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 24 {
+		return 0, false
+	}
+	minute, err := strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, false
+	}
+	total := hour*60 + minute
+	if total > 24*60 {
+		return 0, false
+	}
+	return total, true
+}
+
+func bookingDurations(cfg *models.ServiceBooking) (time.Duration, time.Duration, time.Duration) {
+	duration := time.Duration(cfg.DurationMinutes) * time.Minute
+	buffer := time.Duration(cfg.BufferMinutes) * time.Minute
+	interval := time.Duration(cfg.SlotIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = duration + buffer
+	}
+	return duration, buffer, interval
+}
+
+func isBookable(svc *models.Service) bool {
+	return svc != nil && svc.Booking != nil && svc.Booking.Enabled && svc.Booking.DurationMinutes > 0
+}
+
+func orderHoldsBooking(o *models.Order, now time.Time) bool {
+	if o.BookingStart.IsZero() || IsCancelledStatus(o.Status) {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(o.Status)) {
+	case "refunded", "refund_pending", "payment_invalid", "expired":
+		return false
+	}
+	if OrderBookingConfirmed(o) {
+		return true
+	}
+	if IsPaymentFailureStatus(o.PaymentStatus) {
+		return false
+	}
+	if !o.PaymentExpiresAt.IsZero() {
+		return now.Before(o.PaymentExpiresAt)
+	}
+	return now.Before(o.CreatedAt.Add(defaultBookingHold))
+}
+
+func OrderBookingConfirmed(o *models.Order) bool {
+	if o.BookingStart.IsZero() || IsCancelledStatus(o.Status) {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(o.Status)) {
+	case "done", "in_progress":
+		return true
+	case "refunded", "refund_pending", "payment_invalid":
+		return false
+	}
+	return isPaymentCompletedStatus(o.Status) || isPaymentCompletedStatus(o.PaymentStatus)
+}
+
+func (s *Store) findServiceLocked(id uint) *models.Service {
+	for _, svc := range s.data.Services {
+		if svc.ID == id {
+			return svc
+		}
+	}
+	return nil
+}
+
+// bookingConflictLocked reports whether [start, end) collides with a held
+// booking. The gap between two bookings is the larger buffer of their
+// services.
+func (s *Store) bookingConflictLocked(start, end time.Time, buffer time.Duration, excludeOrderID uint, now time.Time) bool {
+	for _, o := range s.data.Orders {
+		if o.ID == excludeOrderID || !orderHoldsBooking(o, now) {
+			continue
+		}
+		gap := buffer
+		if svc := s.findServiceLocked(o.ServiceID); svc != nil && svc.Booking != nil {
+			_, other, _ := bookingDurations(svc.Booking)
+			gap = max(gap, other)
+		}
+		if start.Before(o.BookingEnd.Add(gap)) && o.BookingStart.Add(-gap).Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) BookingFeedVersion() uint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	return s.data.BookingFeedVersion
+}
+
+// RotateBookingFeed invalidates the current calendar feed link and returns
+// the new version.
+func (s *Store) RotateBookingFeed() (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	s.data.BookingFeedVersion++
+	s.appendActivityLocked(&models.Activity{
+		Type:   "booking",
+		Action: "feed_rotated",
+		Title:  "Tautan kalender booking diganti",
+	})
+	if err := s.persistLocked(); err != nil {
+		return 0, err
+	}
+	return s.data.BookingFeedVersion, nil
+}
+
+func (s *Store) blackoutOverlapsLocked(serviceID uint, start, end time.Time) bool {
+	for _, b := range s.data.BlackoutDates {
+		if b.ServiceID != 0 && b.ServiceID != serviceID {
+			continue
+		}
+		if start.Before(b.End) && b.Start.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+func slotFitsWorkingHours(cfg *models.ServiceBooking, loc *time.Location, start, end time.Time, interval time.Duration) bool {
+	localStart := start.In(loc)
+	localEnd := end.In(loc)
+	dayStart := time.Date(localStart.Year(), localStart.Month(), localStart.Day(), 0, 0, 0, 0, loc)
+	startMinute := int(localStart.Sub(dayStart) / time.Minute)
+	endMinute := int(localEnd.Sub(dayStart) / time.Minute)
+	for _, wh := range cfg.WorkingHours {
+		if time.Weekday(wh.Weekday) != localStart.Weekday() {
+			continue
+		}
+		open, ok := ParseClock(wh.Start)
+		if !ok {
+			continue
+		}
+		closing, ok := ParseClock(wh.End)
+		if !ok {
+			continue
+		}
+		if startMinute < open || endMinute > closing {
+			continue
+		}
+		if interval > 0 && time.Duration(startMinute-open)*time.Minute%interval != 0 {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (s *Store) slotAvailableLocked(svc *models.Service, start time.Time, now time.Time, excludeOrderID uint) (time.Time, bool) {
+	cfg := svc.Booking
+	duration, buffer, interval := bookingDurations(cfg)
+	end := start.Add(duration)
+	if start.Before(now.Add(time.Duration(cfg.MinNoticeHours) * time.Hour)) {
+		return end, false
+	}
+	if cfg.MaxAdvanceDays > 0 && start.After(now.AddDate(0, 0, cfg.MaxAdvanceDays)) {
+		return end, false
+	}
+	if !slotFitsWorkingHours(cfg, BookingLocation(cfg), start, end, interval) {
+		return end, false
+	}
+	if s.blackoutOverlapsLocked(svc.ID, start, end) {
+		return end, false
+	}
+	if s.bookingConflictLocked(start, end, buffer, excludeOrderID, now) {
+		return end, false
+	}
+	return end, true
+}
+
+func (s *Store) reserveBookingLocked(order *models.Order, now time.Time) error {
+	svc := s.findServiceLocked(order.ServiceID)
+	if !isBookable(svc) {
+		return ErrServiceNotBookable
+	}
+	start := order.BookingStart.UTC()
+	end, ok := s.slotAvailableLocked(svc, start, now, order.ID)
+	if !ok {
+		return ErrSlotUnavailable
+	}
+	order.BookingStart = start
+	order.BookingEnd = end.UTC()
+	return nil
+}
+
+func (s *Store) ListAvailableSlots(serviceID uint, from, to time.Time, now time.Time) ([]BookingSlot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	svc := s.findServiceLocked(serviceID)
+	if svc == nil {
+		return nil, os.ErrNotExist
+	}
+	if !isBookable(svc) {
+		return nil, ErrServiceNotBookable
+	}
+	if from.Before(now) {
+		from = now
+	}
+	if to.Sub(from) > maxSlotRangeDays*24*time.Hour {
+		to = from.Add(maxSlotRangeDays * 24 * time.Hour)
+	}
+	cfg := svc.Booking
+	loc := BookingLocation(cfg)
+	duration, _, interval := bookingDurations(cfg)
+	if interval <= 0 {
+		return nil, ErrServiceNotBookable
+	}
+	slots := []BookingSlot{}
+	localFrom := from.In(loc)
+	day := time.Date(localFrom.Year(), localFrom.Month(), localFrom.Day(), 0, 0, 0, 0, loc)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, wh := range cfg.WorkingHours {
+			if time.Weekday(wh.Weekday) != day.Weekday() {
+				continue
+			}
+			open, ok := ParseClock(wh.Start)
+			if !ok {
+				continue
+			}
+			closing, ok := ParseClock(wh.End)
+			if !ok {
+				continue
+			}
+			dayOpen := day.Add(time.Duration(open) * time.Minute)
+			dayClose := day.Add(time.Duration(closing) * time.Minute)
+			for start := dayOpen; !start.Add(duration).After(dayClose); start = start.Add(interval) {
+				if start.Before(from) || !start.Before(to) {
+					continue
+				}
+				if end, ok := s.slotAvailableLocked(svc, start.UTC(), now, 0); ok {
+					slots = append(slots, BookingSlot{Start: start.UTC(), End: end.UTC()})
+				}
+			}
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].Start.Before(slots[j].Start) })
+	return slots, nil
+}
+
+func (s *Store) ListBookings(from, to time.Time, confirmedOnly bool) []models.Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	out := []models.Order{}
+	for _, o := range s.data.Orders {
+		if o.BookingStart.IsZero() {
+			continue
+		}
+		if !from.IsZero() && o.BookingEnd.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !o.BookingStart.Before(to) {
+			continue
+		}
+		if confirmedOnly && !OrderBookingConfirmed(o) {
+			continue
+		}
+		if !confirmedOnly && !orderHoldsBooking(o, now) {
+			continue
+		}
+		out = append(out, *o)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].BookingStart.Before(out[j].BookingStart) })
+	return out
+}
+
+func (s *Store) UpdateServiceBooking(id uint, cfg *models.ServiceBooking) (*models.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	svc := s.findServiceLocked(id)
+	if svc == nil {
+		return nil, os.ErrNotExist
+	}
+	if cfg == nil {
+		svc.Booking = nil
+	} else {
+		booking := *cfg
+		booking.WorkingHours = append([]models.WorkingHours(nil), cfg.WorkingHours...)
+		svc.Booking = &booking
+	}
+	state := "dinonaktifkan"
+	if isBookable(svc) {
+		state = "diaktifkan"
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "service",
+		Action:      "booking_updated",
+		Title:       fmt.Sprintf("Jadwal layanan \"%s\" diperbarui", svc.Title),
+		Description: fmt.Sprintf("Booking %s", state),
+		ReferenceID: svc.ID,
+		Metadata: map[string]string{
+			"title":          svc.Title,
+			"slug":           svc.Slug,
+			"highlight_type": "updated",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneService(svc)
+	return &clone, nil
+}
+
+func (s *Store) ListBlackoutDates() []models.BlackoutDate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.BlackoutDate, 0, len(s.data.BlackoutDates))
+	for _, b := range s.data.BlackoutDates {
+		out = append(out, *b)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+func (s *Store) CreateBlackoutDate(b *models.BlackoutDate) (*models.BlackoutDate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	b.ID = s.nextID("blackout_date")
+	b.CreatedAt = now
+	b.UpdatedAt = now
+	clone := *b
+	s.data.BlackoutDates = append(s.data.BlackoutDates, &clone)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "blackout_date",
+		Action:      "created",
+		Title:       "Tanggal libur ditambahkan",
+		Description: fmt.Sprintf("%s - %s", b.Start.Format("02 Jan 2006 15:04"), b.End.Format("02 Jan 2006 15:04")),
+		ReferenceID: b.ID,
+		Metadata: map[string]string{
+			"reason":         b.Reason,
+			"service_title":  s.serviceTitleLocked(b.ServiceID),
+			"highlight_type": "blackout_date",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *Store) DeleteBlackoutDate(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	filtered := s.data.BlackoutDates[:0]
+	found := false
+	for _, b := range s.data.BlackoutDates {
+		if b.ID == id {
+			found = true
+			continue
+		}
+		filtered = append(filtered, b)
+	}
+	if !found {
+		return os.ErrNotExist
+	}
+	s.data.BlackoutDates = filtered
+	return s.persistLocked()
+}
@@ -0,0 +1,402 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrServiceSoldOut     = errors.New("service capacity reached")
+	ErrReservationInvalid = errors.New("waitlist reservation is not valid")
+)
+
+const defaultReservationWindow = 48 * time.Hour
+
+type ServiceAvailability struct {
+	SoldOut         bool      `json:"sold_out"`
+	Remaining       int       `json:"remaining"`
+	NextAvailableAt time.Time `json:"next_available_at,omitempty"`
+	WaitlistCount   int       `json:"waitlist_count"`
+}
+
+func hasCapacityLimit(svc *models.Service) bool {
+	return svc != nil && svc.Capacity != nil && (svc.Capacity.MaxActiveOrders > 0 || svc.Capacity.WeeklyQuota > 0)
+}
+
+func reservationWindow(cfg *models.ServiceCapacity) time.Duration {
+	if cfg == nil || cfg.ReservationHours <= 0 {
+		return defaultReservationWindow
+	}
+	return time.Duration(cfg.ReservationHours) * time.Hour
+}
+
+func orderOccupiesCapacity(o *models.Order, now time.Time) bool {
+	if IsCancelledStatus(o.Status) {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(o.Status)) {
+	case "done", "refunded", "payment_invalid", "expired":
+		return false
+	}
+	if IsPaymentFailureStatus(o.PaymentStatus) {
+		return false
+	}
+	if strings.EqualFold(o.Status, "pending") && !o.PaymentExpiresAt.IsZero() && !now.Before(o.PaymentExpiresAt) {
+		return false
+	}
+	return true
+}
+
+// orderCountsTowardQuota reports whether an order uses up the weekly quota.
+// It follows orderOccupiesCapacity, except that finished work still counts
+// for the week it was booked in.
+func orderCountsTowardQuota(o *models.Order, now time.Time) bool {
+	if strings.EqualFold(strings.TrimSpace(o.Status), "done") {
+		return true
+	}
+	return orderOccupiesCapacity(o, now)
+}
+
+func capacityWeekStart(now time.Time) time.Time {
+	local := now.In(BookingLocation(nil))
+	offset := (int(local.Weekday()) + 6) % 7
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	return day.AddDate(0, 0, -offset)
+}
+
+func (s *Store) capacityUsageLocked(svc *models.Service, now time.Time, excludeEntryID uint) (int, time.Time) {
+	cfg := svc.Capacity
+	reservations := 0
+	for _, e := range s.data.WaitlistEntries {
+		if e.ServiceID == svc.ID && e.ID != excludeEntryID && e.Status == "notified" && now.Before(e.ReservationExpiresAt) {
+			reservations++
+		}
+	}
+	remaining := -1
+	var nextAvailable time.Time
+	if cfg.MaxActiveOrders > 0 {
+		active := 0
+		var earliestRelease time.Time
+		for _, o := range s.data.Orders {
+			if o.ServiceID != svc.ID || !orderOccupiesCapacity(o, now) {
+				continue
+			}
+			active++
+			if strings.EqualFold(o.Status, "pending") && !o.PaymentExpiresAt.IsZero() {
+				if earliestRelease.IsZero() || o.PaymentExpiresAt.Before(earliestRelease) {
+					earliestRelease = o.PaymentExpiresAt
+				}
+			}
+		}
+		left := cfg.MaxActiveOrders - active - reservations
+		remaining = left
+		if left <= 0 {
+			nextAvailable = earliestRelease
+		}
+	}
+	if cfg.WeeklyQuota > 0 {
+		weekStart := capacityWeekStart(now)
+		booked := 0
+		for _, o := range s.data.Orders {
+			if o.ServiceID != svc.ID || o.CreatedAt.Before(weekStart) || !orderCountsTowardQuota(o, now) {
+				continue
+			}
+			booked++
+		}
+		left := cfg.WeeklyQuota - booked - reservations
+		if remaining < 0 || left < remaining {
+			remaining = left
+		}
+		if left <= 0 {
+			nextWeek := weekStart.AddDate(0, 0, 7).UTC()
+			if nextAvailable.IsZero() || nextWeek.After(nextAvailable) {
+				nextAvailable = nextWeek
+			}
+		}
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, nextAvailable
+}
+
+func (s *Store) checkCapacityLocked(order *models.Order, now time.Time) (*models.WaitlistEntry, error) {
+	svc := s.findServiceLocked(order.ServiceID)
+	var entry *models.WaitlistEntry
+	if order.WaitlistEntryID != 0 {
+		for _, e := range s.data.WaitlistEntries {
+			if e.ID == order.WaitlistEntryID {
+				entry = e
+				break
+			}
+		}
+		if entry == nil || entry.ServiceID != order.ServiceID || entry.Status != "notified" || !now.Before(entry.ReservationExpiresAt) {
+			return nil, ErrReservationInvalid
+		}
+	}
+	if !hasCapacityLimit(svc) || order.Source == OrderSourceAdmin {
+		return entry, nil
+	}
+	var excludeID uint
+	if entry != nil {
+		excludeID = entry.ID
+	}
+	if remaining, _ := s.capacityUsageLocked(svc, now, excludeID); remaining <= 0 {
+		return nil, ErrServiceSoldOut
+	}
+	return entry, nil
+}
+
+func (s *Store) serviceAvailabilityLocked(svc *models.Service, now time.Time) ServiceAvailability {
+	remaining, next := s.capacityUsageLocked(svc, now, 0)
+	waiting := 0
+	for _, e := range s.data.WaitlistEntries {
+		if e.ServiceID == svc.ID && e.Status == "waiting" {
+			waiting++
+		}
+	}
+	return ServiceAvailability{
+		SoldOut:         remaining <= 0,
+		Remaining:       remaining,
+		NextAvailableAt: next,
+		WaitlistCount:   waiting,
+	}
+}
+
+func (s *Store) GetServiceAvailability(serviceID uint, now time.Time) (*ServiceAvailability, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	svc := s.findServiceLocked(serviceID)
+	if !hasCapacityLimit(svc) {
+		return nil, false
+	}
+	availability := s.serviceAvailabilityLocked(svc, now)
+	return &availability, true
+}
+
+func (s *Store) ListServiceAvailability(now time.Time) map[uint]ServiceAvailability {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make(map[uint]ServiceAvailability)
+	for _, svc := range s.data.Services {
+		if hasCapacityLimit(svc) {
+			out[svc.ID] = s.serviceAvailabilityLocked(svc, now)
+		}
+	}
+	return out
+}
+
+func (s *Store) UpdateServiceCapacity(id uint, cfg *models.ServiceCapacity) (*models.Service, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	svc := s.findServiceLocked(id)
+	if svc == nil {
+		return nil, os.ErrNotExist
+	}
+	if cfg == nil {
+		svc.Capacity = nil
+	} else {
+		capacity := *cfg
+		svc.Capacity = &capacity
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "service",
+		Action:      "capacity_updated",
+		Title:       fmt.Sprintf("Kapasitas layanan \"%s\" diperbarui", svc.Title),
+		Description: describeCapacity(svc.Capacity),
+		ReferenceID: svc.ID,
+		Metadata: map[string]string{
+			"title":          svc.Title,
+			"slug":           svc.Slug,
+			"highlight_type": "updated",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneService(svc)
+	return &clone, nil
+}
+
+func describeCapacity(cfg *models.ServiceCapacity) string {
+	if cfg == nil || (cfg.MaxActiveOrders <= 0 && cfg.WeeklyQuota <= 0) {
+		return "Tanpa batas kapasitas"
+	}
+	parts := []string{}
+	if cfg.MaxActiveOrders > 0 {
+		parts = append(parts, fmt.Sprintf("Maks %d order aktif", cfg.MaxActiveOrders))
+	}
+	if cfg.WeeklyQuota > 0 {
+		parts = append(parts, fmt.Sprintf("Kuota %d order per minggu", cfg.WeeklyQuota))
+	}
+	return strings.Join(parts, " • ")
+}
+
+func (s *Store) JoinWaitlist(entry *models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	svc := s.findServiceLocked(entry.ServiceID)
+	if svc == nil {
+		return nil, os.ErrNotExist
+	}
+	for _, e := range s.data.WaitlistEntries {
+		if e.ServiceID == entry.ServiceID && strings.EqualFold(e.CustomerEmail, entry.CustomerEmail) && (e.Status == "waiting" || e.Status == "notified") {
+			clone := *e
+			return &clone, nil
+		}
+	}
+	now := time.Now().UTC()
+	entry.ID = s.nextID("waitlist_entry")
+	entry.Status = "waiting"
+	entry.CreatedAt = now
+	entry.UpdatedAt = now
+	clone := *entry
+	s.data.WaitlistEntries = append(s.data.WaitlistEntries, &clone)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "waitlist",
+		Action:      "joined",
+		Title:       fmt.Sprintf("%s masuk daftar tunggu", entry.CustomerName),
+		Description: svc.Title,
+		ReferenceID: entry.ID,
+		Metadata: map[string]string{
+			"service_id":     fmt.Sprintf("%d", svc.ID),
+			"service_title":  svc.Title,
+			"customer_email": entry.CustomerEmail,
+			"highlight_type": "waitlist",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *Store) GetWaitlistEntryByID(id uint) (*models.WaitlistEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	for _, e := range s.data.WaitlistEntries {
+		if e.ID == id {
+			clone := *e
+			return &clone, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) ListWaitlistEntries(serviceID uint) []models.WaitlistEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.WaitlistEntry, 0, len(s.data.WaitlistEntries))
+	for _, e := range s.data.WaitlistEntries {
+		if serviceID != 0 && e.ServiceID != serviceID {
+			continue
+		}
+		out = append(out, *e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) CancelWaitlistEntry(id uint) (*models.WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, e := range s.data.WaitlistEntries {
+		if e.ID != id {
+			continue
+		}
+		if e.Status == "waiting" || e.Status == "notified" {
+			e.Status = "cancelled"
+			e.UpdatedAt = time.Now().UTC()
+			if err := s.persistLocked(); err != nil {
+				return nil, err
+			}
+		}
+		clone := *e
+		return &clone, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+// restoreWaitlistReservationLocked hands a converted waitlist reservation
+// back to the customer when its order is removed, for example after the
+// payment request failed. Expired reservations are swept by AdvanceWaitlist.
+func (s *Store) restoreWaitlistReservationLocked(orderID uint) {
+	for _, e := range s.data.WaitlistEntries {
+		if e.OrderID == orderID && e.Status == "converted" {
+			e.Status = "notified"
+			e.OrderID = 0
+			e.UpdatedAt = time.Now().UTC()
+		}
+	}
+}
+
+func (s *Store) AdvanceWaitlist(now time.Time) ([]models.WaitlistEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	changed := false
+	for _, e := range s.data.WaitlistEntries {
+		if e.Status == "notified" && !now.Before(e.ReservationExpiresAt) {
+			e.Status = "expired"
+			e.UpdatedAt = now
+			changed = true
+		}
+	}
+	waiting := make(map[uint][]*models.WaitlistEntry)
+	for _, e := range s.data.WaitlistEntries {
+		if e.Status == "waiting" {
+			waiting[e.ServiceID] = append(waiting[e.ServiceID], e)
+		}
+	}
+	var notified []models.WaitlistEntry
+	for _, svc := range s.data.Services {
+		queue := waiting[svc.ID]
+		if len(queue) == 0 || !hasCapacityLimit(svc) {
+			continue
+		}
+		sort.Slice(queue, func(i, j int) bool { return queue[i].CreatedAt.Before(queue[j].CreatedAt) })
+		remaining, _ := s.capacityUsageLocked(svc, now, 0)
+		for i := 0; i < remaining && i < len(queue); i++ {
+			entry := queue[i]
+			entry.Status = "notified"
+			entry.NotifiedAt = now
+			entry.ReservationExpiresAt = now.Add(reservationWindow(svc.Capacity))
+			entry.UpdatedAt = now
+			changed = true
+			notified = append(notified, *entry)
+			s.appendActivityLocked(&models.Activity{
+				Type:        "waitlist",
+				Action:      "notified",
+				Title:       fmt.Sprintf("Slot tersedia untuk %s", entry.CustomerName),
+				Description: svc.Title,
+				ReferenceID: entry.ID,
+				Metadata: map[string]string{
+					"service_id":     fmt.Sprintf("%d", svc.ID),
+					"service_title":  svc.Title,
+					"customer_email": entry.CustomerEmail,
+					"highlight_type": "waitlist",
+				},
+			})
+		}
+	}
+	if !changed {
+		return nil, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return notified, nil
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrCaseStudySlugTaken   = errors.New("case study slug is already in use")
+	ErrCaseStudySlugInvalid = errors.New("case study slug is required")
+)
+
+func cloneCaseStudy(src *models.CaseStudy) models.CaseStudy {
+	clone := *src
+	if len(src.BeforeAfter) > 0 {
+		clone.BeforeAfter = append([]models.CaseStudyAssetPair(nil), src.BeforeAfter...)
+	} else {
+		clone.BeforeAfter = nil
+	}
+	if len(src.Metrics) > 0 {
+		clone.Metrics = append([]models.CaseStudyMetric(nil), src.Metrics...)
+	} else {
+		clone.Metrics = nil
+	}
+	if src.Testimonial != nil {
+		quote := *src.Testimonial
+		clone.Testimonial = &quote
+	}
+	if len(src.ServiceIDs) > 0 {
+		clone.ServiceIDs = append([]uint(nil), src.ServiceIDs...)
+	} else {
+		clone.ServiceIDs = nil
+	}
+	return clone
+}
+
+func (s *Store) findCaseStudyLocked(id uint) *models.CaseStudy {
+	for _, cs := range s.data.CaseStudies {
+		if cs.ID == id {
+			return cs
+		}
+	}
+	return nil
+}
+
+func (s *Store) caseStudySlugTakenLocked(slug string, exceptID uint) bool {
+	for _, cs := range s.data.CaseStudies {
+		if cs.Slug == slug && cs.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareCaseStudyLocked normalizes the editable fields of a case study in
+// place and resolves its slug, falling back to the title.
+func (s *Store) prepareCaseStudyLocked(cs *models.CaseStudy) error {
+	cs.Title = strings.TrimSpace(cs.Title)
+	cs.Slug = slugify(cs.Slug)
+	if cs.Slug == "" {
+		cs.Slug = slugify(cs.Title)
+	}
+	if cs.Slug == "" {
+		return ErrCaseStudySlugInvalid
+	}
+	if s.caseStudySlugTakenLocked(cs.Slug, cs.ID) {
+		return ErrCaseStudySlugTaken
+	}
+	cs.Summary = strings.TrimSpace(cs.Summary)
+	cs.CoverImage = strings.TrimSpace(cs.CoverImage)
+	cs.ClientName = strings.TrimSpace(cs.ClientName)
+	cs.ClientIndustry = strings.TrimSpace(cs.ClientIndustry)
+	cs.Challenge = strings.TrimSpace(cs.Challenge)
+	cs.Approach = strings.TrimSpace(cs.Approach)
+	cs.Result = strings.TrimSpace(cs.Result)
+	s.linkCaseStudyMediaLocked(cs)
+	cs.ServiceIDs = sanitizeIDs(cs.ServiceIDs)
+	if cs.Testimonial != nil && strings.TrimSpace(cs.Testimonial.Quote) == "" {
+		cs.Testimonial = nil
+	}
+	return nil
+}
+
+// ListCaseStudies returns case studies newest first.
+func (s *Store) ListCaseStudies() []models.CaseStudy {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.CaseStudy, 0, len(s.data.CaseStudies))
+	for _, cs := range s.data.CaseStudies {
+		out = append(out, cloneCaseStudy(cs))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID > out[j].ID
+		}
+		return out[i].CreatedAt.After(out[j].CreatedAt)
+	})
+	return out
+}
+
+func (s *Store) GetCaseStudyByID(id uint) (*models.CaseStudy, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	if cs := s.findCaseStudyLocked(id); cs != nil {
+		clone := cloneCaseStudy(cs)
+		return &clone, true
+	}
+	return nil, false
+}
+
+func (s *Store) GetCaseStudyBySlug(slug string) (*models.CaseStudy, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	lower := strings.ToLower(slug)
+	for _, cs := range s.data.CaseStudies {
+		if cs.Slug == lower {
+			clone := cloneCaseStudy(cs)
+			return &clone, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) CreateCaseStudy(cs *models.CaseStudy) (*models.CaseStudy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	cs.ID = 0
+	if err := s.prepareCaseStudyLocked(cs); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	cs.ID = s.nextID("case_study")
+	if cs.PublishStatus == "" {
+		cs.PublishStatus = PublishDraft
+	}
+	cs.CreatedAt = now
+	cs.UpdatedAt = now
+	clone := cloneCaseStudy(cs)
+	s.data.CaseStudies = append(s.data.CaseStudies, &clone)
+	s.indexCaseStudyLocked(&clone)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "case_study",
+		Action:      "created",
+		Title:       fmt.Sprintf("Studi kasus \"%s\" ditambahkan", cs.Title),
+		Description: cs.Slug,
+		ReferenceID: cs.ID,
+		Metadata: map[string]string{
+			"title": cs.Title,
+			"slug":  cs.Slug,
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := cloneCaseStudy(&clone)
+	return &out, nil
+}
+
+// UpdateCaseStudy replaces the editable fields of a case study. Publishing
+// state is changed through SetCaseStudyPublishing.
+func (s *Store) UpdateCaseStudy(id uint, update *models.CaseStudy, author string) (*models.CaseStudy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	cs := s.findCaseStudyLocked(id)
+	if cs == nil {
+		return nil, os.ErrNotExist
+	}
+	next := cloneCaseStudy(update)
+	next.ID = cs.ID
+	if err := s.prepareCaseStudyLocked(&next); err != nil {
+		return nil, err
+	}
+	prev := cloneCaseStudy(cs)
+	next.Publishing = cs.Publishing
+	next.CreatedAt = cs.CreatedAt
+	next.UpdatedAt = time.Now().UTC()
+	*cs = next
+	s.recordRevisionLocked(RevisionCaseStudy, cs.ID, "updated", author, &prev, cs)
+	s.indexCaseStudyLocked(cs)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "case_study",
+		Action:      "updated",
+		Title:       fmt.Sprintf("Studi kasus \"%s\" diperbarui", cs.Title),
+		Description: cs.Slug,
+		ReferenceID: cs.ID,
+		Metadata: map[string]string{
+			"title":     cs.Title,
+			"slug":      cs.Slug,
+			"old_title": prev.Title,
+			"old_slug":  prev.Slug,
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneCaseStudy(cs)
+	return &clone, nil
+}
+
+func (s *Store) DeleteCaseStudy(id uint) (*models.CaseStudy, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	filtered := s.data.CaseStudies[:0]
+	var deleted *models.CaseStudy
+	for _, cs := range s.data.CaseStudies {
+		if cs.ID != id {
+			filtered = append(filtered, cs)
+			continue
+		}
+		clone := cloneCaseStudy(cs)
+		deleted = &clone
+	}
+	s.data.CaseStudies = filtered
+	if deleted == nil {
+		return nil, os.ErrNotExist
+	}
+	s.search.remove(searchDocKey(SearchKindCaseStudy, deleted.ID))
+	s.appendActivityLocked(&models.Activity{
+		Type:        "case_study",
+		Action:      "deleted",
+		Title:       fmt.Sprintf("Studi kasus \"%s\" dihapus", deleted.Title),
+		Description: deleted.Slug,
+		ReferenceID: deleted.ID,
+		Metadata: map[string]string{
+			"title": deleted.Title,
+			"slug":  deleted.Slug,
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func (s *Store) unlinkCaseStudyGalleryLocked(galleryItemID uint) {
+	for _, cs := range s.data.CaseStudies {
+		if cs.GalleryItemID == galleryItemID {
+			cs.GalleryItemID = 0
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrCategoryParentNotFound  = errors.New("parent category not found")
+	ErrCategoryCycle           = errors.New("category cannot be moved under itself or its descendants")
+	ErrCategoryReassignInvalid = errors.New("invalid category to reassign services to")
+)
+
+type CategoryUpdate struct {
+	Name        string
+	Slug        string
+	ParentID    *uint
+	SortOrder   *int
+	Description *string
+	Icon        *string
+	CoverImage  *string
+}
+
+func sortCategories(cats []models.Category) {
+	sort.Slice(cats, func(i, j int) bool {
+		if cats[i].SortOrder != cats[j].SortOrder {
+			return cats[i].SortOrder < cats[j].SortOrder
+		}
+		return strings.ToLower(cats[i].Name) < strings.ToLower(cats[j].Name)
+	})
+}
+
+func (s *Store) findCategoryLocked(id uint) *models.Category {
+	for _, c := range s.data.Categories {
+		if c.ID == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Store) maxCategorySortLocked(parentID uint) int {
+	max := 0
+	for _, c := range s.data.Categories {
+		if c.ParentID == parentID && c.SortOrder > max {
+			max = c.SortOrder
+		}
+	}
+	return max
+}
+
+func (s *Store) validateCategoryParentLocked(id, parentID uint) error {
+	if parentID == 0 {
+		return nil
+	}
+	if s.findCategoryLocked(parentID) == nil {
+		return ErrCategoryParentNotFound
+	}
+	seen := make(map[uint]bool)
+	for current := parentID; current != 0 && !seen[current]; {
+		if current == id {
+			return ErrCategoryCycle
+		}
+		seen[current] = true
+		parent := s.findCategoryLocked(current)
+		if parent == nil {
+			break
+		}
+		current = parent.ParentID
+	}
+	return nil
+}
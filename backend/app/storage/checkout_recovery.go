@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+type RecoveryPolicy struct {
+	Delays         []time.Duration
+	MaxPerCustomer int
+	CapWindow      time.Duration
+}
+
+type DueRecovery struct {
+	Order models.Order
+	Step  int
+}
+
+type CheckoutRecoveryStepReport struct {
+	Step      int `json:"step"`
+	Sent      int `json:"sent"`
+	Clicked   int `json:"clicked"`
+	Recovered int `json:"recovered"`
+}
+
+type CheckoutRecoveryReport struct {
+	From             time.Time                    `json:"from,omitempty"`
+	To               time.Time                    `json:"to,omitempty"`
+	RemindersSent    int                          `json:"reminders_sent"`
+	OrdersContacted  int                          `json:"orders_contacted"`
+	Clicked          int                          `json:"clicked"`
+	RecoveredOrders  int                          `json:"recovered_orders"`
+	RecoveredRevenue float64                      `json:"recovered_revenue"`
+	PromoRedemptions int                          `json:"promo_redemptions"`
+	OptOuts          int                          `json:"opt_outs"`
+	Steps            []CheckoutRecoveryStepReport `json:"steps"`
+}
+
+func orderAbandoned(o *models.Order) bool {
+	paymentStatus := strings.ToUpper(strings.TrimSpace(o.PaymentStatus))
+	if isPaymentCompletedStatus(paymentStatus) {
+		return false
+	}
+	status := strings.ToLower(strings.TrimSpace(o.Status))
+	if status == "pending" {
+		return true
+	}
+	return IsCancelledStatus(status) && status != "cancelled_by_user" && IsPaymentFailureStatus(paymentStatus)
+}
+
+func orderPaid(o *models.Order) bool {
+	return isPaymentCompletedStatus(o.PaymentStatus)
+}
+
+func recoveryEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (s *Store) recoveryOptedOutLocked(email string) bool {
+	key := recoveryEmailKey(email)
+	for _, opt := range s.data.RecoveryOptOuts {
+		if opt.Email == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) DueCheckoutRecoveries(now time.Time, policy RecoveryPolicy) []DueRecovery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	if len(policy.Delays) == 0 {
+		return nil
+	}
+	horizon := policy.Delays[len(policy.Delays)-1] + 24*time.Hour
+	sentByOrder := make(map[uint]int)
+	recentByEmail := make(map[string]int)
+	for _, rec := range s.data.CheckoutRecoveries {
+		sentByOrder[rec.OrderID]++
+		if policy.CapWindow <= 0 || now.Sub(rec.SentAt) < policy.CapWindow {
+			recentByEmail[recoveryEmailKey(rec.CustomerEmail)]++
+		}
+	}
+	latest := make(map[string]*models.Order)
+	for _, o := range s.data.Orders {
+		key := recoveryEmailKey(o.CustomerEmail)
+		if key == "" {
+			continue
+		}
+		key = fmt.Sprintf("%s|%d", key, o.ServiceID)
+		if current, ok := latest[key]; !ok || o.CreatedAt.After(current.CreatedAt) {
+			latest[key] = o
+		}
+	}
+	picked := make(map[string]struct{})
+	var out []DueRecovery
+	for _, o := range latest {
+		email := recoveryEmailKey(o.CustomerEmail)
+		if !orderAbandoned(o) || o.Source == OrderSourceAdmin {
+			continue
+		}
+		if now.Sub(o.CreatedAt) > horizon {
+			continue
+		}
+		step := sentByOrder[o.ID]
+		if step >= len(policy.Delays) || now.Before(o.CreatedAt.Add(policy.Delays[step])) {
+			continue
+		}
+		if policy.MaxPerCustomer > 0 && recentByEmail[email] >= policy.MaxPerCustomer {
+			continue
+		}
+		if _, ok := picked[email]; ok {
+			continue
+		}
+		if s.recoveryOptedOutLocked(email) {
+			continue
+		}
+		picked[email] = struct{}{}
+		out = append(out, DueRecovery{Order: *o, Step: step + 1})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Order.CreatedAt.Before(out[j].Order.CreatedAt) })
+	return out
+}
+
+func (s *Store) RecordCheckoutRecovery(orderID uint, email string, step int, promoCode string, now time.Time) (*models.CheckoutRecovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	rec := &models.CheckoutRecovery{
+		ID:            s.nextID("checkout_recovery"),
+		OrderID:       orderID,
+		CustomerEmail: recoveryEmailKey(email),
+		Step:          step,
+		PromoCode:     promoCode,
+		SentAt:        now,
+	}
+	s.data.CheckoutRecoveries = append(s.data.CheckoutRecoveries, rec)
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := *rec
+	return &clone, nil
+}
+
+func (s *Store) MarkCheckoutRecoveryClicked(orderID uint, now time.Time) (*models.CheckoutRecovery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var last *models.CheckoutRecovery
+	for _, rec := range s.data.CheckoutRecoveries {
+		if rec.OrderID == orderID && (last == nil || rec.SentAt.After(last.SentAt)) {
+			last = rec
+		}
+	}
+	if last == nil {
+		return nil, os.ErrNotExist
+	}
+	if last.ClickedAt.IsZero() {
+		last.ClickedAt = now
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+	}
+	clone := *last
+	return &clone, nil
+}
+
+func (s *Store) OptOutOfRecovery(email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	key := recoveryEmailKey(email)
+	if key == "" || s.recoveryOptedOutLocked(key) {
+		return nil
+	}
+	s.data.RecoveryOptOuts = append(s.data.RecoveryOptOuts, &models.RecoveryOptOut{
+		Email:     key,
+		CreatedAt: time.Now().UTC(),
+	})
+	return s.persistLocked()
+}
+
+func (s *Store) IsRecoveryOptedOut(email string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	return s.recoveryOptedOutLocked(email)
+}
+
+func (s *Store) CheckoutRecoveryReport(from, to time.Time) CheckoutRecoveryReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	report := CheckoutRecoveryReport{From: from, To: to}
+	inRange := func(t time.Time) bool {
+		return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+	}
+	ordersByID := make(map[uint]*models.Order, len(s.data.Orders))
+	recoveredBy := make(map[uint]*models.Order)
+	for _, o := range s.data.Orders {
+		ordersByID[o.ID] = o
+		if o.RecoveredFromID != 0 && orderPaid(o) {
+			recoveredBy[o.RecoveredFromID] = o
+		}
+	}
+	type contact struct {
+		firstSent time.Time
+		lastStep  int
+	}
+	contacts := make(map[uint]*contact)
+	steps := make(map[int]*CheckoutRecoveryStepReport)
+	promoCodes := make(map[string]struct{})
+	for _, rec := range s.data.CheckoutRecoveries {
+		if !inRange(rec.SentAt) {
+			continue
+		}
+		report.RemindersSent++
+		step := steps[rec.Step]
+		if step == nil {
+			step = &CheckoutRecoveryStepReport{Step: rec.Step}
+			steps[rec.Step] = step
+		}
+		step.Sent++
+		if !rec.ClickedAt.IsZero() {
+			step.Clicked++
+			report.Clicked++
+		}
+		if rec.PromoCode != "" {
+			promoCodes[normalizePromoCode(rec.PromoCode)] = struct{}{}
+		}
+		c := contacts[rec.OrderID]
+		if c == nil {
+			c = &contact{firstSent: rec.SentAt}
+			contacts[rec.OrderID] = c
+		}
+		if rec.SentAt.Before(c.firstSent) {
+			c.firstSent = rec.SentAt
+		}
+		if rec.Step > c.lastStep {
+			c.lastStep = rec.Step
+		}
+	}
+	report.OrdersContacted = len(contacts)
+	for orderID, c := range contacts {
+		var paid *models.Order
+		if o, ok := ordersByID[orderID]; ok && orderPaid(o) && o.UpdatedAt.After(c.firstSent) {
+			paid = o
+		} else if r, ok := recoveredBy[orderID]; ok {
+			paid = r
+		}
+		if paid == nil {
+			continue
+		}
+		report.RecoveredOrders++
+		report.RecoveredRevenue += paid.Amount
+		if step := steps[c.lastStep]; step != nil {
+			step.Recovered++
+		}
+		if _, ok := promoCodes[normalizePromoCode(paid.PromoCode)]; ok && paid.PromoCode != "" {
+			report.PromoRedemptions++
+		}
+	}
+	report.RecoveredRevenue = roundCurrency(report.RecoveredRevenue)
+	for _, opt := range s.data.RecoveryOptOuts {
+		if inRange(opt.CreatedAt) {
+			report.OptOuts++
+		}
+	}
+	report.Steps = make([]CheckoutRecoveryStepReport, 0, len(steps))
+	for _, step := range steps {
+		report.Steps = append(report.Steps, *step)
+	}
+	sort.Slice(report.Steps, func(i, j int) bool { return report.Steps[i].Step < report.Steps[j].Step })
+	return report
+}
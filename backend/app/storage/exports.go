@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+func cloneExportJob(src *models.ExportJob) *models.ExportJob {
+	if src == nil {
+		return nil
+	}
+	clone := *src
+	if len(src.Columns) > 0 {
+		clone.Columns = append([]string(nil), src.Columns...)
+	}
+	return &clone
+}
+
+func (s *Store) ListPaymentTransactions(from, to time.Time) []models.PaymentTransaction {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.PaymentTransaction, 0, len(s.data.PaymentTransactions))
+	for _, tx := range s.data.PaymentTransactions {
+		if !from.IsZero() && tx.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && tx.CreatedAt.After(to) {
+			continue
+		}
+		out = append(out, *clonePaymentTransaction(tx))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) CreateExportJob(job *models.ExportJob) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	job.ID = s.nextID("export_job")
+	job.Status = "queued"
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	stored := cloneExportJob(job)
+	s.data.ExportJobs = append(s.data.ExportJobs, stored)
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return cloneExportJob(stored), nil
+}
+
+func (s *Store) GetExportJobByID(id uint) (*models.ExportJob, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	for _, job := range s.data.ExportJobs {
+		if job.ID == id {
+			return cloneExportJob(job), true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) ListExportJobs() []models.ExportJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.ExportJob, 0, len(s.data.ExportJobs))
+	for _, job := range s.data.ExportJobs {
+		out = append(out, *cloneExportJob(job))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) updateExportJob(id uint, apply func(job *models.ExportJob)) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, job := range s.data.ExportJobs {
+		if job.ID != id {
+			continue
+		}
+		apply(job)
+		job.UpdatedAt = time.Now().UTC()
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		return cloneExportJob(job), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) MarkExportJobRunning(id uint) (*models.ExportJob, error) {
+	return s.updateExportJob(id, func(job *models.ExportJob) {
+		job.Status = "running"
+		job.Error = ""
+	})
+}
+
+func (s *Store) CompleteExportJob(id uint, fileName string, rows int) (*models.ExportJob, error) {
+	return s.updateExportJob(id, func(job *models.ExportJob) {
+		job.Status = "completed"
+		job.FileName = fileName
+		job.RowCount = rows
+		job.CompletedAt = time.Now().UTC()
+	})
+}
+
+func (s *Store) FailExportJob(id uint, reason string) (*models.ExportJob, error) {
+	return s.updateExportJob(id, func(job *models.ExportJob) {
+		job.Status = "failed"
+		job.Error = reason
+		job.CompletedAt = time.Now().UTC()
+	})
+}
+
+func (s *Store) DeleteExportJob(id uint) (*models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for i, job := range s.data.ExportJobs {
+		if job.ID != id {
+			continue
+		}
+		s.data.ExportJobs = append(s.data.ExportJobs[:i], s.data.ExportJobs[i+1:]...)
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		return job, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) PruneExportJobs(before time.Time) ([]models.ExportJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var removed []models.ExportJob
+	kept := s.data.ExportJobs[:0]
+	for _, job := range s.data.ExportJobs {
+		if job.CreatedAt.Before(before) {
+			removed = append(removed, *job)
+			continue
+		}
+		kept = append(kept, job)
+	}
+	s.data.ExportJobs = kept
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+func (s *Store) FailInterruptedExportJobs(reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	changed := false
+	for _, job := range s.data.ExportJobs {
+		if job.Status != "queued" && job.Status != "running" {
+			continue
+		}
+		job.Status = "failed"
+		job.Error = reason
+		job.CompletedAt = now
+		job.UpdatedAt = now
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return s.persistLocked()
+}
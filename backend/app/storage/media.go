@@ -0,0 +1,649 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	MediaImage    = "image"
+	MediaVideo    = "video"
+	MediaDocument = "document"
+)
+
+var ErrMediaInUse = errors.New("media item is still referenced")
+
+type MediaQuery struct {
+	Text   string
+	Type   string
+	Unused bool
+	Limit  int
+	Offset int
+}
+
+type MediaUpdate struct {
+	AltText           *string
+	Caption           *string
+	PublicUseApproved *bool
+}
+
+func MediaTypeFor(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "image/"):
+		return MediaImage
+	case strings.HasPrefix(contentType, "video/"):
+		return MediaVideo
+	}
+	return MediaDocument
+}
+
+func cloneMediaItem(src *models.MediaItem) models.MediaItem {
+	clone := *src
+	if src.Image != nil {
+		image := cloneImageAssets(map[string]models.ImageAsset{src.URL: *src.Image})[src.URL]
+		clone.Image = &image
+	}
+	clone.References = nil
+	return clone
+}
+
+func (s *Store) findMediaLocked(id uint) *models.MediaItem {
+	for _, item := range s.data.Media {
+		if item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// findMediaByURLLocked matches url against the library by its store key
+// as well, so references written before the upload URLs moved to the CDN
+// still resolve.
+func (s *Store) findMediaByURLLocked(url string) *models.MediaItem {
+	if url == "" {
+		return nil
+	}
+	for _, item := range s.data.Media {
+		if item.URL == url {
+			return item
+		}
+	}
+	for _, item := range s.data.Media {
+		if mediaKeyMatches(item, url) {
+			return item
+		}
+	}
+	return nil
+}
+
+func mediaKeyMatches(item *models.MediaItem, url string) bool {
+	return item.Key != "" && strings.HasSuffix(url, "/"+item.Key)
+}
+
+func (s *Store) CreateMediaItem(item *models.MediaItem) (*models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	item.ID = s.nextID("media")
+	item.Type = MediaTypeFor(item.ContentType)
+	item.AltText = strings.TrimSpace(item.AltText)
+	item.Caption = strings.TrimSpace(item.Caption)
+	item.References = nil
+	item.UnusedSince = time.Time{}
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	clone := cloneMediaItem(item)
+	s.data.Media = append(s.data.Media, &clone)
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := cloneMediaItem(&clone)
+	out.References = []models.MediaReference{}
+	return &out, nil
+}
+
+func (s *Store) GetMediaItem(id uint) (*models.MediaItem, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	item := s.findMediaLocked(id)
+	if item == nil {
+		return nil, false
+	}
+	clone := s.mediaWithReferencesLocked(item, s.mediaReferencesLocked())
+	return &clone, true
+}
+
+func (s *Store) MediaByURL(url string) (*models.MediaItem, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	item := s.findMediaByURLLocked(url)
+	if item == nil {
+		return nil, false
+	}
+	clone := cloneMediaItem(item)
+	return &clone, true
+}
+
+func (s *Store) ListMedia(q MediaQuery) ([]models.MediaItem, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	refs := s.mediaReferencesLocked()
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+	matched := make([]models.MediaItem, 0)
+	for _, item := range s.data.Media {
+		if q.Type != "" && item.Type != q.Type {
+			continue
+		}
+		if q.Unused && len(refs[item.ID]) > 0 {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(item.FileName+" "+item.AltText+" "+item.Caption), text) {
+			continue
+		}
+		matched = append(matched, s.mediaWithReferencesLocked(item, refs))
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].CreatedAt.Equal(matched[j].CreatedAt) {
+			return matched[i].CreatedAt.After(matched[j].CreatedAt)
+		}
+		return matched[i].ID > matched[j].ID
+	})
+	total := len(matched)
+	start := min(q.Offset, total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+	return matched[start:end], total
+}
+
+func (s *Store) UpdateMediaItem(id uint, update MediaUpdate) (*models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	item := s.findMediaLocked(id)
+	if item == nil {
+		return nil, os.ErrNotExist
+	}
+	if update.AltText != nil {
+		item.AltText = strings.TrimSpace(*update.AltText)
+	}
+	if update.Caption != nil {
+		item.Caption = strings.TrimSpace(*update.Caption)
+	}
+	if update.PublicUseApproved != nil {
+		item.PublicUseApproved = *update.PublicUseApproved
+	}
+	item.UpdatedAt = time.Now().UTC()
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := s.mediaWithReferencesLocked(item, s.mediaReferencesLocked())
+	return &clone, nil
+}
+
+// DeleteMediaItem removes an unreferenced item. Callers delete the file.
+func (s *Store) DeleteMediaItem(id uint) (*models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	item := s.findMediaLocked(id)
+	if item == nil {
+		return nil, os.ErrNotExist
+	}
+	if len(s.mediaReferencesLocked()[id]) > 0 {
+		return nil, ErrMediaInUse
+	}
+	return s.removeMediaLocked(item)
+}
+
+// DiscardMediaByURL drops the record for a file that was uploaded but never
+// saved to an entity. It returns nil when the URL is not tracked.
+func (s *Store) DiscardMediaByURL(url string) (*models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	item := s.findMediaByURLLocked(url)
+	if item == nil {
+		return nil, nil
+	}
+	return s.removeMediaLocked(item)
+}
+
+// SetMediaImage replaces the rendered variants of an item after its image was
+// processed again, updating the copies kept on services and gallery items.
+func (s *Store) SetMediaImage(id uint, image models.ImageAsset, watermarked bool) (*models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	item := s.findMediaLocked(id)
+	if item == nil {
+		return nil, os.ErrNotExist
+	}
+	item.Image = &image
+	item.Width, item.Height = image.Width, image.Height
+	item.Watermarked = watermarked
+	item.UpdatedAt = time.Now().UTC()
+	for _, svc := range s.data.Services {
+		if _, ok := svc.Images[item.URL]; ok {
+			svc.Images[item.URL] = image
+		}
+	}
+	for _, gallery := range s.data.GalleryItems {
+		if _, ok := gallery.Images[item.URL]; ok {
+			gallery.Images[item.URL] = image
+		}
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := s.mediaWithReferencesLocked(item, s.mediaReferencesLocked())
+	return &clone, nil
+}
+
+func (s *Store) removeMediaLocked(item *models.MediaItem) (*models.MediaItem, error) {
+	filtered := s.data.Media[:0]
+	for _, candidate := range s.data.Media {
+		if candidate.ID != item.ID {
+			filtered = append(filtered, candidate)
+		}
+	}
+	s.data.Media = filtered
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneMediaItem(item)
+	return &clone, nil
+}
+
+// CollectUnusedMedia marks unreferenced items and removes those that stayed
+// unreferenced for longer than grace. The removed items are returned so their
+// files can be deleted.
+func (s *Store) CollectUnusedMedia(now time.Time, grace time.Duration) ([]models.MediaItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	refs := s.mediaReferencesLocked()
+	var removed []models.MediaItem
+	dirty := false
+	kept := s.data.Media[:0]
+	for _, item := range s.data.Media {
+		switch {
+		case len(refs[item.ID]) > 0:
+			if !item.UnusedSince.IsZero() {
+				item.UnusedSince = time.Time{}
+				dirty = true
+			}
+		case item.UnusedSince.IsZero():
+			item.UnusedSince = now
+			dirty = true
+		case now.Sub(item.UnusedSince) >= grace:
+			removed = append(removed, cloneMediaItem(item))
+			dirty = true
+			continue
+		}
+		kept = append(kept, item)
+	}
+	s.data.Media = kept
+	if len(removed) > 0 {
+		s.appendActivityLocked(&models.Activity{
+			Type:        "media",
+			Action:      "collected",
+			Title:       fmt.Sprintf("%d berkas media tidak terpakai dihapus", len(removed)),
+			Description: fmt.Sprintf("Tidak dipakai lebih dari %s", grace),
+			Metadata: map[string]string{
+				"count": fmt.Sprintf("%d", len(removed)),
+			},
+		})
+	}
+	if !dirty {
+		return nil, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+// BackfillMedia registers library items for uploads referenced by services,
+// gallery items and categories that predate the media library. keyFor maps a
+// public URL to its file store key and reports whether it is an upload.
+func (s *Store) BackfillMedia(keyFor func(string) (string, bool)) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	created := 0
+	register := func(url string, images map[string]models.ImageAsset) {
+		if url == "" || s.findMediaByURLLocked(url) != nil {
+			return
+		}
+		key, ok := keyFor(url)
+		if !ok {
+			return
+		}
+		item := &models.MediaItem{
+			ID:          s.nextID("media"),
+			Key:         key,
+			URL:         url,
+			ContentType: mime.TypeByExtension(path.Ext(key)),
+			FileName:    path.Base(key),
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+		item.Type = MediaTypeFor(item.ContentType)
+		if asset, ok := images[url]; ok {
+			image := asset
+			image.Variants = append([]models.ImageVariant(nil), asset.Variants...)
+			item.Image = &image
+			item.Width, item.Height = asset.Width, asset.Height
+		}
+		s.data.Media = append(s.data.Media, item)
+		created++
+	}
+	for _, svc := range s.data.Services {
+		for _, url := range serviceImageURLs(svc) {
+			register(url, svc.Images)
+		}
+	}
+	for _, item := range s.data.GalleryItems {
+		for _, url := range galleryImageURLs(item) {
+			register(url, item.Images)
+		}
+	}
+	for _, cat := range s.data.Categories {
+		register(cat.CoverImage, nil)
+	}
+	if created == 0 {
+		return 0, nil
+	}
+	for _, svc := range s.data.Services {
+		s.linkServiceMediaLocked(svc)
+	}
+	for _, item := range s.data.GalleryItems {
+		s.linkGalleryMediaLocked(item)
+	}
+	for _, cat := range s.data.Categories {
+		s.linkCategoryMediaLocked(cat)
+	}
+	for _, cs := range s.data.CaseStudies {
+		s.linkCaseStudyMediaLocked(cs)
+	}
+	if err := s.persistLocked(); err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+func (s *Store) linkServiceMediaLocked(svc *models.Service) {
+	svc.ThumbnailMediaID = 0
+	svc.GalleryMediaIDs = nil
+	if item := s.findMediaByURLLocked(svc.Thumbnail); item != nil {
+		svc.ThumbnailMediaID = item.ID
+		svc.Images = withMediaImage(svc.Images, item)
+	}
+	for _, url := range svc.GalleryImages {
+		if item := s.findMediaByURLLocked(url); item != nil {
+			svc.GalleryMediaIDs = append(svc.GalleryMediaIDs, item.ID)
+			svc.Images = withMediaImage(svc.Images, item)
+		}
+	}
+}
+
+func (s *Store) linkGalleryMediaLocked(gallery *models.GalleryItem) {
+	gallery.ThumbnailMediaID = 0
+	if item := s.findMediaByURLLocked(gallery.Thumbnail); item != nil {
+		gallery.ThumbnailMediaID = item.ID
+		gallery.Images = withMediaImage(gallery.Images, item)
+	}
+	for i := range gallery.Assets {
+		gallery.Assets[i].MediaID = 0
+		if item := s.findMediaByURLLocked(gallery.Assets[i].URL); item != nil {
+			gallery.Assets[i].MediaID = item.ID
+			gallery.Images = withMediaImage(gallery.Images, item)
+		}
+	}
+}
+
+func (s *Store) linkCategoryMediaLocked(cat *models.Category) {
+	cat.CoverMediaID = s.mediaIDForURLLocked(cat.CoverImage)
+}
+
+func (s *Store) linkCaseStudyMediaLocked(cs *models.CaseStudy) {
+	cs.CoverMediaID = s.mediaIDForURLLocked(cs.CoverImage)
+	for i := range cs.BeforeAfter {
+		cs.BeforeAfter[i].BeforeMediaID = s.mediaIDForURLLocked(cs.BeforeAfter[i].Before)
+		cs.BeforeAfter[i].AfterMediaID = s.mediaIDForURLLocked(cs.BeforeAfter[i].After)
+	}
+}
+
+func (s *Store) mediaIDForURLLocked(url string) uint {
+	if item := s.findMediaByURLLocked(url); item != nil {
+		return item.ID
+	}
+	return 0
+}
+
+func withMediaImage(images map[string]models.ImageAsset, item *models.MediaItem) map[string]models.ImageAsset {
+	if item.Image == nil {
+		return images
+	}
+	if _, ok := images[item.URL]; ok {
+		return images
+	}
+	if images == nil {
+		images = make(map[string]models.ImageAsset)
+	}
+	image := *item.Image
+	image.Variants = append([]models.ImageVariant(nil), item.Image.Variants...)
+	images[item.URL] = image
+	return images
+}
+
+// mediaReferencesLocked returns the references of every media item keyed by
+// media ID. The index is built on first use and dropped by persistLocked, so
+// reads between writes share it. Callers must not modify the result.
+func (s *Store) mediaReferencesLocked() map[uint][]models.MediaReference {
+	s.mediaRefsMu.Lock()
+	defer s.mediaRefsMu.Unlock()
+	if s.mediaRefs == nil {
+		s.mediaRefs = s.buildMediaReferencesLocked()
+	}
+	return s.mediaRefs
+}
+
+// buildMediaReferencesLocked collects references from the media IDs stored
+// on each entity. URLs are only resolved for records saved before those IDs
+// were tracked. Callers hold mediaRefsMu.
+func (s *Store) buildMediaReferencesLocked() map[uint][]models.MediaReference {
+	byURL := make(map[string]uint, len(s.data.Media))
+	for _, item := range s.data.Media {
+		byURL[item.URL] = item.ID
+	}
+	resolve := func(url string) uint {
+		if id, ok := byURL[url]; ok || url == "" {
+			return id
+		}
+		id := s.mediaIDForURLLocked(url)
+		byURL[url] = id
+		return id
+	}
+	refs := make(map[uint][]models.MediaReference)
+	add := func(id uint, url, entityType string, entityID uint, field, title string) {
+		if id == 0 {
+			id = resolve(url)
+		}
+		if id == 0 {
+			return
+		}
+		refs[id] = append(refs[id], models.MediaReference{EntityType: entityType, EntityID: entityID, Field: field, Title: title})
+	}
+	for _, svc := range s.data.Services {
+		add(svc.ThumbnailMediaID, svc.Thumbnail, RevisionService, svc.ID, "thumbnail", svc.Title)
+		if len(svc.GalleryMediaIDs) > 0 {
+			for _, id := range svc.GalleryMediaIDs {
+				add(id, "", RevisionService, svc.ID, "gallery_images", svc.Title)
+			}
+		} else {
+			for _, url := range svc.GalleryImages {
+				add(0, url, RevisionService, svc.ID, "gallery_images", svc.Title)
+			}
+		}
+	}
+	for _, item := range s.data.GalleryItems {
+		add(item.ThumbnailMediaID, item.Thumbnail, RevisionGallery, item.ID, "thumbnail", item.Title)
+		for _, asset := range item.Assets {
+			add(asset.MediaID, asset.URL, RevisionGallery, item.ID, "assets", item.Title)
+		}
+	}
+	for _, cat := range s.data.Categories {
+		add(cat.CoverMediaID, cat.CoverImage, RevisionCategory, cat.ID, "cover_image", cat.Name)
+	}
+	for _, cs := range s.data.CaseStudies {
+		add(cs.CoverMediaID, cs.CoverImage, RevisionCaseStudy, cs.ID, "cover_image", cs.Title)
+		for _, pair := range cs.BeforeAfter {
+			add(pair.BeforeMediaID, pair.Before, RevisionCaseStudy, cs.ID, "before_after", cs.Title)
+			add(pair.AfterMediaID, pair.After, RevisionCaseStudy, cs.ID, "before_after", cs.Title)
+		}
+	}
+	// Files used by older revisions stay referenced so restoring a revision
+	// never brings back URLs whose media was collected in the meantime.
+	type revisionRef struct {
+		media uint
+		kind  string
+		id    uint
+	}
+	seen := make(map[revisionRef]bool)
+	decoded := make(map[uint][]revisionMediaRef, len(s.data.Revisions))
+	for _, rev := range s.data.Revisions {
+		media, ok := s.revisionMedia[rev.ID]
+		if !ok {
+			media = revisionMediaRefs(rev)
+		}
+		decoded[rev.ID] = media
+		title, ok := s.revisionEntityTitleLocked(rev.EntityType, rev.EntityID)
+		if !ok {
+			continue
+		}
+		for _, ref := range media {
+			key := revisionRef{media: ref.id, kind: rev.EntityType, id: rev.EntityID}
+			if key.media == 0 {
+				key.media = resolve(ref.url)
+			}
+			if key.media == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if slices.ContainsFunc(refs[key.media], func(ref models.MediaReference) bool {
+				return ref.EntityType == key.kind && ref.EntityID == key.id
+			}) {
+				continue
+			}
+			add(key.media, "", rev.EntityType, rev.EntityID, "revisions", title)
+		}
+	}
+	s.revisionMedia = decoded
+	return refs
+}
+
+// revisionEntityTitleLocked returns the title of the entity a revision
+// belongs to. Revisions of deleted entities cannot be restored and report
+// false.
+func (s *Store) revisionEntityTitleLocked(kind string, id uint) (string, bool) {
+	switch kind {
+	case RevisionService:
+		if svc := s.findServiceLocked(id); svc != nil {
+			return svc.Title, true
+		}
+	case RevisionCategory:
+		if cat := s.findCategoryLocked(id); cat != nil {
+			return cat.Name, true
+		}
+	case RevisionGallery:
+		for _, item := range s.data.GalleryItems {
+			if item.ID == id {
+				return item.Title, true
+			}
+		}
+	case RevisionCaseStudy:
+		if cs := s.findCaseStudyLocked(id); cs != nil {
+			return cs.Title, true
+		}
+	}
+	return "", false
+}
+
+type revisionMediaRef struct {
+	id  uint
+	url string
+}
+
+// revisionMediaRefs lists the uploads stored in a revision snapshot. The
+// media ID is zero for snapshots taken before IDs were tracked.
+func revisionMediaRefs(rev *models.Revision) []revisionMediaRef {
+	var refs []revisionMediaRef
+	add := func(id uint, url string) {
+		if id != 0 || url != "" {
+			refs = append(refs, revisionMediaRef{id: id, url: url})
+		}
+	}
+	switch rev.EntityType {
+	case RevisionService:
+		var snap models.Service
+		if json.Unmarshal(rev.Snapshot, &snap) == nil {
+			add(snap.ThumbnailMediaID, snap.Thumbnail)
+			if len(snap.GalleryMediaIDs) > 0 {
+				for _, id := range snap.GalleryMediaIDs {
+					add(id, "")
+				}
+			} else {
+				for _, url := range snap.GalleryImages {
+					add(0, url)
+				}
+			}
+		}
+	case RevisionCategory:
+		var snap models.Category
+		if json.Unmarshal(rev.Snapshot, &snap) == nil {
+			add(snap.CoverMediaID, snap.CoverImage)
+		}
+	case RevisionGallery:
+		var snap models.GalleryItem
+		if json.Unmarshal(rev.Snapshot, &snap) == nil {
+			add(snap.ThumbnailMediaID, snap.Thumbnail)
+			for _, asset := range snap.Assets {
+				add(asset.MediaID, asset.URL)
+			}
+		}
+	case RevisionCaseStudy:
+		var snap models.CaseStudy
+		if json.Unmarshal(rev.Snapshot, &snap) == nil {
+			add(snap.CoverMediaID, snap.CoverImage)
+			for _, pair := range snap.BeforeAfter {
+				add(pair.BeforeMediaID, pair.Before)
+				add(pair.AfterMediaID, pair.After)
+			}
+		}
+	}
+	return refs
+}
+
+func (s *Store) mediaWithReferencesLocked(item *models.MediaItem, refs map[uint][]models.MediaReference) models.MediaItem {
+	clone := cloneMediaItem(item)
+	clone.References = append([]models.MediaReference{}, refs[item.ID]...)
+	return clone
+}
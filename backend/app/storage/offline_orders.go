@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	OrderSourceAdmin     = "admin"
+	defaultOrderCurrency = "IDR"
+)
+
+var ErrOrderAlreadyPaid = errors.New("order is already paid")
+
+var offlinePaymentMethods = map[string]string{
+	"cash":     "offline_cash",
+	"transfer": "offline_transfer",
+}
+
+func OfflinePaymentMethod(kind string) (string, bool) {
+	method, ok := offlinePaymentMethods[strings.ToLower(strings.TrimSpace(kind))]
+	return method, ok
+}
+
+// RecordOfflinePayment marks an unpaid order as paid in cash or by manual
+// transfer. The check and the write share one lock so concurrent calls cannot
+// both record a payment.
+func (s *Store) RecordOfflinePayment(orderID uint, method, reference string) (*models.PaymentTransaction, *models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var order *models.Order
+	for _, o := range s.data.Orders {
+		if o.ID == orderID {
+			order = o
+			break
+		}
+	}
+	if order == nil {
+		return nil, nil, os.ErrNotExist
+	}
+	if isPaymentCompletedStatus(order.PaymentStatus) {
+		return nil, nil, ErrOrderAlreadyPaid
+	}
+	return s.createPaymentTransactionLocked(&models.PaymentTransaction{
+		OrderID:   order.ID,
+		Method:    method,
+		Status:    "PAID",
+		Amount:    order.Amount,
+		Currency:  s.orderCurrencyLocked(order.ID),
+		Reference: strings.TrimSpace(reference),
+	})
+}
+
+// orderCurrencyLocked returns the currency the order was billed in, taken
+// from its most recent transaction that records one.
+func (s *Store) orderCurrencyLocked(orderID uint) string {
+	var latest *models.PaymentTransaction
+	for _, tx := range s.data.PaymentTransactions {
+		if tx.OrderID != orderID || strings.TrimSpace(tx.Currency) == "" {
+			continue
+		}
+		if latest == nil || tx.CreatedAt.After(latest.CreatedAt) {
+			latest = tx
+		}
+	}
+	if latest == nil {
+		return defaultOrderCurrency
+	}
+	return strings.ToUpper(strings.TrimSpace(latest.Currency))
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"sort"
+
+	"devara-creative-backend/app/models"
+)
+
+type OrderRecord struct {
+	Order             models.Order
+	LatestTransaction *models.PaymentTransaction
+}
+
+func (s *Store) ListOrderRecords() []OrderRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	latest := make(map[uint]*models.PaymentTransaction, len(s.data.Orders))
+	for _, tx := range s.data.PaymentTransactions {
+		if current, ok := latest[tx.OrderID]; !ok || tx.CreatedAt.After(current.CreatedAt) {
+			latest[tx.OrderID] = tx
+		}
+	}
+	out := make([]OrderRecord, 0, len(s.data.Orders))
+	for _, o := range s.data.Orders {
+		out = append(out, OrderRecord{
+			Order:             *o,
+			LatestTransaction: clonePaymentTransaction(latest[o.ID]),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Order.CreatedAt.After(out[j].Order.CreatedAt) })
+	return out
+}
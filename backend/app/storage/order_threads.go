@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+type OrderThreadSummary struct {
+	OrderID       uint                 `json:"order_id"`
+	CustomerName  string               `json:"customer_name"`
+	CustomerEmail string               `json:"customer_email"`
+	ServiceTitle  string               `json:"service_title"`
+	MessageCount  int                  `json:"message_count"`
+	UnreadCount   int                  `json:"unread_count"`
+	LastMessage   *models.OrderMessage `json:"last_message,omitempty"`
+}
+
+func cloneOrderMessage(src *models.OrderMessage) models.OrderMessage {
+	clone := *src
+	if len(src.Attachments) > 0 {
+		clone.Attachments = append([]models.OrderMessageAttachment(nil), src.Attachments...)
+	} else {
+		clone.Attachments = nil
+	}
+	if src.ReadByCustomerAt != nil {
+		at := *src.ReadByCustomerAt
+		clone.ReadByCustomerAt = &at
+	}
+	if src.ReadByAdminAt != nil {
+		at := *src.ReadByAdminAt
+		clone.ReadByAdminAt = &at
+	}
+	return clone
+}
+
+func orderMessageUnreadFor(msg *models.OrderMessage, readerRole string) bool {
+	switch readerRole {
+	case "admin":
+		return msg.AuthorRole != "admin" && msg.ReadByAdminAt == nil
+	case "customer":
+		return msg.AuthorRole != "customer" && msg.ReadByCustomerAt == nil
+	}
+	return false
+}
+
+func (s *Store) CreateOrderMessage(msg *models.OrderMessage) (*models.OrderMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var order *models.Order
+	for _, o := range s.data.Orders {
+		if o.ID == msg.OrderID {
+			order = o
+			break
+		}
+	}
+	if order == nil {
+		return nil, os.ErrNotExist
+	}
+	msg.ID = s.nextID("order_message")
+	now := time.Now().UTC()
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+	if msg.Source == "" {
+		msg.Source = "web"
+	}
+	switch msg.AuthorRole {
+	case "admin":
+		msg.ReadByAdminAt = &now
+	case "customer":
+		msg.ReadByCustomerAt = &now
+	}
+	clone := cloneOrderMessage(msg)
+	s.data.OrderMessages = append(s.data.OrderMessages, &clone)
+	title := fmt.Sprintf("Pesan baru untuk order #%d", order.ID)
+	if msg.AuthorRole == "admin" {
+		title = fmt.Sprintf("Balasan admin untuk order #%d", order.ID)
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "order_message",
+		Action:      "created",
+		Title:       title,
+		Description: msg.AuthorName,
+		ReferenceID: order.ID,
+		Metadata: map[string]string{
+			"author_role":    msg.AuthorRole,
+			"source":         msg.Source,
+			"service_title":  s.serviceTitleLocked(order.ServiceID),
+			"highlight_type": "order_message",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := cloneOrderMessage(&clone)
+	return &out, nil
+}
+
+func (s *Store) ListOrderMessages(orderID uint) []models.OrderMessage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.OrderMessage, 0)
+	for _, m := range s.data.OrderMessages {
+		if m.OrderID == orderID {
+			out = append(out, cloneOrderMessage(m))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+func (s *Store) MarkOrderMessagesRead(orderID uint, readerRole string, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	updated := 0
+	for _, m := range s.data.OrderMessages {
+		if m.OrderID != orderID || !orderMessageUnreadFor(m, readerRole) {
+			continue
+		}
+		switch readerRole {
+		case "admin":
+			readAt := now
+			m.ReadByAdminAt = &readAt
+		case "customer":
+			readAt := now
+			m.ReadByCustomerAt = &readAt
+		}
+		updated++
+	}
+	if updated == 0 {
+		return 0, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
+
+func (s *Store) CountUnreadOrderMessages(orderID uint, readerRole string) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	count := 0
+	for _, m := range s.data.OrderMessages {
+		if m.OrderID == orderID && orderMessageUnreadFor(m, readerRole) {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Store) ListOrderThreads(readerRole string, email string) []OrderThreadSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	email = strings.ToLower(strings.TrimSpace(email))
+	orders := make(map[uint]*models.Order, len(s.data.Orders))
+	for _, o := range s.data.Orders {
+		if email != "" && strings.ToLower(strings.TrimSpace(o.CustomerEmail)) != email {
+			continue
+		}
+		orders[o.ID] = o
+	}
+	threads := make(map[uint]*OrderThreadSummary)
+	for _, m := range s.data.OrderMessages {
+		order, ok := orders[m.OrderID]
+		if !ok {
+			continue
+		}
+		summary, ok := threads[m.OrderID]
+		if !ok {
+			summary = &OrderThreadSummary{
+				OrderID:       order.ID,
+				CustomerName:  order.CustomerName,
+				CustomerEmail: order.CustomerEmail,
+				ServiceTitle:  s.serviceTitleLocked(order.ServiceID),
+			}
+			threads[m.OrderID] = summary
+		}
+		summary.MessageCount++
+		if orderMessageUnreadFor(m, readerRole) {
+			summary.UnreadCount++
+		}
+		if summary.LastMessage == nil || !m.CreatedAt.Before(summary.LastMessage.CreatedAt) {
+			last := cloneOrderMessage(m)
+			summary.LastMessage = &last
+		}
+	}
+	out := make([]OrderThreadSummary, 0, len(threads))
+	for _, summary := range threads {
+		out = append(out, *summary)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastMessage.CreatedAt.After(out[j].LastMessage.CreatedAt)
+	})
+	return out
+}
+
+func (s *Store) deleteOrderMessagesLocked(orderID uint) {
+	filtered := s.data.OrderMessages[:0]
+	for _, m := range s.data.OrderMessages {
+		if m.OrderID != orderID {
+			filtered = append(filtered, m)
+		}
+	}
+	s.data.OrderMessages = filtered
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrPackageNotFound  = errors.New("package not found")
+	ErrAddOnUnavailable = errors.New("add-on is not available for the selected package")
+)
+
+type ServiceSelection struct {
+	Package   *models.ServicePackage
+	AddOns    []models.AddOn
+	LineItems []models.QuoteLineItem
+	Amount    float64
+}
+
+func NormalizeServicePackages(raw []models.ServicePackage) ([]models.ServicePackage, error) {
+	out := make([]models.ServicePackage, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, pkg := range raw {
+		pkg.Name = strings.TrimSpace(pkg.Name)
+		pkg.Description = strings.TrimSpace(pkg.Description)
+		if pkg.Name == "" {
+			return nil, errors.New("package name is required")
+		}
+		pkg.Key = slugify(pkg.Key)
+		if pkg.Key == "" {
+			pkg.Key = slugify(pkg.Name)
+		}
+		if _, dup := seen[pkg.Key]; dup {
+			return nil, fmt.Errorf("duplicate package %q", pkg.Key)
+		}
+		seen[pkg.Key] = struct{}{}
+		if pkg.Price < 0 {
+			return nil, fmt.Errorf("package %q price cannot be negative", pkg.Name)
+		}
+		if pkg.DeliveryDays < 0 {
+			pkg.DeliveryDays = 0
+		}
+		if pkg.Revisions < -1 {
+			pkg.Revisions = -1
+		}
+		if pkg.ProofPicks < 0 {
+			pkg.ProofPicks = 0
+		}
+		features := make([]string, 0, len(pkg.Features))
+		for _, f := range pkg.Features {
+			if f = strings.TrimSpace(f); f != "" {
+				features = append(features, f)
+			}
+		}
+		pkg.Features = features
+		addOns := make([]string, 0, len(pkg.AddOns))
+		for _, name := range pkg.AddOns {
+			if name = strings.TrimSpace(name); name != "" {
+				addOns = append(addOns, name)
+			}
+		}
+		pkg.AddOns = addOns
+		out = append(out, pkg)
+	}
+	return out, nil
+}
+
+func cloneServicePackage(pkg models.ServicePackage) models.ServicePackage {
+	pkg.Features = append([]string(nil), pkg.Features...)
+	pkg.AddOns = append([]string(nil), pkg.AddOns...)
+	return pkg
+}
+
+func cloneServicePackages(pkgs []models.ServicePackage) []models.ServicePackage {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	out := make([]models.ServicePackage, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		out = append(out, cloneServicePackage(pkg))
+	}
+	return out
+}
+
+func findAddOn(addOns []models.AddOn, name string) (models.AddOn, bool) {
+	name = strings.TrimSpace(name)
+	for _, addOn := range addOns {
+		if strings.EqualFold(strings.TrimSpace(addOn.Name), name) {
+			return addOn, true
+		}
+	}
+	return models.AddOn{}, false
+}
+
+func applyServicePackages(svc *models.Service) {
+	if len(svc.Packages) == 0 {
+		svc.Packages = nil
+		return
+	}
+	for i := range svc.Packages {
+		available := svc.Packages[i].AddOns[:0]
+		for _, name := range svc.Packages[i].AddOns {
+			if addOn, ok := findAddOn(svc.AddOns, name); ok {
+				available = append(available, addOn.Name)
+			}
+		}
+		svc.Packages[i].AddOns = available
+	}
+}
+
+// ServiceStartingPrice is the "from" price shown for a service: its cheapest
+// package, or the base price when it has none. The base price is kept as
+// entered by the admin.
+func ServiceStartingPrice(svc *models.Service) float64 {
+	if len(svc.Packages) == 0 {
+		return svc.Price
+	}
+	price := svc.Packages[0].Price
+	for _, pkg := range svc.Packages[1:] {
+		price = min(price, pkg.Price)
+	}
+	return price
+}
+
+// defaultServicePackage picks the package used when checkout does not name
+// one: the recommended package, or the first.
+func defaultServicePackage(svc *models.Service) string {
+	for _, pkg := range svc.Packages {
+		if pkg.Recommended {
+			return pkg.Key
+		}
+	}
+	return svc.Packages[0].Key
+}
+
+func FindServicePackage(svc *models.Service, key string) (*models.ServicePackage, bool) {
+	key = slugify(key)
+	for _, pkg := range svc.Packages {
+		if pkg.Key == key {
+			clone := cloneServicePackage(pkg)
+			return &clone, true
+		}
+	}
+	return nil, false
+}
+
+func ResolveServiceSelection(svc *models.Service, packageKey string, addOnNames []string) (*ServiceSelection, error) {
+	sel := &ServiceSelection{Amount: svc.Price}
+	allowed := svc.AddOns
+	if len(svc.Packages) > 0 {
+		if strings.TrimSpace(packageKey) == "" {
+			packageKey = defaultServicePackage(svc)
+		}
+		pkg, ok := FindServicePackage(svc, packageKey)
+		if !ok {
+			return nil, ErrPackageNotFound
+		}
+		sel.Package = pkg
+		sel.Amount = pkg.Price
+		allowed = nil
+		for _, name := range pkg.AddOns {
+			if addOn, ok := findAddOn(svc.AddOns, name); ok {
+				allowed = append(allowed, addOn)
+			}
+		}
+	}
+	if sel.Package == nil && len(addOnNames) == 0 {
+		return sel, nil
+	}
+	title := svc.Title
+	if sel.Package != nil {
+		title = fmt.Sprintf("%s • %s", svc.Title, sel.Package.Name)
+	}
+	sel.LineItems = append(sel.LineItems, models.QuoteLineItem{Title: title, Quantity: 1, UnitPrice: sel.Amount, Amount: sel.Amount})
+	picked := make(map[string]struct{}, len(addOnNames))
+	for _, name := range addOnNames {
+		addOn, ok := findAddOn(allowed, name)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrAddOnUnavailable, strings.TrimSpace(name))
+		}
+		if _, dup := picked[addOn.Name]; dup {
+			continue
+		}
+		picked[addOn.Name] = struct{}{}
+		sel.AddOns = append(sel.AddOns, addOn)
+		sel.LineItems = append(sel.LineItems, models.QuoteLineItem{Title: addOn.Name, Quantity: 1, UnitPrice: addOn.Price, Amount: addOn.Price})
+		sel.Amount += addOn.Price
+	}
+	sel.Amount = roundCurrency(sel.Amount)
+	return sel, nil
+}
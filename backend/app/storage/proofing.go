@@ -0,0 +1,430 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	ProofingOpen      = "open"
+	ProofingSubmitted = "submitted"
+	ProofingClosed    = "closed"
+)
+
+var (
+	ErrProofingNotOpen        = errors.New("proofing gallery is not open for selection")
+	ErrProofingExpired        = errors.New("proofing gallery has expired")
+	ErrProofingLimitReached   = errors.New("selection limit reached")
+	ErrProofingEmptySelection = errors.New("select at least one image before submitting")
+	ErrProofingOrderNotFound  = errors.New("order not found")
+)
+
+type ProofingGalleryUpdate struct {
+	Title          *string
+	Message        *string
+	Status         *string
+	SelectionLimit *int
+	PasswordHash   *string
+	ExpiresAt      *time.Time
+}
+
+type ProofingMark struct {
+	Favorite *bool
+	Selected *bool
+}
+
+func cloneProofingGallery(src *models.ProofingGallery) models.ProofingGallery {
+	clone := *src
+	clone.Images = make([]models.ProofingImage, len(src.Images))
+	for i, img := range src.Images {
+		img.Comments = append([]models.ProofingComment(nil), img.Comments...)
+		clone.Images[i] = img
+	}
+	clone.PasswordProtected = src.PasswordHash != ""
+	return clone
+}
+
+func (s *Store) findProofingGalleryLocked(id uint) *models.ProofingGallery {
+	for _, g := range s.data.ProofingGalleries {
+		if g.ID == id {
+			return g
+		}
+	}
+	return nil
+}
+
+func findProofingImage(g *models.ProofingGallery, imageID uint) *models.ProofingImage {
+	for i := range g.Images {
+		if g.Images[i].ID == imageID {
+			return &g.Images[i]
+		}
+	}
+	return nil
+}
+
+func proofingSelectionCount(g *models.ProofingGallery) int {
+	count := 0
+	for _, img := range g.Images {
+		if img.Selected {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Store) findOrderLocked(id uint) *models.Order {
+	for _, o := range s.data.Orders {
+		if o.ID == id {
+			return o
+		}
+	}
+	return nil
+}
+
+func (s *Store) ListProofingGalleries(orderID uint) []models.ProofingGallery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.ProofingGallery, 0)
+	for _, g := range s.data.ProofingGalleries {
+		if orderID != 0 && g.OrderID != orderID {
+			continue
+		}
+		out = append(out, cloneProofingGallery(g))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) GetProofingGallery(id uint) (*models.ProofingGallery, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, false
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, true
+}
+
+// CreateProofingGallery opens a gallery for an order. A zero selection limit
+// falls back to the proof picks of the ordered package.
+func (s *Store) CreateProofingGallery(g *models.ProofingGallery) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	order := s.findOrderLocked(g.OrderID)
+	if order == nil {
+		return nil, ErrProofingOrderNotFound
+	}
+	now := time.Now().UTC()
+	g.ID = s.nextID("proofing_gallery")
+	g.Title = strings.TrimSpace(g.Title)
+	if g.Title == "" {
+		g.Title = fmt.Sprintf("Proofing Order #%d", order.ID)
+	}
+	g.Message = strings.TrimSpace(g.Message)
+	if g.SelectionLimit <= 0 && order.Package != nil {
+		g.SelectionLimit = order.Package.ProofPicks
+	}
+	g.SelectionLimit = max(g.SelectionLimit, 0)
+	g.Status = ProofingOpen
+	g.Images = []models.ProofingImage{}
+	g.CreatedAt = now
+	g.UpdatedAt = now
+	clone := cloneProofingGallery(g)
+	s.data.ProofingGalleries = append(s.data.ProofingGalleries, &clone)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "proofing",
+		Action:      "created",
+		Title:       fmt.Sprintf("Galeri proofing order #%d dibuat", order.ID),
+		Description: g.Title,
+		ReferenceID: g.ID,
+		Metadata: map[string]string{
+			"order_id":        fmt.Sprintf("%d", order.ID),
+			"selection_limit": fmt.Sprintf("%d", g.SelectionLimit),
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := cloneProofingGallery(&clone)
+	return &out, nil
+}
+
+func (s *Store) UpdateProofingGallery(id uint, update ProofingGalleryUpdate) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, os.ErrNotExist
+	}
+	if update.Status != nil {
+		switch *update.Status {
+		case ProofingOpen, ProofingSubmitted, ProofingClosed:
+		default:
+			return nil, fmt.Errorf("invalid proofing status %q", *update.Status)
+		}
+	}
+	next := cloneProofingGallery(g)
+	if update.Title != nil && strings.TrimSpace(*update.Title) != "" {
+		next.Title = strings.TrimSpace(*update.Title)
+	}
+	if update.Message != nil {
+		next.Message = strings.TrimSpace(*update.Message)
+	}
+	if update.SelectionLimit != nil {
+		next.SelectionLimit = max(*update.SelectionLimit, 0)
+	}
+	if update.PasswordHash != nil {
+		next.PasswordHash = *update.PasswordHash
+	}
+	if update.ExpiresAt != nil {
+		next.ExpiresAt = *update.ExpiresAt
+	}
+	if update.Status != nil {
+		if *update.Status == ProofingOpen {
+			next.SubmittedAt = time.Time{}
+		}
+		next.Status = *update.Status
+	}
+	next.UpdatedAt = time.Now().UTC()
+	*g = next
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, nil
+}
+
+func (s *Store) MarkProofingGallerySent(id uint, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return os.ErrNotExist
+	}
+	g.SentAt = now
+	return s.persistLocked()
+}
+
+func (s *Store) DeleteProofingGallery(id uint) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	filtered := s.data.ProofingGalleries[:0]
+	var deleted *models.ProofingGallery
+	for _, g := range s.data.ProofingGalleries {
+		if g.ID == id {
+			clone := cloneProofingGallery(g)
+			deleted = &clone
+			continue
+		}
+		filtered = append(filtered, g)
+	}
+	if deleted == nil {
+		return nil, os.ErrNotExist
+	}
+	s.data.ProofingGalleries = filtered
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func (s *Store) AddProofingImages(id uint, images []models.ProofingImage) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, os.ErrNotExist
+	}
+	now := time.Now().UTC()
+	for _, img := range images {
+		img.ID = s.nextID("proofing_image")
+		img.Favorite, img.Selected = false, false
+		img.Comments = nil
+		img.CreatedAt = now
+		g.Images = append(g.Images, img)
+	}
+	g.UpdatedAt = now
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, nil
+}
+
+func (s *Store) RemoveProofingImage(id, imageID uint) (*models.ProofingImage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, os.ErrNotExist
+	}
+	for i, img := range g.Images {
+		if img.ID != imageID {
+			continue
+		}
+		g.Images = append(g.Images[:i], g.Images[i+1:]...)
+		g.UpdatedAt = time.Now().UTC()
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		return &img, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) openProofingGalleryLocked(id uint, now time.Time) (*models.ProofingGallery, error) {
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, os.ErrNotExist
+	}
+	if !g.ExpiresAt.IsZero() && now.After(g.ExpiresAt) {
+		return nil, ErrProofingExpired
+	}
+	if g.Status != ProofingOpen {
+		return nil, ErrProofingNotOpen
+	}
+	return g, nil
+}
+
+// MarkProofingImage toggles the client's favourite and selection flags.
+// Selecting beyond the gallery limit fails with ErrProofingLimitReached.
+func (s *Store) MarkProofingImage(id, imageID uint, mark ProofingMark, now time.Time) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g, err := s.openProofingGalleryLocked(id, now)
+	if err != nil {
+		return nil, err
+	}
+	img := findProofingImage(g, imageID)
+	if img == nil {
+		return nil, os.ErrNotExist
+	}
+	if mark.Selected != nil && *mark.Selected && !img.Selected && g.SelectionLimit > 0 && proofingSelectionCount(g) >= g.SelectionLimit {
+		return nil, ErrProofingLimitReached
+	}
+	if mark.Favorite != nil {
+		img.Favorite = *mark.Favorite
+	}
+	if mark.Selected != nil {
+		img.Selected = *mark.Selected
+	}
+	g.UpdatedAt = now
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, nil
+}
+
+func (s *Store) AddProofingComment(id, imageID uint, comment models.ProofingComment) (*models.ProofingGallery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g := s.findProofingGalleryLocked(id)
+	if g == nil {
+		return nil, os.ErrNotExist
+	}
+	if !comment.FromStudio {
+		if !g.ExpiresAt.IsZero() && comment.CreatedAt.After(g.ExpiresAt) {
+			return nil, ErrProofingExpired
+		}
+		if g.Status == ProofingClosed {
+			return nil, ErrProofingNotOpen
+		}
+	}
+	img := findProofingImage(g, imageID)
+	if img == nil {
+		return nil, os.ErrNotExist
+	}
+	img.Comments = append(img.Comments, comment)
+	g.UpdatedAt = comment.CreatedAt
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, nil
+}
+
+// SubmitProofingSelection locks the client's selection and moves a paid or
+// confirmed order into production.
+func (s *Store) SubmitProofingSelection(id uint, note string, now time.Time) (*models.ProofingGallery, *models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	g, err := s.openProofingGalleryLocked(id, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	selected := proofingSelectionCount(g)
+	if selected == 0 {
+		return nil, nil, ErrProofingEmptySelection
+	}
+	if g.SelectionLimit > 0 && selected > g.SelectionLimit {
+		return nil, nil, ErrProofingLimitReached
+	}
+	g.Status = ProofingSubmitted
+	g.SubmissionNote = strings.TrimSpace(note)
+	g.SubmittedAt = now
+	g.UpdatedAt = now
+	var orderClone *models.Order
+	if order := s.findOrderLocked(g.OrderID); order != nil {
+		switch strings.ToLower(order.Status) {
+		case "paid", "confirmed":
+			prevStatus := order.Status
+			order.Status = "in_progress"
+			order.UpdatedAt = now
+			s.appendActivityLocked(&models.Activity{
+				Type:        "order",
+				Action:      "status_changed",
+				Title:       fmt.Sprintf("Status order #%d", order.ID),
+				Description: fmt.Sprintf("Dari %s ke %s", formatStatus(prevStatus), formatStatus(order.Status)),
+				ReferenceID: order.ID,
+				Metadata: map[string]string{
+					"status":          order.Status,
+					"status_label":    formatStatus(order.Status),
+					"previous_status": prevStatus,
+					"previous_label":  formatStatus(prevStatus),
+					"service_title":   s.serviceTitleLocked(order.ServiceID),
+					"service_id":      fmt.Sprintf("%d", order.ServiceID),
+					"highlight_type":  "order_status",
+					"update_category": "proofing",
+				},
+			})
+		}
+		clone := *order
+		orderClone = &clone
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "proofing",
+		Action:      "submitted",
+		Title:       fmt.Sprintf("Klien memilih %d foto untuk order #%d", selected, g.OrderID),
+		Description: g.Title,
+		ReferenceID: g.ID,
+		Metadata: map[string]string{
+			"order_id":       fmt.Sprintf("%d", g.OrderID),
+			"selected":       fmt.Sprintf("%d", selected),
+			"highlight_type": "proofing_submitted",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, nil, err
+	}
+	clone := cloneProofingGallery(g)
+	return &clone, orderClone, nil
+}
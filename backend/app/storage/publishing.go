@@ -0,0 +1,226 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	PublishDraft     = "draft"
+	PublishScheduled = "scheduled"
+	PublishPublished = "published"
+	PublishArchived  = "archived"
+)
+
+var ErrServiceHasOrders = errors.New("service is referenced by orders")
+
+func IsLive(p models.Publishing, now time.Time) bool {
+	switch p.PublishStatus {
+	case "", PublishPublished:
+	case PublishScheduled:
+		if p.PublishAt.IsZero() {
+			return false
+		}
+	default:
+		return false
+	}
+	if !p.PublishAt.IsZero() && now.Before(p.PublishAt) {
+		return false
+	}
+	if !p.UnpublishAt.IsZero() && !now.Before(p.UnpublishAt) {
+		return false
+	}
+	return true
+}
+
+func EffectivePublishStatus(p models.Publishing) string {
+	if p.PublishStatus == "" {
+		return PublishPublished
+	}
+	return p.PublishStatus
+}
+
+func NormalizePublishing(p models.Publishing) (models.Publishing, error) {
+	p.PublishStatus = strings.ToLower(strings.TrimSpace(p.PublishStatus))
+	switch p.PublishStatus {
+	case "":
+		p.PublishStatus = PublishDraft
+	case PublishDraft, PublishPublished, PublishArchived:
+	case PublishScheduled:
+		if p.PublishAt.IsZero() {
+			return p, errors.New("publish_at is required for scheduled content")
+		}
+	default:
+		return p, fmt.Errorf("unsupported publish status %q", p.PublishStatus)
+	}
+	if !p.PublishAt.IsZero() && !p.UnpublishAt.IsZero() && !p.UnpublishAt.After(p.PublishAt) {
+		return p, errors.New("unpublish_at must be after publish_at")
+	}
+	p.PublishAt = p.PublishAt.UTC()
+	p.UnpublishAt = p.UnpublishAt.UTC()
+	p.ArchivedAt = time.Time{}
+	return p, nil
+}
+
+func applyPublishing(target *models.Publishing, next models.Publishing, now time.Time) {
+	archivedAt := target.ArchivedAt
+	*target = next
+	if next.PublishStatus == PublishArchived {
+		target.ArchivedAt = archivedAt
+		if target.ArchivedAt.IsZero() {
+			target.ArchivedAt = now
+		}
+	}
+}
+
+func publishingActivity(kind, title string, id uint, p models.Publishing) *models.Activity {
+	labels := map[string]string{
+		PublishDraft:     "disimpan sebagai draf",
+		PublishScheduled: "dijadwalkan",
+		PublishPublished: "dipublikasikan",
+		PublishArchived:  "diarsipkan",
+	}
+	desc := ""
+	if p.PublishStatus == PublishScheduled {
+		desc = fmt.Sprintf("Tayang %s", p.PublishAt.Format(time.RFC3339))
+	}
+	return &models.Activity{
+		Type:        kind,
+		Action:      p.PublishStatus,
+		Title:       fmt.Sprintf("\"%s\" %s", title, labels[p.PublishStatus]),
+		Description: desc,
+		ReferenceID: id,
+		Metadata: map[string]string{
+			"title":          title,
+			"publish_status": p.PublishStatus,
+			"highlight_type": "publishing",
+		},
+	}
+}
+
+func (s *Store) SetServicePublishing(id uint, p models.Publishing, author string) (*models.Service, error) {
+	p, err := NormalizePublishing(p)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, svc := range s.data.Services {
+		if svc.ID != id {
+			continue
+		}
+		prev := cloneService(svc)
+		applyPublishing(&svc.Publishing, p, time.Now().UTC())
+		s.recordRevisionLocked(RevisionService, svc.ID, svc.PublishStatus, author, &prev, svc)
+		s.indexServiceLocked(svc)
+		s.appendActivityLocked(publishingActivity("service", svc.Title, svc.ID, svc.Publishing))
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		clone := cloneService(svc)
+		return &clone, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) SetGalleryItemPublishing(id uint, p models.Publishing, author string) (*models.GalleryItem, error) {
+	p, err := NormalizePublishing(p)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, item := range s.data.GalleryItems {
+		if item.ID != id {
+			continue
+		}
+		prev := cloneGalleryItem(item)
+		now := time.Now().UTC()
+		applyPublishing(&item.Publishing, p, now)
+		item.UpdatedAt = now
+		s.recordRevisionLocked(RevisionGallery, item.ID, item.PublishStatus, author, &prev, item)
+		s.indexGalleryItemLocked(item)
+		s.appendActivityLocked(publishingActivity("gallery", item.Title, item.ID, item.Publishing))
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		clone := cloneGalleryItem(item)
+		return &clone, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) SetExperiencePublishing(id uint, p models.Publishing, author string) (*models.Experience, error) {
+	p, err := NormalizePublishing(p)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, exp := range s.data.Experiences {
+		if exp.ID != id {
+			continue
+		}
+		prev := cloneExperience(exp)
+		now := time.Now().UTC()
+		applyPublishing(&exp.Publishing, p, now)
+		exp.UpdatedAt = now
+		s.recordRevisionLocked(RevisionExperience, exp.ID, exp.PublishStatus, author, &prev, exp)
+		s.indexExperienceLocked(exp)
+		s.appendActivityLocked(publishingActivity("experience", exp.Title, exp.ID, exp.Publishing))
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		clone := cloneExperience(exp)
+		return &clone, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) SetCaseStudyPublishing(id uint, p models.Publishing, author string) (*models.CaseStudy, error) {
+	p, err := NormalizePublishing(p)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	cs := s.findCaseStudyLocked(id)
+	if cs == nil {
+		return nil, os.ErrNotExist
+	}
+	prev := cloneCaseStudy(cs)
+	now := time.Now().UTC()
+	applyPublishing(&cs.Publishing, p, now)
+	cs.UpdatedAt = now
+	s.recordRevisionLocked(RevisionCaseStudy, cs.ID, cs.PublishStatus, author, &prev, cs)
+	s.indexCaseStudyLocked(cs)
+	s.appendActivityLocked(publishingActivity("case_study", cs.Title, cs.ID, cs.Publishing))
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneCaseStudy(cs)
+	return &clone, nil
+}
+
+func (s *Store) serviceHasOrdersLocked(id uint) bool {
+	for _, o := range s.data.Orders {
+		if o.ServiceID == id {
+			return true
+		}
+	}
+	for _, q := range s.data.QuoteRequests {
+		if q.ServiceID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+var (
+	ErrQuoteNotOffered    = errors.New("quote has no open offer")
+	ErrQuoteOfferExpired  = errors.New("quote offer expired")
+	ErrQuoteAlreadyClosed = errors.New("quote already closed")
+	ErrQuoteServiceNeeded = errors.New("quote offer requires a service")
+	ErrQuoteSlotRequired  = errors.New("quote service requires a booking slot")
+)
+
+type QuoteOfferInput struct {
+	ServiceID uint
+	Items     []models.QuoteLineItem
+	Amount    float64
+	Terms     string
+	Notes     string
+	ExpiresAt time.Time
+}
+
+func cloneQuoteRequest(src *models.QuoteRequest) models.QuoteRequest {
+	clone := *src
+	if len(src.OfferItems) > 0 {
+		clone.OfferItems = append([]models.QuoteLineItem(nil), src.OfferItems...)
+	} else {
+		clone.OfferItems = nil
+	}
+	return clone
+}
+
+func (s *Store) findQuoteRequestLocked(id uint) *models.QuoteRequest {
+	for _, q := range s.data.QuoteRequests {
+		if q.ID == id {
+			return q
+		}
+	}
+	return nil
+}
+
+func (s *Store) quoteSubjectLocked(q *models.QuoteRequest) string {
+	if title := s.serviceTitleLocked(q.ServiceID); title != "" {
+		return title
+	}
+	return s.categoryNameLocked(q.CategoryID)
+}
+
+func (s *Store) expireQuoteLocked(q *models.QuoteRequest, now time.Time) bool {
+	if q.Status != "offered" || q.OfferExpiresAt.IsZero() || now.Before(q.OfferExpiresAt) {
+		return false
+	}
+	q.Status = "expired"
+	q.UpdatedAt = now
+	return true
+}
+
+func (s *Store) ListQuoteRequests(status string) []models.QuoteRequest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	status = strings.ToLower(strings.TrimSpace(status))
+	out := make([]models.QuoteRequest, 0, len(s.data.QuoteRequests))
+	for _, q := range s.data.QuoteRequests {
+		if status != "" && q.Status != status {
+			continue
+		}
+		out = append(out, cloneQuoteRequest(q))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) GetQuoteRequestByID(id uint) (*models.QuoteRequest, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	q := s.findQuoteRequestLocked(id)
+	if q == nil {
+		return nil, false
+	}
+	clone := cloneQuoteRequest(q)
+	return &clone, true
+}
+
+func (s *Store) CreateQuoteRequest(q *models.QuoteRequest) (*models.QuoteRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	now := time.Now().UTC()
+	q.ID = s.nextID("quote_request")
+	q.Status = "requested"
+	q.CreatedAt = now
+	q.UpdatedAt = now
+	clone := cloneQuoteRequest(q)
+	s.data.QuoteRequests = append(s.data.QuoteRequests, &clone)
+	subject := s.quoteSubjectLocked(q)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "quote",
+		Action:      "requested",
+		Title:       fmt.Sprintf("Permintaan penawaran dari %s", q.CustomerName),
+		Description: subject,
+		ReferenceID: q.ID,
+		Metadata: map[string]string{
+			"customer_email": q.CustomerEmail,
+			"subject":        subject,
+			"highlight_type": "quote_requested",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := cloneQuoteRequest(&clone)
+	return &out, nil
+}
+
+func (s *Store) SetQuoteOffer(id uint, input QuoteOfferInput) (*models.QuoteRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	q := s.findQuoteRequestLocked(id)
+	if q == nil {
+		return nil, os.ErrNotExist
+	}
+	switch q.Status {
+	case "accepted", "declined":
+		return nil, ErrQuoteAlreadyClosed
+	}
+	if input.ServiceID != 0 {
+		q.ServiceID = input.ServiceID
+	}
+	if q.ServiceID == 0 {
+		return nil, ErrQuoteServiceNeeded
+	}
+	now := time.Now().UTC()
+	q.OfferItems = append([]models.QuoteLineItem(nil), input.Items...)
+	q.OfferAmount = roundCurrency(input.Amount)
+	q.OfferTerms = input.Terms
+	q.OfferNotes = input.Notes
+	q.OfferExpiresAt = input.ExpiresAt
+	q.OfferedAt = now
+	q.Status = "offered"
+	q.UpdatedAt = now
+	s.appendActivityLocked(&models.Activity{
+		Type:        "quote",
+		Action:      "offered",
+		Title:       fmt.Sprintf("Penawaran #%d dikirim", q.ID),
+		Description: fmt.Sprintf("%s • %.2f", s.quoteSubjectLocked(q), q.OfferAmount),
+		ReferenceID: q.ID,
+		Metadata: map[string]string{
+			"customer_email": q.CustomerEmail,
+			"amount":         fmt.Sprintf("%.2f", q.OfferAmount),
+			"highlight_type": "quote_offered",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneQuoteRequest(q)
+	return &clone, nil
+}
+
+// AcceptQuoteOffer turns an open offer into a pending order. For bookable
+// services the order books bookingStart, falling back to the slot requested
+// with the quote, and the slot is checked under the same lock.
+func (s *Store) AcceptQuoteOffer(id uint, bookingStart, now time.Time) (*models.QuoteRequest, *models.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	q := s.findQuoteRequestLocked(id)
+	if q == nil {
+		return nil, nil, os.ErrNotExist
+	}
+	if s.expireQuoteLocked(q, now) {
+		if err := s.persistLocked(); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrQuoteOfferExpired
+	}
+	switch q.Status {
+	case "offered":
+	case "accepted", "declined":
+		return nil, nil, ErrQuoteAlreadyClosed
+	case "expired":
+		return nil, nil, ErrQuoteOfferExpired
+	default:
+		return nil, nil, ErrQuoteNotOffered
+	}
+	notes := fmt.Sprintf("Penawaran #%d", q.ID)
+	if strings.TrimSpace(q.Details) != "" {
+		notes += "\n" + q.Details
+	}
+	order := &models.Order{
+		ServiceID:     q.ServiceID,
+		CustomerName:  q.CustomerName,
+		CustomerEmail: q.CustomerEmail,
+		CustomerPhone: q.CustomerPhone,
+		Notes:         notes,
+		LineItems:     append([]models.QuoteLineItem(nil), q.OfferItems...),
+		Amount:        q.OfferAmount,
+		Status:        "pending",
+		QuoteID:       q.ID,
+	}
+	if isBookable(s.findServiceLocked(q.ServiceID)) {
+		if bookingStart.IsZero() {
+			bookingStart = q.BookingStart
+		}
+		if bookingStart.IsZero() {
+			return nil, nil, ErrQuoteSlotRequired
+		}
+		order.BookingStart = bookingStart.UTC()
+	}
+	if err := s.createOrderLocked(order, now); err != nil {
+		return nil, nil, err
+	}
+	q.BookingStart = order.BookingStart
+	q.Status = "accepted"
+	q.OrderID = order.ID
+	q.RespondedAt = now
+	q.UpdatedAt = now
+	s.appendActivityLocked(&models.Activity{
+		Type:        "quote",
+		Action:      "accepted",
+		Title:       fmt.Sprintf("Penawaran #%d diterima", q.ID),
+		Description: fmt.Sprintf("Order #%d dibuat dari penawaran", order.ID),
+		ReferenceID: q.ID,
+		Metadata: map[string]string{
+			"order_id":       fmt.Sprintf("%d", order.ID),
+			"customer_email": q.CustomerEmail,
+			"highlight_type": "quote_accepted",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, nil, err
+	}
+	clone := cloneQuoteRequest(q)
+	orderClone := *order
+	return &clone, &orderClone, nil
+}
+
+func (s *Store) RevertQuoteAcceptance(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	q := s.findQuoteRequestLocked(id)
+	if q == nil {
+		return os.ErrNotExist
+	}
+	if q.Status != "accepted" {
+		return nil
+	}
+	q.Status = "offered"
+	q.OrderID = 0
+	q.RespondedAt = time.Time{}
+	q.UpdatedAt = time.Now().UTC()
+	return s.persistLocked()
+}
+
+func (s *Store) DeclineQuoteOffer(id uint, reason string, now time.Time) (*models.QuoteRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	q := s.findQuoteRequestLocked(id)
+	if q == nil {
+		return nil, os.ErrNotExist
+	}
+	switch q.Status {
+	case "offered", "expired":
+	case "accepted", "declined":
+		return nil, ErrQuoteAlreadyClosed
+	default:
+		return nil, ErrQuoteNotOffered
+	}
+	q.Status = "declined"
+	q.DeclineReason = reason
+	q.RespondedAt = now
+	q.UpdatedAt = now
+	s.appendActivityLocked(&models.Activity{
+		Type:        "quote",
+		Action:      "declined",
+		Title:       fmt.Sprintf("Penawaran #%d ditolak", q.ID),
+		Description: reason,
+		ReferenceID: q.ID,
+		Metadata: map[string]string{
+			"customer_email": q.CustomerEmail,
+			"highlight_type": "quote_declined",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := cloneQuoteRequest(q)
+	return &clone, nil
+}
+
+func (s *Store) ExpireQuoteOffers(now time.Time) ([]models.QuoteRequest, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var expired []models.QuoteRequest
+	for _, q := range s.data.QuoteRequests {
+		if s.expireQuoteLocked(q, now) {
+			expired = append(expired, cloneQuoteRequest(q))
+		}
+	}
+	if len(expired) == 0 {
+		return nil, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+func (s *Store) DeleteQuoteRequest(id uint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	filtered := s.data.QuoteRequests[:0]
+	found := false
+	for _, q := range s.data.QuoteRequests {
+		if q.ID == id {
+			found = true
+			continue
+		}
+		filtered = append(filtered, q)
+	}
+	if !found {
+		return os.ErrNotExist
+	}
+	s.data.QuoteRequests = filtered
+	return s.persistLocked()
+}
@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusHidden   = "hidden"
+)
+
+const maxReviewDisplayName = 60
+
+type ReviewSubmission struct {
+	Rating      int
+	Comment     string
+	DisplayName string
+	Photos      []string
+}
+
+type ReviewModeration struct {
+	Status *string
+	Reply  *string
+}
+
+func IsReviewStatus(status string) bool {
+	switch status {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusHidden:
+		return true
+	}
+	return false
+}
+
+func reviewerDisplayName(name string) string {
+	parts := strings.Fields(name)
+	if len(parts) == 0 {
+		return "Pelanggan"
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	initial, _ := utf8.DecodeRuneInString(parts[len(parts)-1])
+	return fmt.Sprintf("%s %s.", parts[0], strings.ToUpper(string(initial)))
+}
+
+func cloneReview(r *models.Review) *models.Review {
+	clone := *r
+	if len(r.Photos) > 0 {
+		clone.Photos = append([]string(nil), r.Photos...)
+	}
+	return &clone
+}
+
+func migrateLegacyRatings(snap *snapshot) {
+	for _, o := range snap.Orders {
+		if o.RatingValue <= 0 {
+			continue
+		}
+		ratedAt := o.RatedAt
+		if ratedAt.IsZero() {
+			ratedAt = o.UpdatedAt
+		}
+		id := snap.NextIDs["review"]
+		snap.NextIDs["review"] = id + 1
+		snap.Reviews = append(snap.Reviews, &models.Review{
+			ID:          id,
+			OrderID:     o.ID,
+			ServiceID:   o.ServiceID,
+			Rating:      o.RatingValue,
+			Comment:     o.RatingReview,
+			DisplayName: reviewerDisplayName(o.CustomerName),
+			Status:      ReviewStatusApproved,
+			ModeratedAt: ratedAt,
+			CreatedAt:   ratedAt,
+			UpdatedAt:   ratedAt,
+		})
+	}
+}
+
+func (s *Store) SubmitOrderReview(orderID uint, sub ReviewSubmission) (*models.Order, *models.Review, error) {
+	if sub.Rating < 1 || sub.Rating > 5 {
+		return nil, nil, errors.New("rating must be between 1 and 5")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var o *models.Order
+	for _, candidate := range s.data.Orders {
+		if candidate.ID == orderID {
+			o = candidate
+			break
+		}
+	}
+	if o == nil {
+		return nil, nil, os.ErrNotExist
+	}
+	if o.Status != "done" {
+		return nil, nil, errors.New("order is not marked as done")
+	}
+	comment := strings.TrimSpace(sub.Comment)
+	now := time.Now().UTC()
+	o.RatingValue = sub.Rating
+	o.RatingReview = comment
+	o.RatedAt = now
+	o.UpdatedAt = now
+
+	displayName := strings.TrimSpace(sub.DisplayName)
+	if displayName == "" {
+		displayName = reviewerDisplayName(o.CustomerName)
+	}
+	if utf8.RuneCountInString(displayName) > maxReviewDisplayName {
+		displayName = string([]rune(displayName)[:maxReviewDisplayName])
+	}
+	var review *models.Review
+	for _, existing := range s.data.Reviews {
+		if existing.OrderID == o.ID {
+			review = existing
+			break
+		}
+	}
+	changed := review == nil ||
+		review.Rating != sub.Rating ||
+		review.Comment != comment ||
+		review.DisplayName != displayName ||
+		(sub.Photos != nil && !slices.Equal(review.Photos, sub.Photos))
+	if review == nil {
+		review = &models.Review{
+			ID:        s.nextID("review"),
+			OrderID:   o.ID,
+			ServiceID: o.ServiceID,
+			CreatedAt: now,
+		}
+		s.data.Reviews = append(s.data.Reviews, review)
+	}
+	review.Rating = sub.Rating
+	review.Comment = comment
+	review.DisplayName = displayName
+	if sub.Photos != nil {
+		review.Photos = append([]string(nil), sub.Photos...)
+	}
+	if changed {
+		review.Status = ReviewStatusPending
+	}
+	review.UpdatedAt = now
+
+	serviceTitle := s.serviceTitleLocked(o.ServiceID)
+	desc := fmt.Sprintf("Rating %d/5", sub.Rating)
+	if comment != "" {
+		desc = fmt.Sprintf("%s • \"%s\"", desc, comment)
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "order",
+		Action:      "rated",
+		Title:       fmt.Sprintf("Order #%d diberi rating", o.ID),
+		Description: desc,
+		ReferenceID: o.ID,
+		Metadata: map[string]string{
+			"rating":         fmt.Sprintf("%d", sub.Rating),
+			"review":         comment,
+			"review_id":      fmt.Sprintf("%d", review.ID),
+			"service_title":  serviceTitle,
+			"service_id":     fmt.Sprintf("%d", o.ServiceID),
+			"highlight_type": "order_feedback",
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, nil, err
+	}
+	clone := *o
+	return &clone, cloneReview(review), nil
+}
+
+func (s *Store) GetReviewByOrderID(orderID uint) (*models.Review, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	for _, r := range s.data.Reviews {
+		if r.OrderID == orderID {
+			return cloneReview(r), true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) ListReviews(status string, serviceID uint) []models.Review {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.Review, 0, len(s.data.Reviews))
+	for _, r := range s.data.Reviews {
+		if status != "" && r.Status != status {
+			continue
+		}
+		if serviceID != 0 && r.ServiceID != serviceID {
+			continue
+		}
+		out = append(out, *cloneReview(r))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+func (s *Store) ModerateReview(id uint, m ReviewModeration) (*models.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, r := range s.data.Reviews {
+		if r.ID != id {
+			continue
+		}
+		now := time.Now().UTC()
+		if m.Status != nil {
+			if !IsReviewStatus(*m.Status) {
+				return nil, errors.New("unsupported review status")
+			}
+			r.Status = *m.Status
+			r.ModeratedAt = now
+		}
+		if m.Reply != nil {
+			reply := strings.TrimSpace(*m.Reply)
+			r.Reply = reply
+			r.RepliedAt = time.Time{}
+			if reply != "" {
+				r.RepliedAt = now
+			}
+		}
+		r.UpdatedAt = now
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		return cloneReview(r), nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) DeleteReview(id uint) (*models.Review, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for i, r := range s.data.Reviews {
+		if r.ID != id {
+			continue
+		}
+		s.data.Reviews = append(s.data.Reviews[:i], s.data.Reviews[i+1:]...)
+		if err := s.persistLocked(); err != nil {
+			return nil, err
+		}
+		return r, nil
+	}
+	return nil, os.ErrNotExist
+}
+
+func (s *Store) deleteOrderReviewsLocked(orderID uint) {
+	filtered := s.data.Reviews[:0]
+	for _, r := range s.data.Reviews {
+		if r.OrderID != orderID {
+			filtered = append(filtered, r)
+		}
+	}
+	s.data.Reviews = filtered
+}
+
+func (s *Store) DueReviewRequests(completedBefore time.Time) []models.Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	var out []models.Order
+	for _, o := range s.data.Orders {
+		if o.Status != "done" || o.RatingValue > 0 || !o.ReviewRequestedAt.IsZero() {
+			continue
+		}
+		// Orders finished before CompletedAt was tracked fall back to their
+		// last update.
+		completedAt := o.CompletedAt
+		if completedAt.IsZero() {
+			completedAt = o.UpdatedAt
+		}
+		if completedAt.IsZero() || completedAt.After(completedBefore) {
+			continue
+		}
+		if strings.TrimSpace(o.CustomerEmail) == "" {
+			continue
+		}
+		out = append(out, *o)
+	}
+	return out
+}
+
+func (s *Store) MarkReviewRequested(orderID uint, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	for _, o := range s.data.Orders {
+		if o.ID == orderID {
+			o.ReviewRequestedAt = now
+			return s.persistLocked()
+		}
+	}
+	return os.ErrNotExist
+}
@@ -0,0 +1,348 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	RevisionService    = "service"
+	RevisionCategory   = "category"
+	RevisionGallery    = "gallery"
+	RevisionExperience = "experience"
+	RevisionPromoCode  = "promo_code"
+	RevisionCaseStudy  = "case_study"
+
+	maxRevisionsPerEntity = 50
+)
+
+var (
+	ErrRevisionMismatch  = errors.New("revisions belong to different entities")
+	ErrRevisionSlugTaken = errors.New("slug of this revision is used by another entry")
+)
+
+type RevisionChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+func IsRevisionEntity(kind string) bool {
+	switch kind {
+	case RevisionService, RevisionCategory, RevisionGallery, RevisionExperience, RevisionPromoCode, RevisionCaseStudy:
+		return true
+	}
+	return false
+}
+
+func cloneRevision(src *models.Revision) models.Revision {
+	clone := *src
+	clone.Snapshot = append(json.RawMessage(nil), src.Snapshot...)
+	return clone
+}
+
+func (s *Store) latestRevisionLocked(kind string, id uint) *models.Revision {
+	var latest *models.Revision
+	for _, rev := range s.data.Revisions {
+		if rev.EntityType == kind && rev.EntityID == id && (latest == nil || rev.Version > latest.Version) {
+			latest = rev
+		}
+	}
+	return latest
+}
+
+func (s *Store) recordRevisionLocked(kind string, id uint, action, author string, prev, current any) {
+	if prev != nil && s.latestRevisionLocked(kind, id) == nil {
+		s.appendRevisionLocked(kind, id, "baseline", "", prev)
+	}
+	s.appendRevisionLocked(kind, id, action, author, current)
+}
+
+func (s *Store) appendRevisionLocked(kind string, id uint, action, author string, entity any) {
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return
+	}
+	version := 1
+	if latest := s.latestRevisionLocked(kind, id); latest != nil {
+		version = latest.Version + 1
+	}
+	s.data.Revisions = append(s.data.Revisions, &models.Revision{
+		ID:         s.nextID("revision"),
+		EntityType: kind,
+		EntityID:   id,
+		Version:    version,
+		Action:     action,
+		Author:     strings.TrimSpace(author),
+		Snapshot:   raw,
+		CreatedAt:  time.Now().UTC(),
+	})
+	count := 0
+	for _, rev := range s.data.Revisions {
+		if rev.EntityType == kind && rev.EntityID == id {
+			count++
+		}
+	}
+	if count <= maxRevisionsPerEntity {
+		return
+	}
+	filtered := s.data.Revisions[:0]
+	for _, rev := range s.data.Revisions {
+		if count > maxRevisionsPerEntity && rev.EntityType == kind && rev.EntityID == id {
+			count--
+			continue
+		}
+		filtered = append(filtered, rev)
+	}
+	s.data.Revisions = filtered
+}
+
+func (s *Store) ListRevisions(kind string, id uint) []models.Revision {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	out := make([]models.Revision, 0)
+	for _, rev := range s.data.Revisions {
+		if rev.EntityType == kind && rev.EntityID == id {
+			out = append(out, cloneRevision(rev))
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version > out[j].Version })
+	return out
+}
+
+func (s *Store) GetRevision(id uint) (*models.Revision, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	for _, rev := range s.data.Revisions {
+		if rev.ID == id {
+			clone := cloneRevision(rev)
+			return &clone, true
+		}
+	}
+	return nil, false
+}
+
+func (s *Store) DiffRevisions(fromID, toID uint) ([]RevisionChange, error) {
+	from, ok := s.GetRevision(fromID)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	to, ok := s.GetRevision(toID)
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	if from.EntityType != to.EntityType || from.EntityID != to.EntityID {
+		return nil, ErrRevisionMismatch
+	}
+	var before, after map[string]any
+	if err := json.Unmarshal(from.Snapshot, &before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(to.Snapshot, &after); err != nil {
+		return nil, err
+	}
+	fields := make([]string, 0, len(after))
+	for key := range before {
+		fields = append(fields, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	changes := make([]RevisionChange, 0)
+	for _, field := range fields {
+		if field == "updated_at" || reflect.DeepEqual(before[field], after[field]) {
+			continue
+		}
+		changes = append(changes, RevisionChange{Field: field, Before: before[field], After: after[field]})
+	}
+	return changes, nil
+}
+
+func (s *Store) RestoreRevision(id uint, author string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	var rev *models.Revision
+	for _, candidate := range s.data.Revisions {
+		if candidate.ID == id {
+			rev = candidate
+			break
+		}
+	}
+	if rev == nil {
+		return nil, os.ErrNotExist
+	}
+	now := time.Now().UTC()
+	var (
+		restored any
+		title    string
+	)
+	switch rev.EntityType {
+	case RevisionService:
+		var snap models.Service
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		svc := s.findServiceLocked(rev.EntityID)
+		if svc == nil {
+			return nil, os.ErrNotExist
+		}
+		if s.serviceSlugTakenLocked(snap.Slug, svc.ID) {
+			return nil, ErrRevisionSlugTaken
+		}
+		next := cloneService(&snap)
+		next.ID = svc.ID
+		next.Booking = svc.Booking
+		next.Capacity = svc.Capacity
+		applyServicePackages(&next)
+		s.linkServiceMediaLocked(&next)
+		*svc = next
+		clone := cloneService(svc)
+		restored, title = &clone, svc.Title
+	case RevisionCategory:
+		var snap models.Category
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		cat := s.findCategoryLocked(rev.EntityID)
+		if cat == nil {
+			return nil, os.ErrNotExist
+		}
+		if s.categorySlugTakenLocked(snap.Slug, cat.ID) {
+			return nil, ErrRevisionSlugTaken
+		}
+		if snap.ParentID != cat.ParentID && s.validateCategoryParentLocked(cat.ID, snap.ParentID) != nil {
+			snap.ParentID = cat.ParentID
+		}
+		snap.ID = cat.ID
+		s.linkCategoryMediaLocked(&snap)
+		*cat = snap
+		clone := *cat
+		restored, title = &clone, cat.Name
+	case RevisionGallery:
+		var snap models.GalleryItem
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		var item *models.GalleryItem
+		for _, candidate := range s.data.GalleryItems {
+			if candidate.ID == rev.EntityID {
+				item = candidate
+			}
+		}
+		if item == nil {
+			return nil, os.ErrNotExist
+		}
+		next := cloneGalleryItem(&snap)
+		next.ID = item.ID
+		next.CreatedAt = item.CreatedAt
+		next.UpdatedAt = now
+		s.linkGalleryMediaLocked(&next)
+		*item = next
+		clone := cloneGalleryItem(item)
+		restored, title = &clone, item.Title
+	case RevisionExperience:
+		var snap models.Experience
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		var exp *models.Experience
+		for _, candidate := range s.data.Experiences {
+			if candidate.ID == rev.EntityID {
+				exp = candidate
+			}
+		}
+		if exp == nil {
+			return nil, os.ErrNotExist
+		}
+		next := cloneExperience(&snap)
+		next.ID = exp.ID
+		next.CreatedAt = exp.CreatedAt
+		next.UpdatedAt = now
+		*exp = next
+		clone := cloneExperience(exp)
+		restored, title = &clone, exp.Title
+	case RevisionPromoCode:
+		var snap models.PromoCode
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		var promo *models.PromoCode
+		for _, candidate := range s.data.PromoCodes {
+			if candidate.ID == rev.EntityID {
+				promo = candidate
+			}
+		}
+		if promo == nil {
+			return nil, ErrPromoNotFound
+		}
+		if existing, ok := s.findPromoByCodeLocked(snap.Code); ok && existing != nil && existing.ID != promo.ID {
+			return nil, ErrPromoDuplicate
+		}
+		next := clonePromoCode(&snap)
+		next.ID = promo.ID
+		next.UsedCount = promo.UsedCount
+		next.CreatedAt = promo.CreatedAt
+		next.UpdatedAt = now
+		*promo = next
+		clone := clonePromoCode(promo)
+		restored, title = &clone, promo.Code
+	case RevisionCaseStudy:
+		var snap models.CaseStudy
+		if err := json.Unmarshal(rev.Snapshot, &snap); err != nil {
+			return nil, err
+		}
+		cs := s.findCaseStudyLocked(rev.EntityID)
+		if cs == nil {
+			return nil, os.ErrNotExist
+		}
+		if s.caseStudySlugTakenLocked(snap.Slug, cs.ID) {
+			return nil, ErrCaseStudySlugTaken
+		}
+		next := cloneCaseStudy(&snap)
+		next.ID = cs.ID
+		next.CreatedAt = cs.CreatedAt
+		next.UpdatedAt = now
+		s.linkCaseStudyMediaLocked(&next)
+		*cs = next
+		clone := cloneCaseStudy(cs)
+		restored, title = &clone, cs.Title
+	default:
+		return nil, fmt.Errorf("unsupported revision entity %q", rev.EntityType)
+	}
+	s.appendRevisionLocked(rev.EntityType, rev.EntityID, "restored", author, restored)
+	if rev.EntityType == RevisionCategory {
+		s.reindexCategoryLocked(rev.EntityID)
+	} else {
+		s.reindexLocked(rev.EntityType, rev.EntityID)
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        rev.EntityType,
+		Action:      "restored",
+		Title:       fmt.Sprintf("\"%s\" dipulihkan ke versi %d", title, rev.Version),
+		Description: author,
+		ReferenceID: rev.EntityID,
+		Metadata: map[string]string{
+			"title":       title,
+			"revision_id": fmt.Sprintf("%d", rev.ID),
+			"version":     fmt.Sprintf("%d", rev.Version),
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -0,0 +1,590 @@
+package storage
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	SearchKindService    = "service"
+	SearchKindGallery    = "gallery"
+	SearchKindExperience = "experience"
+	SearchKindCaseStudy  = "case_study"
+
+	searchSnippetLength = 200
+)
+
+var searchStopwords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "be": {}, "by": {}, "for": {}, "from": {},
+	"in": {}, "into": {}, "is": {}, "it": {}, "of": {}, "on": {}, "or": {}, "our": {}, "the": {}, "to": {},
+	"we": {}, "with": {}, "you": {}, "your": {},
+	"akan": {}, "anda": {}, "atau": {}, "dalam": {}, "dan": {}, "dari": {}, "dengan": {}, "di": {}, "ini": {},
+	"itu": {}, "juga": {}, "kami": {}, "ke": {}, "kita": {}, "oleh": {}, "pada": {}, "sebagai": {}, "serta": {},
+	"untuk": {}, "yang": {},
+}
+
+type SearchQuery struct {
+	Text  string
+	Kinds []string
+	Limit int
+	Now   time.Time
+}
+
+type SearchResult struct {
+	Kind      string  `json:"type"`
+	ID        uint    `json:"id"`
+	Title     string  `json:"title"`
+	Slug      string  `json:"slug,omitempty"`
+	Thumbnail string  `json:"thumbnail,omitempty"`
+	Snippet   string  `json:"snippet"`
+	Score     float64 `json:"score"`
+	docKey    string
+}
+
+type searchField struct {
+	weight float64
+	text   string
+}
+
+type searchDoc struct {
+	kind       string
+	id         uint
+	title      string
+	slug       string
+	thumbnail  string
+	publishing models.Publishing
+	fields     []searchField
+}
+
+type searchIndex struct {
+	docs     map[string]*searchDoc
+	postings map[string]map[string]float64
+	// terms lists the terms of each document so removal only touches its
+	// own postings.
+	terms map[string][]string
+
+	// vocab is the sorted term list used for prefix and fuzzy matching. It
+	// is rebuilt lazily on the next search after the term set changed;
+	// vocabMu serializes that between concurrent readers.
+	vocabMu    sync.Mutex
+	vocab      []string
+	vocabDirty bool
+}
+
+type wordSpan struct {
+	start int
+	end   int
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:     make(map[string]*searchDoc),
+		postings: make(map[string]map[string]float64),
+		terms:    make(map[string][]string),
+	}
+}
+
+func searchDocKey(kind string, id uint) string {
+	return fmt.Sprintf("%s:%d", kind, id)
+}
+
+func isSearchRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func stemSearchTerm(word string) string {
+	if len(word) > 6 && strings.HasSuffix(word, "nya") {
+		word = strings.TrimSuffix(word, "nya")
+	}
+	switch {
+	case len(word) > 5 && strings.HasSuffix(word, "ies"):
+		word = word[:len(word)-3] + "y"
+	case len(word) > 5 && strings.HasSuffix(word, "ing"):
+		word = word[:len(word)-3]
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+func searchTerm(word string) (string, bool) {
+	word = strings.ToLower(word)
+	if _, stop := searchStopwords[word]; stop {
+		return "", false
+	}
+	return stemSearchTerm(word), true
+}
+
+func searchTokens(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool { return !isSearchRune(r) })
+	out := make([]string, 0, len(words))
+	for _, word := range words {
+		if term, ok := searchTerm(word); ok {
+			out = append(out, term)
+		}
+	}
+	return out
+}
+
+func wordSpans(text string) []wordSpan {
+	var spans []wordSpan
+	start := -1
+	for i, r := range text {
+		if isSearchRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			spans = append(spans, wordSpan{start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start: start, end: len(text)})
+	}
+	return spans
+}
+
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	rows := make([][]int, len(ar)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(br)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ar)][len(br)]
+}
+
+func (idx *searchIndex) put(doc *searchDoc) {
+	key := searchDocKey(doc.kind, doc.id)
+	idx.remove(key)
+	idx.docs[key] = doc
+	var terms []string
+	for _, field := range doc.fields {
+		for _, term := range searchTokens(field.text) {
+			postings, ok := idx.postings[term]
+			if !ok {
+				postings = make(map[string]float64)
+				idx.postings[term] = postings
+				idx.vocabDirty = true
+			}
+			if _, seen := postings[key]; !seen {
+				terms = append(terms, term)
+			}
+			postings[key] += field.weight
+		}
+	}
+	idx.terms[key] = terms
+}
+
+func (idx *searchIndex) remove(key string) {
+	if _, ok := idx.docs[key]; !ok {
+		return
+	}
+	delete(idx.docs, key)
+	for _, term := range idx.terms[key] {
+		postings := idx.postings[term]
+		delete(postings, key)
+		if len(postings) == 0 {
+			delete(idx.postings, term)
+			idx.vocabDirty = true
+		}
+	}
+	delete(idx.terms, key)
+}
+
+// vocabulary returns the sorted term list, rebuilding it if terms were added
+// or dropped since the last call.
+func (idx *searchIndex) vocabulary() []string {
+	idx.vocabMu.Lock()
+	defer idx.vocabMu.Unlock()
+	if idx.vocabDirty || idx.vocab == nil {
+		vocab := make([]string, 0, len(idx.postings))
+		for term := range idx.postings {
+			vocab = append(vocab, term)
+		}
+		sort.Strings(vocab)
+		idx.vocab, idx.vocabDirty = vocab, false
+	}
+	return idx.vocab
+}
+
+func (idx *searchIndex) expand(term string) map[string]float64 {
+	matches := make(map[string]float64)
+	if _, ok := idx.postings[term]; ok {
+		matches[term] = 1
+	}
+	vocab := idx.vocabulary()
+	if len([]rune(term)) >= 2 {
+		for i := sort.SearchStrings(vocab, term); i < len(vocab) && strings.HasPrefix(vocab[i], term); i++ {
+			if _, ok := matches[vocab[i]]; !ok {
+				matches[vocab[i]] = 0.6
+			}
+		}
+	}
+	length := len([]rune(term))
+	if length < 4 {
+		return matches
+	}
+	maxDist := 1
+	if length >= 8 {
+		maxDist = 2
+	}
+	for _, candidate := range vocab {
+		if _, ok := matches[candidate]; ok {
+			continue
+		}
+		diff := len([]rune(candidate)) - length
+		if diff < -maxDist || diff > maxDist {
+			continue
+		}
+		if editDistance(term, candidate) <= maxDist {
+			matches[candidate] = 0.4
+		}
+	}
+	return matches
+}
+
+func (idx *searchIndex) snippet(doc *searchDoc, matched map[string]struct{}) string {
+	isMatch := func(word string) bool {
+		term, ok := searchTerm(word)
+		if !ok {
+			return false
+		}
+		_, hit := matched[term]
+		return hit
+	}
+	text := ""
+	for _, field := range doc.fields[1:] {
+		for _, span := range wordSpans(field.text) {
+			if isMatch(field.text[span.start:span.end]) {
+				text = field.text
+				break
+			}
+		}
+		if text != "" {
+			break
+		}
+	}
+	if text == "" {
+		for _, field := range doc.fields[1:] {
+			if strings.TrimSpace(field.text) != "" {
+				text = field.text
+				break
+			}
+		}
+	}
+	if text == "" {
+		text = doc.title
+	}
+	spans := wordSpans(text)
+	if len(spans) == 0 {
+		return html.EscapeString(strings.TrimSpace(text))
+	}
+	first := 0
+	for i, span := range spans {
+		if isMatch(text[span.start:span.end]) {
+			first = i
+			break
+		}
+	}
+	from := max(0, first-8)
+	windowStart := spans[from].start
+	if from == 0 {
+		windowStart = 0
+	}
+	windowEnd := len(text)
+	if windowEnd-windowStart > searchSnippetLength {
+		windowEnd = spans[from].end
+		for _, span := range spans[from:] {
+			if span.end-windowStart > searchSnippetLength {
+				break
+			}
+			windowEnd = span.end
+		}
+	}
+	var b strings.Builder
+	if windowStart > 0 {
+		b.WriteString("…")
+	}
+	cursor := windowStart
+	for _, span := range spans {
+		if span.start < windowStart || span.end > windowEnd {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[cursor:span.start]))
+		word := text[span.start:span.end]
+		if isMatch(word) {
+			b.WriteString("<mark>" + html.EscapeString(word) + "</mark>")
+		} else {
+			b.WriteString(html.EscapeString(word))
+		}
+		cursor = span.end
+	}
+	b.WriteString(html.EscapeString(text[cursor:windowEnd]))
+	if windowEnd < len(text) {
+		b.WriteString("…")
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (s *Store) rebuildSearchIndexLocked() {
+	s.search = newSearchIndex()
+	for _, svc := range s.data.Services {
+		s.indexServiceLocked(svc)
+	}
+	for _, item := range s.data.GalleryItems {
+		s.indexGalleryItemLocked(item)
+	}
+	for _, exp := range s.data.Experiences {
+		s.indexExperienceLocked(exp)
+	}
+	for _, cs := range s.data.CaseStudies {
+		s.indexCaseStudyLocked(cs)
+	}
+}
+
+func (s *Store) indexServiceLocked(svc *models.Service) {
+	fields := []searchField{
+		{weight: 5, text: svc.Title},
+		{weight: 2, text: svc.Summary},
+		{weight: 1, text: svc.Description},
+		{weight: 3, text: s.categoryNameLocked(svc.CategoryID)},
+	}
+	for _, h := range svc.Highlights {
+		fields = append(fields, searchField{weight: 2, text: h.Title}, searchField{weight: 1, text: h.Description})
+	}
+	s.search.put(&searchDoc{
+		kind:       SearchKindService,
+		id:         svc.ID,
+		title:      svc.Title,
+		slug:       svc.Slug,
+		thumbnail:  svc.Thumbnail,
+		publishing: svc.Publishing,
+		fields:     fields,
+	})
+}
+
+func (s *Store) indexGalleryItemLocked(item *models.GalleryItem) {
+	fields := []searchField{
+		{weight: 5, text: item.Title},
+		{weight: 2, text: item.Subtitle},
+		{weight: 1, text: item.Description},
+		{weight: 3, text: strings.Join(item.Filters, ", ")},
+		{weight: 1, text: item.Section},
+	}
+	s.search.put(&searchDoc{
+		kind:       SearchKindGallery,
+		id:         item.ID,
+		title:      item.Title,
+		thumbnail:  item.Thumbnail,
+		publishing: item.Publishing,
+		fields:     fields,
+	})
+}
+
+func (s *Store) indexExperienceLocked(exp *models.Experience) {
+	fields := []searchField{
+		{weight: 5, text: exp.Title},
+		{weight: 1, text: exp.Description},
+		{weight: 3, text: exp.Company},
+		{weight: 1, text: exp.Period},
+	}
+	s.search.put(&searchDoc{
+		kind:       SearchKindExperience,
+		id:         exp.ID,
+		title:      exp.Title,
+		publishing: exp.Publishing,
+		fields:     fields,
+	})
+}
+
+func (s *Store) indexCaseStudyLocked(cs *models.CaseStudy) {
+	fields := []searchField{
+		{weight: 5, text: cs.Title},
+		{weight: 2, text: cs.Summary},
+		{weight: 1, text: cs.Challenge},
+		{weight: 1, text: cs.Approach},
+		{weight: 1, text: cs.Result},
+		{weight: 2, text: cs.ClientIndustry},
+	}
+	if !cs.ClientAnonymized {
+		fields = append(fields, searchField{weight: 3, text: cs.ClientName})
+	}
+	s.search.put(&searchDoc{
+		kind:       SearchKindCaseStudy,
+		id:         cs.ID,
+		title:      cs.Title,
+		slug:       cs.Slug,
+		thumbnail:  cs.CoverImage,
+		publishing: cs.Publishing,
+		fields:     fields,
+	})
+}
+
+func (s *Store) reindexLocked(kind string, id uint) {
+	switch kind {
+	case SearchKindService:
+		if svc := s.findServiceLocked(id); svc != nil {
+			s.indexServiceLocked(svc)
+			return
+		}
+	case SearchKindGallery:
+		for _, item := range s.data.GalleryItems {
+			if item.ID == id {
+				s.indexGalleryItemLocked(item)
+				return
+			}
+		}
+	case SearchKindExperience:
+		for _, exp := range s.data.Experiences {
+			if exp.ID == id {
+				s.indexExperienceLocked(exp)
+				return
+			}
+		}
+	case SearchKindCaseStudy:
+		if cs := s.findCaseStudyLocked(id); cs != nil {
+			s.indexCaseStudyLocked(cs)
+			return
+		}
+	}
+	s.search.remove(searchDocKey(kind, id))
+}
+
+func (s *Store) reindexCategoryLocked(categoryID uint) {
+	for _, svc := range s.data.Services {
+		if svc.CategoryID == categoryID {
+			s.indexServiceLocked(svc)
+		}
+	}
+}
+
+func (s *Store) serviceSearchBoostsLocked() map[uint]float64 {
+	completed := make(map[uint]int)
+	for _, order := range s.data.Orders {
+		if order.Status == "done" {
+			completed[order.ServiceID]++
+		}
+	}
+	ratingSum := make(map[uint]int)
+	ratingCount := make(map[uint]int)
+	for _, review := range s.data.Reviews {
+		if review.Status == ReviewStatusApproved {
+			ratingSum[review.ServiceID] += review.Rating
+			ratingCount[review.ServiceID]++
+		}
+	}
+	boosts := make(map[uint]float64, len(s.data.Services))
+	for _, svc := range s.data.Services {
+		boost := 1 + math.Log1p(float64(completed[svc.ID]))/10
+		if n := ratingCount[svc.ID]; n > 0 {
+			boost += float64(ratingSum[svc.ID]) / float64(n) / 10
+		}
+		boosts[svc.ID] = boost
+	}
+	return boosts
+}
+
+func (s *Store) Search(q SearchQuery) []SearchResult {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	terms := searchTokens(q.Text)
+	if len(terms) == 0 {
+		return []SearchResult{}
+	}
+	if q.Now.IsZero() {
+		q.Now = time.Now().UTC()
+	}
+	kinds := make(map[string]bool, len(q.Kinds))
+	for _, kind := range q.Kinds {
+		kinds[kind] = true
+	}
+	total := float64(len(s.search.docs))
+	scores := make(map[string]float64)
+	hits := make(map[string]int)
+	matched := make(map[string]map[string]struct{})
+	for _, term := range terms {
+		best := make(map[string]float64)
+		for candidate, factor := range s.search.expand(term) {
+			postings := s.search.postings[candidate]
+			idf := math.Log(1 + total/float64(len(postings)))
+			for key, weight := range postings {
+				if score := factor * idf * weight; score > best[key] {
+					best[key] = score
+				}
+				if matched[key] == nil {
+					matched[key] = make(map[string]struct{})
+				}
+				matched[key][candidate] = struct{}{}
+			}
+		}
+		for key, score := range best {
+			scores[key] += score
+			hits[key]++
+		}
+	}
+	boosts := s.serviceSearchBoostsLocked()
+	results := make([]SearchResult, 0, len(scores))
+	for key, score := range scores {
+		doc := s.search.docs[key]
+		if len(kinds) > 0 && !kinds[doc.kind] {
+			continue
+		}
+		if !IsLive(doc.publishing, q.Now) {
+			continue
+		}
+		score *= float64(hits[key]) / float64(len(terms))
+		if doc.kind == SearchKindService {
+			score *= boosts[doc.id]
+		}
+		results = append(results, SearchResult{
+			Kind:      doc.kind,
+			ID:        doc.id,
+			Title:     doc.title,
+			Slug:      doc.slug,
+			Thumbnail: doc.thumbnail,
+			Score:     math.Round(score*1000) / 1000,
+			docKey:    key,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Title < results[j].Title
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	for i := range results {
+		results[i].Snippet = s.search.snippet(s.search.docs[results[i].docKey], matched[results[i].docKey])
+	}
+	return results
+}
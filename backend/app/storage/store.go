@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -20,6 +21,14 @@ type Store struct {
 	path   string
 	data   *snapshot
 	loaded bool
+	search *searchIndex
+
+	// mediaRefs caches the media reference index until the next write.
+	// revisionMedia keeps the media decoded from each revision snapshot,
+	// which never changes once recorded.
+	mediaRefsMu   sync.Mutex
+	mediaRefs     map[uint][]models.MediaReference
+	revisionMedia map[uint][]revisionMediaRef
 }
 
 var (
@@ -29,6 +38,7 @@ var (
 	ErrPromoUsageExceeded = errors.New("promo code usage limit reached")
 	ErrPromoNotFound      = errors.New("promo code not found")
 	ErrPromoDuplicate     = errors.New("promo code already exists")
+	ErrPromoNotEligible   = errors.New("promo code belongs to another customer")
 )
 
 func cloneService(src *models.Service) models.Service {
@@ -38,16 +48,32 @@ func cloneService(src *models.Service) models.Service {
 	} else {
 		clone.GalleryImages = nil
 	}
+	if len(src.GalleryMediaIDs) > 0 {
+		clone.GalleryMediaIDs = append([]uint(nil), src.GalleryMediaIDs...)
+	} else {
+		clone.GalleryMediaIDs = nil
+	}
 	if len(src.AddOns) > 0 {
 		clone.AddOns = append([]models.AddOn(nil), src.AddOns...)
 	} else {
 		clone.AddOns = nil
 	}
+	clone.Packages = cloneServicePackages(src.Packages)
 	if len(src.Highlights) > 0 {
 		clone.Highlights = append([]models.ServiceHighlight(nil), src.Highlights...)
 	} else {
 		clone.Highlights = nil
 	}
+	if src.Booking != nil {
+		booking := *src.Booking
+		booking.WorkingHours = append([]models.WorkingHours(nil), src.Booking.WorkingHours...)
+		clone.Booking = &booking
+	}
+	if src.Capacity != nil {
+		capacity := *src.Capacity
+		clone.Capacity = &capacity
+	}
+	clone.Images = cloneImageAssets(src.Images)
 	return clone
 }
 
@@ -63,9 +89,69 @@ func cloneGalleryItem(src *models.GalleryItem) models.GalleryItem {
 	} else {
 		clone.Assets = nil
 	}
+	clone.Images = cloneImageAssets(src.Images)
+	clone.VideoEmbed = cloneLinkEmbed(src.VideoEmbed)
+	clone.LinkEmbed = cloneLinkEmbed(src.LinkEmbed)
+	if len(src.ServiceIDs) > 0 {
+		clone.ServiceIDs = append([]uint(nil), src.ServiceIDs...)
+	} else {
+		clone.ServiceIDs = nil
+	}
 	return clone
 }
 
+func cloneLinkEmbed(src *models.LinkEmbed) *models.LinkEmbed {
+	if src == nil {
+		return nil
+	}
+	clone := *src
+	return &clone
+}
+
+func cloneImageAssets(src map[string]models.ImageAsset) map[string]models.ImageAsset {
+	if len(src) == 0 {
+		return nil
+	}
+	out := make(map[string]models.ImageAsset, len(src))
+	for key, asset := range src {
+		asset.Variants = append([]models.ImageVariant(nil), asset.Variants...)
+		out[key] = asset
+	}
+	return out
+}
+
+func mergeImageAssets(current, added map[string]models.ImageAsset, urls []string) map[string]models.ImageAsset {
+	keep := make(map[string]bool, len(urls))
+	for _, u := range urls {
+		keep[u] = true
+	}
+	out := make(map[string]models.ImageAsset)
+	for _, src := range []map[string]models.ImageAsset{current, added} {
+		for key, asset := range src {
+			if keep[key] {
+				asset.Variants = append([]models.ImageVariant(nil), asset.Variants...)
+				out[key] = asset
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func serviceImageURLs(svc *models.Service) []string {
+	return append([]string{svc.Thumbnail}, svc.GalleryImages...)
+}
+
+func galleryImageURLs(item *models.GalleryItem) []string {
+	urls := []string{item.Thumbnail}
+	for _, asset := range item.Assets {
+		urls = append(urls, asset.URL)
+	}
+	return urls
+}
+
 func cloneExperience(src *models.Experience) models.Experience {
 	clone := *src
 	return clone
@@ -153,12 +239,25 @@ type snapshot struct {
 	Categories             []*models.Category             `json:"categories"`
 	Orders                 []*models.Order                `json:"orders"`
 	Messages               []*models.Message              `json:"messages"`
+	OrderMessages          []*models.OrderMessage         `json:"order_messages"`
+	QuoteRequests          []*models.QuoteRequest         `json:"quote_requests"`
+	BlackoutDates          []*models.BlackoutDate         `json:"blackout_dates"`
+	WaitlistEntries        []*models.WaitlistEntry        `json:"waitlist_entries"`
+	ExportJobs             []*models.ExportJob            `json:"export_jobs"`
+	CheckoutRecoveries     []*models.CheckoutRecovery     `json:"checkout_recoveries"`
+	RecoveryOptOuts        []*models.RecoveryOptOut       `json:"recovery_opt_outs"`
+	Reviews                []*models.Review               `json:"reviews"`
+	Revisions              []*models.Revision             `json:"revisions"`
+	Media                  []*models.MediaItem            `json:"media"`
+	ProofingGalleries      []*models.ProofingGallery      `json:"proofing_galleries"`
+	CaseStudies            []*models.CaseStudy            `json:"case_studies"`
 	Activities             []*models.Activity             `json:"activities"`
 	AnalyticsEvents        []*models.AnalyticsEvent       `json:"analytics_events"`
 	AnalyticsSessions      []*models.AnalyticsSession     `json:"analytics_sessions"`
 	PromoCodes             []*models.PromoCode            `json:"promo_codes"`
 	PaymentTransactions    []*models.PaymentTransaction   `json:"payment_transactions"`
 	PaymentChannelStatuses []*models.PaymentChannelStatus `json:"payment_channel_statuses,omitempty"`
+	BookingFeedVersion     uint                           `json:"booking_feed_version,omitempty"`
 }
 
 func defaultSnapshot() *snapshot {
@@ -177,11 +276,35 @@ func defaultSnapshot() *snapshot {
 			"analytics_session":   1,
 			"promo_code":          1,
 			"payment_transaction": 1,
+			"order_message":       1,
+			"quote_request":       1,
+			"blackout_date":       1,
+			"waitlist_entry":      1,
+			"export_job":          1,
+			"checkout_recovery":   1,
+			"review":              1,
+			"revision":            1,
+			"media":               1,
+			"proofing_gallery":    1,
+			"proofing_image":      1,
+			"case_study":          1,
 		},
 		GalleryItems:           []*models.GalleryItem{},
 		Experiences:            []*models.Experience{},
 		PaymentTransactions:    []*models.PaymentTransaction{},
 		PaymentChannelStatuses: []*models.PaymentChannelStatus{},
+		OrderMessages:          []*models.OrderMessage{},
+		QuoteRequests:          []*models.QuoteRequest{},
+		BlackoutDates:          []*models.BlackoutDate{},
+		WaitlistEntries:        []*models.WaitlistEntry{},
+		ExportJobs:             []*models.ExportJob{},
+		CheckoutRecoveries:     []*models.CheckoutRecovery{},
+		RecoveryOptOuts:        []*models.RecoveryOptOut{},
+		Reviews:                []*models.Review{},
+		Revisions:              []*models.Revision{},
+		Media:                  []*models.MediaItem{},
+		ProofingGalleries:      []*models.ProofingGallery{},
+		CaseStudies:            []*models.CaseStudy{},
 	}
 }
 
@@ -278,6 +401,7 @@ func (s *Store) loadFromDisk() error {
 		}
 		s.data = defaultSnapshot()
 		s.loaded = true
+		s.rebuildSearchIndexLocked()
 		return s.persistLocked()
 	}
 	f, err := os.Open(s.path)
@@ -314,13 +438,88 @@ func (s *Store) loadFromDisk() error {
 	if _, ok := snap.NextIDs["payment_transaction"]; !ok {
 		snap.NextIDs["payment_transaction"] = 1
 	}
+	if snap.OrderMessages == nil {
+		snap.OrderMessages = []*models.OrderMessage{}
+	}
+	if _, ok := snap.NextIDs["order_message"]; !ok {
+		snap.NextIDs["order_message"] = 1
+	}
+	if snap.QuoteRequests == nil {
+		snap.QuoteRequests = []*models.QuoteRequest{}
+	}
+	if _, ok := snap.NextIDs["quote_request"]; !ok {
+		snap.NextIDs["quote_request"] = 1
+	}
+	if snap.BlackoutDates == nil {
+		snap.BlackoutDates = []*models.BlackoutDate{}
+	}
+	if _, ok := snap.NextIDs["blackout_date"]; !ok {
+		snap.NextIDs["blackout_date"] = 1
+	}
+	if snap.WaitlistEntries == nil {
+		snap.WaitlistEntries = []*models.WaitlistEntry{}
+	}
+	if _, ok := snap.NextIDs["waitlist_entry"]; !ok {
+		snap.NextIDs["waitlist_entry"] = 1
+	}
+	if snap.ExportJobs == nil {
+		snap.ExportJobs = []*models.ExportJob{}
+	}
+	if _, ok := snap.NextIDs["export_job"]; !ok {
+		snap.NextIDs["export_job"] = 1
+	}
+	if snap.CheckoutRecoveries == nil {
+		snap.CheckoutRecoveries = []*models.CheckoutRecovery{}
+	}
+	if snap.RecoveryOptOuts == nil {
+		snap.RecoveryOptOuts = []*models.RecoveryOptOut{}
+	}
+	if _, ok := snap.NextIDs["checkout_recovery"]; !ok {
+		snap.NextIDs["checkout_recovery"] = 1
+	}
+	if _, ok := snap.NextIDs["review"]; !ok {
+		snap.NextIDs["review"] = 1
+	}
+	if snap.Reviews == nil {
+		snap.Reviews = []*models.Review{}
+		migrateLegacyRatings(snap)
+	}
+	if snap.Revisions == nil {
+		snap.Revisions = []*models.Revision{}
+	}
+	if _, ok := snap.NextIDs["revision"]; !ok {
+		snap.NextIDs["revision"] = 1
+	}
+	if snap.Media == nil {
+		snap.Media = []*models.MediaItem{}
+	}
+	if _, ok := snap.NextIDs["media"]; !ok {
+		snap.NextIDs["media"] = 1
+	}
+	if snap.ProofingGalleries == nil {
+		snap.ProofingGalleries = []*models.ProofingGallery{}
+	}
+	if _, ok := snap.NextIDs["proofing_gallery"]; !ok {
+		snap.NextIDs["proofing_gallery"] = 1
+	}
+	if _, ok := snap.NextIDs["proofing_image"]; !ok {
+		snap.NextIDs["proofing_image"] = 1
+	}
+	if snap.CaseStudies == nil {
+		snap.CaseStudies = []*models.CaseStudy{}
+	}
+	if _, ok := snap.NextIDs["case_study"]; !ok {
+		snap.NextIDs["case_study"] = 1
+	}
 	s.data = snap
 	s.loaded = true
+	s.rebuildSearchIndexLocked()
 	s.pruneAnalyticsLocked(time.Now().UTC())
 	return nil
 }
 
 func (s *Store) persistLocked() error {
+	s.mediaRefs = nil
 	tmpPath := s.path + ".tmp"
 	f, err := os.Create(tmpPath)
 	if err != nil {
@@ -581,7 +780,7 @@ func (s *Store) ListCategories() []models.Category {
 	for _, c := range s.data.Categories {
 		out = append(out, *c)
 	}
-	sort.Slice(out, func(i, j int) bool { return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name) })
+	sortCategories(out)
 	return out
 }
 
@@ -589,12 +788,21 @@ func (s *Store) CreateCategory(cat *models.Category) (*models.Category, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
+	if cat.ParentID != 0 && s.findCategoryLocked(cat.ParentID) == nil {
+		return nil, ErrCategoryParentNotFound
+	}
 	cat.ID = s.nextID("category")
 	if cat.Slug != "" {
 		cat.Slug = slugify(cat.Slug)
 	} else {
 		cat.Slug = slugify(cat.Name)
 	}
+	cat.Description = strings.TrimSpace(cat.Description)
+	cat.Icon = strings.TrimSpace(cat.Icon)
+	s.linkCategoryMediaLocked(cat)
+	if cat.SortOrder <= 0 {
+		cat.SortOrder = s.maxCategorySortLocked(cat.ParentID) + 1
+	}
 	clone := *cat
 	s.data.Categories = append(s.data.Categories, &clone)
 	s.appendActivityLocked(&models.Activity{
@@ -604,8 +812,9 @@ func (s *Store) CreateCategory(cat *models.Category) (*models.Category, error) {
 		Description: fmt.Sprintf("Slug: %s", cat.Slug),
 		ReferenceID: cat.ID,
 		Metadata: map[string]string{
-			"name": cat.Name,
-			"slug": cat.Slug,
+			"name":      cat.Name,
+			"slug":      cat.Slug,
+			"parent_id": fmt.Sprintf("%d", cat.ParentID),
 		},
 	})
 	if err := s.persistLocked(); err != nil {
@@ -614,77 +823,121 @@ func (s *Store) CreateCategory(cat *models.Category) (*models.Category, error) {
 	return cat, nil
 }
 
-func (s *Store) UpdateCategory(id uint, name, slug string) (*models.Category, error) {
+func (s *Store) UpdateCategory(id uint, update CategoryUpdate, author string) (*models.Category, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
-	for _, c := range s.data.Categories {
-		if c.ID == id {
-			prevName := c.Name
-			prevSlug := c.Slug
-			c.Name = name
-			if slug != "" {
-				c.Slug = slugify(slug)
-			} else {
-				c.Slug = slugify(name)
-			}
-			s.appendActivityLocked(&models.Activity{
-				Type:        "category",
-				Action:      "updated",
-				Title:       fmt.Sprintf("Kategori \"%s\" diperbarui", c.Name),
-				Description: fmt.Sprintf("Sebelumnya \"%s\" (%s)", prevName, prevSlug),
-				ReferenceID: c.ID,
-				Metadata: map[string]string{
-					"old_name": prevName,
-					"old_slug": prevSlug,
-					"name":     c.Name,
-					"slug":     c.Slug,
-				},
-			})
-			if err := s.persistLocked(); err != nil {
-				return nil, err
-			}
-			clone := *c
-			return &clone, nil
+	c := s.findCategoryLocked(id)
+	if c == nil {
+		return nil, os.ErrNotExist
+	}
+	if update.ParentID != nil && *update.ParentID != c.ParentID {
+		if err := s.validateCategoryParentLocked(id, *update.ParentID); err != nil {
+			return nil, err
 		}
 	}
-	return nil, os.ErrNotExist
+	prev := *c
+	c.Name = update.Name
+	if update.Slug != "" {
+		c.Slug = slugify(update.Slug)
+	} else {
+		c.Slug = slugify(update.Name)
+	}
+	if update.ParentID != nil && *update.ParentID != c.ParentID {
+		c.ParentID = *update.ParentID
+		if update.SortOrder == nil {
+			c.SortOrder = s.maxCategorySortLocked(c.ParentID) + 1
+		}
+	}
+	if update.SortOrder != nil {
+		c.SortOrder = *update.SortOrder
+	}
+	if update.Description != nil {
+		c.Description = strings.TrimSpace(*update.Description)
+	}
+	if update.Icon != nil {
+		c.Icon = strings.TrimSpace(*update.Icon)
+	}
+	if update.CoverImage != nil {
+		c.CoverImage = strings.TrimSpace(*update.CoverImage)
+	}
+	s.linkCategoryMediaLocked(c)
+	s.recordRevisionLocked(RevisionCategory, c.ID, "updated", author, &prev, c)
+	s.reindexCategoryLocked(c.ID)
+	s.appendActivityLocked(&models.Activity{
+		Type:        "category",
+		Action:      "updated",
+		Title:       fmt.Sprintf("Kategori \"%s\" diperbarui", c.Name),
+		Description: fmt.Sprintf("Sebelumnya \"%s\" (%s)", prev.Name, prev.Slug),
+		ReferenceID: c.ID,
+		Metadata: map[string]string{
+			"old_name":      prev.Name,
+			"old_slug":      prev.Slug,
+			"old_parent_id": fmt.Sprintf("%d", prev.ParentID),
+			"name":          c.Name,
+			"slug":          c.Slug,
+			"parent_id":     fmt.Sprintf("%d", c.ParentID),
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	clone := *c
+	return &clone, nil
 }
 
-func (s *Store) DeleteCategory(id uint) error {
+func (s *Store) DeleteCategory(id uint, reassignTo *uint) (*models.Category, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
+	deleted := s.findCategoryLocked(id)
+	if deleted == nil {
+		return nil, os.ErrNotExist
+	}
+	target := deleted.ParentID
+	if reassignTo != nil {
+		if *reassignTo == id || (*reassignTo != 0 && s.findCategoryLocked(*reassignTo) == nil) {
+			return nil, ErrCategoryReassignInvalid
+		}
+		target = *reassignTo
+	}
 	filtered := s.data.Categories[:0]
-	var deletedName, deletedSlug string
 	for _, c := range s.data.Categories {
-		if c.ID != id {
-			filtered = append(filtered, c)
-		} else {
-			deletedName = c.Name
-			deletedSlug = c.Slug
+		if c.ID == id {
+			continue
 		}
+		if c.ParentID == id {
+			c.ParentID = deleted.ParentID
+		}
+		filtered = append(filtered, c)
 	}
 	s.data.Categories = filtered
+	moved := 0
 	for _, svc := range s.data.Services {
 		if svc.CategoryID == id {
-			svc.CategoryID = 0
+			svc.CategoryID = target
+			s.indexServiceLocked(svc)
+			moved++
 		}
 	}
-	if deletedName != "" {
-		s.appendActivityLocked(&models.Activity{
-			Type:        "category",
-			Action:      "deleted",
-			Title:       fmt.Sprintf("Kategori \"%s\" dihapus", deletedName),
-			Description: fmt.Sprintf("Slug: %s", deletedSlug),
-			ReferenceID: id,
-			Metadata: map[string]string{
-				"name": deletedName,
-				"slug": deletedSlug,
-			},
-		})
+	s.appendActivityLocked(&models.Activity{
+		Type:        "category",
+		Action:      "deleted",
+		Title:       fmt.Sprintf("Kategori \"%s\" dihapus", deleted.Name),
+		Description: fmt.Sprintf("Slug: %s • %d layanan dipindahkan", deleted.Slug, moved),
+		ReferenceID: id,
+		Metadata: map[string]string{
+			"name":           deleted.Name,
+			"slug":           deleted.Slug,
+			"reassigned_to":  fmt.Sprintf("%d", target),
+			"services_moved": fmt.Sprintf("%d", moved),
+		},
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
 	}
-	return s.persistLocked()
+	clone := *deleted
+	return &clone, nil
 }
 
 func (s *Store) GetCategoryByID(id uint) (*models.Category, bool) {
@@ -714,6 +967,24 @@ func (s *Store) GetCategoryBySlug(slug string) (*models.Category, bool) {
 	return nil, false
 }
 
+func (s *Store) categorySlugTakenLocked(slug string, exceptID uint) bool {
+	for _, c := range s.data.Categories {
+		if c.Slug == slug && c.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) serviceSlugTakenLocked(slug string, exceptID uint) bool {
+	for _, svc := range s.data.Services {
+		if svc.Slug == slug && svc.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Store) ListServices() []models.Service {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -762,8 +1033,15 @@ func (s *Store) CreateService(svc *models.Service) (*models.Service, error) {
 	if svc.Slug == "" {
 		svc.Slug = slugify(svc.Title)
 	}
+	applyServicePackages(svc)
+	svc.Images = mergeImageAssets(nil, svc.Images, serviceImageURLs(svc))
+	s.linkServiceMediaLocked(svc)
+	if svc.PublishStatus == "" {
+		svc.PublishStatus = PublishDraft
+	}
 	clone := cloneService(svc)
 	s.data.Services = append(s.data.Services, &clone)
+	s.indexServiceLocked(&clone)
 	categoryName := s.categoryNameLocked(svc.CategoryID)
 	description := "Belum ada kategori"
 	if categoryName != "" {
@@ -790,7 +1068,7 @@ func (s *Store) CreateService(svc *models.Service) (*models.Service, error) {
 	return svc, nil
 }
 
-func (s *Store) UpdateService(id uint, update *models.Service) (*models.Service, error) {
+func (s *Store) UpdateService(id uint, update *models.Service, author string) (*models.Service, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
@@ -805,6 +1083,10 @@ func (s *Store) UpdateService(id uint, update *models.Service) (*models.Service,
 			if update.AddOns != nil {
 				svc.AddOns = append([]models.AddOn(nil), update.AddOns...)
 			}
+			if update.Packages != nil {
+				svc.Packages = cloneServicePackages(update.Packages)
+			}
+			applyServicePackages(svc)
 			if update.Highlights != nil {
 				svc.Highlights = append([]models.ServiceHighlight(nil), update.Highlights...)
 			}
@@ -814,12 +1096,16 @@ func (s *Store) UpdateService(id uint, update *models.Service) (*models.Service,
 			if update.GalleryImages != nil {
 				svc.GalleryImages = append([]string(nil), update.GalleryImages...)
 			}
+			svc.Images = mergeImageAssets(svc.Images, update.Images, serviceImageURLs(svc))
+			s.linkServiceMediaLocked(svc)
 			if update.Slug != "" {
 				svc.Slug = slugify(update.Slug)
 			}
 			if svc.Slug == "" {
 				svc.Slug = slugify(update.Title)
 			}
+			s.recordRevisionLocked(RevisionService, svc.ID, "updated", author, &prev, svc)
+			s.indexServiceLocked(svc)
 			categoryName := s.categoryNameLocked(svc.CategoryID)
 			prevCategoryName := s.categoryNameLocked(prev.CategoryID)
 			s.appendActivityLocked(&models.Activity{
@@ -856,6 +1142,9 @@ func (s *Store) DeleteService(id uint) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
+	if s.serviceHasOrdersLocked(id) {
+		return ErrServiceHasOrders
+	}
 	filtered := s.data.Services[:0]
 	var deleted *models.Service
 	for _, svc := range s.data.Services {
@@ -868,6 +1157,8 @@ func (s *Store) DeleteService(id uint) error {
 	}
 	s.data.Services = filtered
 	if deleted != nil {
+		s.search.remove(searchDocKey(SearchKindService, deleted.ID))
+		s.unlinkServiceLocked(deleted.ID)
 		categoryName := s.categoryNameLocked(deleted.CategoryID)
 		s.appendActivityLocked(&models.Activity{
 			Type:        "service",
@@ -887,6 +1178,17 @@ func (s *Store) DeleteService(id uint) error {
 	return s.persistLocked()
 }
 
+// unlinkServiceLocked drops a deleted service from the gallery items and
+// case studies that listed it.
+func (s *Store) unlinkServiceLocked(serviceID uint) {
+	for _, item := range s.data.GalleryItems {
+		item.ServiceIDs = slices.DeleteFunc(item.ServiceIDs, func(id uint) bool { return id == serviceID })
+	}
+	for _, cs := range s.data.CaseStudies {
+		cs.ServiceIDs = slices.DeleteFunc(cs.ServiceIDs, func(id uint) bool { return id == serviceID })
+	}
+}
+
 func (s *Store) maxExperienceOrderLocked() int {
 	max := 0
 	for _, exp := range s.data.Experiences {
@@ -952,10 +1254,14 @@ func (s *Store) CreateExperience(exp *models.Experience) (*models.Experience, er
 	if exp.Order <= 0 {
 		exp.Order = s.maxExperienceOrderLocked() + 1
 	}
+	if exp.PublishStatus == "" {
+		exp.PublishStatus = PublishDraft
+	}
 	exp.CreatedAt = now
 	exp.UpdatedAt = now
 	clone := cloneExperience(exp)
 	s.data.Experiences = append(s.data.Experiences, &clone)
+	s.indexExperienceLocked(&clone)
 	s.appendActivityLocked(&models.Activity{
 		Type:        "experience",
 		Action:      "created",
@@ -974,7 +1280,7 @@ func (s *Store) CreateExperience(exp *models.Experience) (*models.Experience, er
 	return exp, nil
 }
 
-func (s *Store) UpdateExperience(id uint, update *models.Experience) (*models.Experience, error) {
+func (s *Store) UpdateExperience(id uint, update *models.Experience, author string) (*models.Experience, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
@@ -989,6 +1295,8 @@ func (s *Store) UpdateExperience(id uint, update *models.Experience) (*models.Ex
 				exp.Order = update.Order
 			}
 			exp.UpdatedAt = time.Now().UTC()
+			s.recordRevisionLocked(RevisionExperience, exp.ID, "updated", author, &prev, exp)
+			s.indexExperienceLocked(exp)
 			s.appendActivityLocked(&models.Activity{
 				Type:        "experience",
 				Action:      "updated",
@@ -1032,6 +1340,7 @@ func (s *Store) DeleteExperience(id uint) (*models.Experience, error) {
 	}
 	s.data.Experiences = filtered
 	if deleted != nil {
+		s.search.remove(searchDocKey(SearchKindExperience, deleted.ID))
 		s.appendActivityLocked(&models.Activity{
 			Type:        "experience",
 			Action:      "deleted",
@@ -1574,6 +1883,10 @@ func (s *Store) CreatePaymentTransaction(tx *models.PaymentTransaction) (*models
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
+	return s.createPaymentTransactionLocked(tx)
+}
+
+func (s *Store) createPaymentTransactionLocked(tx *models.PaymentTransaction) (*models.PaymentTransaction, *models.Order, error) {
 	var order *models.Order
 	for _, o := range s.data.Orders {
 		if o.ID == tx.OrderID {
@@ -1948,12 +2261,13 @@ func (s *Store) CreatePromoCode(promo *models.PromoCode) (*models.PromoCode, err
 	return promo, nil
 }
 
-func (s *Store) UpdatePromoCode(id uint, update PromoCodeUpdate) (*models.PromoCode, error) {
+func (s *Store) UpdatePromoCode(id uint, update PromoCodeUpdate, author string) (*models.PromoCode, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
 	for _, promo := range s.data.PromoCodes {
 		if promo.ID == id {
+			prev := clonePromoCode(promo)
 			if update.Code != nil {
 				code := normalizePromoCode(*update.Code)
 				if code == "" {
@@ -2011,6 +2325,7 @@ func (s *Store) UpdatePromoCode(id uint, update PromoCodeUpdate) (*models.PromoC
 				}
 			}
 			promo.UpdatedAt = time.Now().UTC()
+			s.recordRevisionLocked(RevisionPromoCode, promo.ID, "updated", author, &prev, promo)
 			clone := clonePromoCode(promo)
 			if err := s.persistLocked(); err != nil {
 				return nil, err
@@ -2058,7 +2373,25 @@ func (s *Store) CreateOrder(order *models.Order) (*models.Order, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
-	now := time.Now().UTC()
+	if err := s.createOrderLocked(order, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *Store) createOrderLocked(order *models.Order, now time.Time) error {
+	waitlistEntry, err := s.checkCapacityLocked(order, now)
+	if err != nil {
+		return err
+	}
+	if !order.BookingStart.IsZero() {
+		if err := s.reserveBookingLocked(order, now); err != nil {
+			return err
+		}
+	}
 	baseAmount := order.Amount
 	if baseAmount < 0 {
 		baseAmount = 0
@@ -2068,10 +2401,13 @@ func (s *Store) CreateOrder(order *models.Order) (*models.Order, error) {
 		order.PromoCode = normalizePromoCode(order.PromoCode)
 		promo, ok := s.findPromoByCodeLocked(order.PromoCode)
 		if !ok {
-			return nil, ErrPromoNotFound
+			return ErrPromoNotFound
 		}
 		if err := s.validatePromoLocked(promo, now); err != nil {
-			return nil, err
+			return err
+		}
+		if !promoAllowedFor(promo, order.CustomerEmail) {
+			return ErrPromoNotEligible
 		}
 		order.PromoDiscountPercent = promo.DiscountPercent
 		discount := roundCurrency(order.Amount * promo.DiscountPercent / 100)
@@ -2096,7 +2432,22 @@ func (s *Store) CreateOrder(order *models.Order) (*models.Order, error) {
 	if order.Status == "" {
 		order.Status = "pending"
 	}
+	if waitlistEntry != nil {
+		waitlistEntry.Status = "converted"
+		waitlistEntry.OrderID = order.ID
+		waitlistEntry.UpdatedAt = now
+	}
 	clone := *order
+	if len(order.LineItems) > 0 {
+		clone.LineItems = append([]models.QuoteLineItem(nil), order.LineItems...)
+	}
+	if order.Package != nil {
+		pkg := cloneServicePackage(*order.Package)
+		clone.Package = &pkg
+	}
+	if len(order.AddOns) > 0 {
+		clone.AddOns = append([]models.AddOn(nil), order.AddOns...)
+	}
 	s.data.Orders = append(s.data.Orders, &clone)
 	serviceTitle := s.serviceTitleLocked(order.ServiceID)
 	customer := order.CustomerName
@@ -2129,10 +2480,7 @@ func (s *Store) CreateOrder(order *models.Order) (*models.Order, error) {
 			"highlight_type": "order_created",
 		},
 	})
-	if err := s.persistLocked(); err != nil {
-		return nil, err
-	}
-	return order, nil
+	return nil
 }
 
 func (s *Store) UpdateRequest(id uint, newStatus, reason string) (*models.Order, error) {
@@ -2200,6 +2548,9 @@ func (s *Store) UpdateOrderStatus(id uint, status string) (*models.Order, error)
 				o.CancelReason = ""
 			}
 			o.UpdatedAt = time.Now().UTC()
+			if status == "done" && prevStatus != "done" {
+				o.CompletedAt = o.UpdatedAt
+			}
 			statusLabel := formatStatus(status)
 			prevStatusLabel := formatStatus(prevStatus)
 			serviceTitle := s.serviceTitleLocked(o.ServiceID)
@@ -2231,52 +2582,6 @@ func (s *Store) UpdateOrderStatus(id uint, status string) (*models.Order, error)
 	return nil, os.ErrNotExist
 }
 
-func (s *Store) SetOrderRating(id uint, rating int, review string) (*models.Order, error) {
-	if rating < 1 || rating > 5 {
-		return nil, errors.New("rating must be between 1 and 5")
-	}
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.ensureLoaded()
-	for _, o := range s.data.Orders {
-		if o.ID == id {
-			if o.Status != "done" {
-				return nil, errors.New("order is not marked as done")
-			}
-			o.RatingValue = rating
-			o.RatingReview = review
-			now := time.Now().UTC()
-			o.RatedAt = now
-			o.UpdatedAt = now
-			serviceTitle := s.serviceTitleLocked(o.ServiceID)
-			desc := fmt.Sprintf("Rating %d/5", rating)
-			if review != "" {
-				desc = fmt.Sprintf("%s • \"%s\"", desc, review)
-			}
-			s.appendActivityLocked(&models.Activity{
-				Type:        "order",
-				Action:      "rated",
-				Title:       fmt.Sprintf("Order #%d diberi rating", o.ID),
-				Description: desc,
-				ReferenceID: o.ID,
-				Metadata: map[string]string{
-					"rating":         fmt.Sprintf("%d", rating),
-					"review":         review,
-					"service_title":  serviceTitle,
-					"service_id":     fmt.Sprintf("%d", o.ServiceID),
-					"highlight_type": "order_feedback",
-				},
-			})
-			if err := s.persistLocked(); err != nil {
-				return nil, err
-			}
-			clone := *o
-			return &clone, nil
-		}
-	}
-	return nil, os.ErrNotExist
-}
-
 func (s *Store) DeleteOrder(id uint) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -2293,6 +2598,9 @@ func (s *Store) DeleteOrder(id uint) error {
 	}
 	s.data.Orders = filtered
 	if deleted != nil {
+		s.deleteOrderMessagesLocked(deleted.ID)
+		s.deleteOrderReviewsLocked(deleted.ID)
+		s.restoreWaitlistReservationLocked(deleted.ID)
 		serviceTitle := s.serviceTitleLocked(deleted.ServiceID)
 		statusLabel := formatStatus(deleted.Status)
 		s.appendActivityLocked(&models.Activity{
@@ -2410,6 +2718,14 @@ func (s *Store) validatePromoLocked(promo *models.PromoCode, now time.Time) erro
 	return nil
 }
 
+// promoAllowedFor reports whether email may redeem promo. Codes handed out
+// to one customer, such as checkout recovery codes, carry that customer's
+// email.
+func promoAllowedFor(promo *models.PromoCode, email string) bool {
+	restricted := strings.TrimSpace(promo.CustomerEmail)
+	return restricted == "" || strings.EqualFold(restricted, strings.TrimSpace(email))
+}
+
 func normalizeGallerySection(section string) string {
 	return strings.TrimSpace(strings.ToLower(section))
 }
@@ -2442,6 +2758,28 @@ func sanitizeFilters(filters []string) []string {
 	return out
 }
 
+func sanitizeIDs(ids []uint) []uint {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := make([]uint, 0, len(ids))
+	seen := make(map[uint]struct{})
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, exists := seen[id]; exists {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 func sanitizeAssets(assets []models.GalleryAsset) []models.GalleryAsset {
 	if len(assets) == 0 {
 		return nil
@@ -2477,15 +2815,30 @@ func (s *Store) ListGalleryItems(section string) []models.GalleryItem {
 		}
 		out = append(out, cloneGalleryItem(item))
 	}
-	sort.Slice(out, func(i, j int) bool {
-		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
-			return strings.ToLower(out[i].Title) < strings.ToLower(out[j].Title)
-		}
-		return out[i].CreatedAt.After(out[j].CreatedAt)
-	})
+	sortGalleryItems(out)
 	return out
 }
 
+// sortGalleryItems puts pinned items first, then follows the curated sort
+// order. Items that were never positioned have order zero and show newest
+// first ahead of the curated ones.
+func sortGalleryItems(items []models.GalleryItem) {
+	sort.SliceStable(items, func(i, j int) bool { return galleryItemLess(&items[i], &items[j]) })
+}
+
+func galleryItemLess(a, b *models.GalleryItem) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	if a.SortOrder != b.SortOrder {
+		return a.SortOrder < b.SortOrder
+	}
+	if a.CreatedAt.Equal(b.CreatedAt) {
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	}
+	return a.CreatedAt.After(b.CreatedAt)
+}
+
 func (s *Store) GetGalleryItemByID(id uint) (*models.GalleryItem, bool) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -2515,12 +2868,19 @@ func (s *Store) CreateGalleryItem(item *models.GalleryItem) (*models.GalleryItem
 	item.VideoURL = strings.TrimSpace(item.VideoURL)
 	item.LinkURL = strings.TrimSpace(item.LinkURL)
 	item.Description = strings.TrimSpace(item.Description)
+	item.Images = mergeImageAssets(nil, item.Images, galleryImageURLs(item))
+	item.ServiceIDs = sanitizeIDs(item.ServiceIDs)
+	s.linkGalleryMediaLocked(item)
+	if item.PublishStatus == "" {
+		item.PublishStatus = PublishDraft
+	}
 	item.CreatedAt = now
 	item.UpdatedAt = now
 	clone := cloneGalleryItem(item)
 	clone.CreatedAt = item.CreatedAt
 	clone.UpdatedAt = item.UpdatedAt
 	s.data.GalleryItems = append(s.data.GalleryItems, &clone)
+	s.indexGalleryItemLocked(&clone)
 	s.appendActivityLocked(&models.Activity{
 		Type:        "gallery",
 		Action:      "created",
@@ -2538,7 +2898,7 @@ func (s *Store) CreateGalleryItem(item *models.GalleryItem) (*models.GalleryItem
 	return item, nil
 }
 
-func (s *Store) UpdateGalleryItem(id uint, update *models.GalleryItem) (*models.GalleryItem, error) {
+func (s *Store) UpdateGalleryItem(id uint, update *models.GalleryItem, author string) (*models.GalleryItem, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ensureLoaded()
@@ -2559,9 +2919,19 @@ func (s *Store) UpdateGalleryItem(id uint, update *models.GalleryItem) (*models.
 			item.Filters = sanitizeFilters(update.Filters)
 			item.Assets = sanitizeAssets(update.Assets)
 			item.VideoURL = strings.TrimSpace(update.VideoURL)
+			item.VideoEmbed = cloneLinkEmbed(update.VideoEmbed)
 			item.LinkURL = strings.TrimSpace(update.LinkURL)
+			item.LinkEmbed = cloneLinkEmbed(update.LinkEmbed)
 			item.Description = strings.TrimSpace(update.Description)
+			item.SortOrder = update.SortOrder
+			item.Featured = update.Featured
+			item.Pinned = update.Pinned
+			item.ServiceIDs = sanitizeIDs(update.ServiceIDs)
+			item.Images = mergeImageAssets(item.Images, update.Images, galleryImageURLs(item))
+			s.linkGalleryMediaLocked(item)
 			item.UpdatedAt = time.Now().UTC()
+			s.recordRevisionLocked(RevisionGallery, item.ID, "updated", author, &prev, item)
+			s.indexGalleryItemLocked(item)
 			s.appendActivityLocked(&models.Activity{
 				Type:        "gallery",
 				Action:      "updated",
@@ -2585,6 +2955,109 @@ func (s *Store) UpdateGalleryItem(id uint, update *models.GalleryItem) (*models.
 	return nil, os.ErrNotExist
 }
 
+type GalleryPosition struct {
+	ID       uint  `json:"id"`
+	Pinned   *bool `json:"pinned,omitempty"`
+	Featured *bool `json:"featured,omitempty"`
+}
+
+// ReorderGalleryItems assigns sort orders following the given positions and
+// applies any pinned or featured changes. Items left out keep their order.
+func (s *Store) ReorderGalleryItems(positions []GalleryPosition) ([]models.GalleryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	byID := make(map[uint]*models.GalleryItem, len(s.data.GalleryItems))
+	for _, item := range s.data.GalleryItems {
+		byID[item.ID] = item
+	}
+	for _, pos := range positions {
+		if byID[pos.ID] == nil {
+			return nil, fmt.Errorf("gallery item %d: %w", pos.ID, os.ErrNotExist)
+		}
+	}
+	// The listed items take over the slots they held between them, in the
+	// given order, and every item is numbered again so the ones left out
+	// keep their place instead of sharing a sort order with a moved item.
+	ordered := slices.Clone(s.data.GalleryItems)
+	sort.SliceStable(ordered, func(i, j int) bool { return galleryItemLess(ordered[i], ordered[j]) })
+	listed := make(map[uint]bool, len(positions))
+	for _, pos := range positions {
+		listed[pos.ID] = true
+	}
+	next := 0
+	for i, item := range ordered {
+		if listed[item.ID] {
+			ordered[i] = byID[positions[next].ID]
+			next++
+		}
+	}
+	now := time.Now().UTC()
+	for i, item := range ordered {
+		if item.SortOrder != i+1 {
+			item.SortOrder = i + 1
+			item.UpdatedAt = now
+		}
+	}
+	for _, pos := range positions {
+		item := byID[pos.ID]
+		if pos.Pinned != nil {
+			item.Pinned = *pos.Pinned
+		}
+		if pos.Featured != nil {
+			item.Featured = *pos.Featured
+		}
+		item.UpdatedAt = now
+	}
+	s.appendActivityLocked(&models.Activity{
+		Type:        "gallery",
+		Action:      "reordered",
+		Title:       "Urutan galeri diperbarui",
+		Description: fmt.Sprintf("%d item diurutkan ulang", len(positions)),
+	})
+	if err := s.persistLocked(); err != nil {
+		return nil, err
+	}
+	out := make([]models.GalleryItem, 0, len(s.data.GalleryItems))
+	for _, item := range s.data.GalleryItems {
+		out = append(out, cloneGalleryItem(item))
+	}
+	sortGalleryItems(out)
+	return out, nil
+}
+
+// BackfillGalleryEmbeds fills in embed data for gallery items saved before
+// links were parsed. Links the parser rejects are left without embed data.
+func (s *Store) BackfillGalleryEmbeds(parse func(string) (*models.LinkEmbed, error)) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	changed := 0
+	for _, item := range s.data.GalleryItems {
+		dirty := false
+		if item.VideoURL != "" && item.VideoEmbed == nil {
+			if embed, err := parse(item.VideoURL); err == nil {
+				item.VideoEmbed, dirty = embed, true
+			}
+		}
+		if item.LinkURL != "" && item.LinkEmbed == nil {
+			if embed, err := parse(item.LinkURL); err == nil {
+				item.LinkEmbed, dirty = embed, true
+			}
+		}
+		if dirty {
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	if err := s.persistLocked(); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
 func (s *Store) DeleteGalleryItem(id uint) (*models.GalleryItem, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -2601,6 +3074,8 @@ func (s *Store) DeleteGalleryItem(id uint) (*models.GalleryItem, error) {
 	}
 	s.data.GalleryItems = filtered
 	if deleted != nil {
+		s.search.remove(searchDocKey(SearchKindGallery, deleted.ID))
+		s.unlinkCaseStudyGalleryLocked(deleted.ID)
 		s.appendActivityLocked(&models.Activity{
 			Type:        "gallery",
 			Action:      "deleted",
@@ -2619,7 +3094,10 @@ func (s *Store) DeleteGalleryItem(id uint) (*models.GalleryItem, error) {
 	}
 	return nil, os.ErrNotExist
 }
-func (s *Store) ValidatePromoCode(code string, now time.Time) (*models.PromoCode, error) {
+
+// ValidatePromoCode checks a code before checkout. The customer restriction
+// is only checked when email is known; order creation always checks it.
+func (s *Store) ValidatePromoCode(code, email string, now time.Time) (*models.PromoCode, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	s.ensureLoaded()
@@ -2630,6 +3108,9 @@ func (s *Store) ValidatePromoCode(code string, now time.Time) (*models.PromoCode
 	if err := s.validatePromoLocked(promo, now); err != nil {
 		return nil, err
 	}
+	if email != "" && !promoAllowedFor(promo, email) {
+		return nil, ErrPromoNotEligible
+	}
 	clone := clonePromoCode(promo)
 	return &clone, nil
 }
@@ -2814,6 +3295,28 @@ func (s *Store) ListAnalyticsEvents(filter AnalyticsEventFilter) []models.Analyt
 	return out
 }
 
+func (s *Store) CountPageViews(prefix string) map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.ensureLoaded()
+	counts := make(map[string]int)
+	for _, ev := range s.data.AnalyticsEvents {
+		if !strings.EqualFold(ev.EventType, "page_view") || !strings.HasPrefix(ev.PagePath, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(ev.PagePath, prefix)
+		if idx := strings.IndexAny(rest, "?#"); idx >= 0 {
+			rest = rest[:idx]
+		}
+		rest = strings.ToLower(strings.Trim(rest, "/"))
+		if rest == "" || strings.Contains(rest, "/") {
+			continue
+		}
+		counts[rest]++
+	}
+	return counts
+}
+
 func (s *Store) GetAnalyticsSummary(opts AnalyticsSummaryOptions) AnalyticsSummary {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -3202,5 +3705,6 @@ func (s *Store) ensureSampleData() error {
 	if !changed {
 		return nil
 	}
+	s.rebuildSearchIndexLocked()
 	return s.persistLocked()
 }
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"encoding/json"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+// RewriteUploadURLs passes every public upload URL through rewrite and
+// persists the result. It returns the number of records that changed,
+// counting revision snapshots so restoring an old revision never brings back
+// a URL from before the migration. Order thread attachments and proofing
+// images are private and keep their /api/static/ URLs, which are only ever
+// served as signed links.
+func (s *Store) RewriteUploadURLs(rewrite func(string) string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensureLoaded()
+	changed := 0
+	for _, svc := range s.data.Services {
+		if rewriteServiceURLs(svc, rewrite) {
+			changed++
+		}
+	}
+	for _, item := range s.data.GalleryItems {
+		if rewriteGalleryURLs(item, rewrite) {
+			changed++
+		}
+	}
+	for _, cat := range s.data.Categories {
+		if rewriteURL(&cat.CoverImage, rewrite) {
+			changed++
+		}
+	}
+	for _, cs := range s.data.CaseStudies {
+		if rewriteCaseStudyURLs(cs, rewrite) {
+			changed++
+		}
+	}
+	for _, review := range s.data.Reviews {
+		if rewriteURLs(review.Photos, rewrite) {
+			changed++
+		}
+	}
+	for _, item := range s.data.Media {
+		dirty := rewriteURL(&item.URL, rewrite)
+		if item.Image != nil {
+			if images, ok := rewriteImageAssets(map[string]models.ImageAsset{"": *item.Image}, rewrite); ok {
+				image := images[""]
+				item.Image, dirty = &image, true
+			}
+		}
+		if dirty {
+			changed++
+		}
+	}
+	for _, rev := range s.data.Revisions {
+		if rewriteRevisionURLs(rev, rewrite) {
+			delete(s.revisionMedia, rev.ID)
+			changed++
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	s.rebuildSearchIndexLocked()
+	if err := s.persistLocked(); err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
+
+func rewriteURL(value *string, rewrite func(string) string) bool {
+	next := rewrite(*value)
+	if next == *value {
+		return false
+	}
+	*value = next
+	return true
+}
+
+func rewriteURLs(values []string, rewrite func(string) string) bool {
+	dirty := false
+	for i := range values {
+		if rewriteURL(&values[i], rewrite) {
+			dirty = true
+		}
+	}
+	return dirty
+}
+
+func rewriteServiceURLs(svc *models.Service, rewrite func(string) string) bool {
+	dirty := rewriteURL(&svc.Thumbnail, rewrite)
+	if rewriteURLs(svc.GalleryImages, rewrite) {
+		dirty = true
+	}
+	if images, ok := rewriteImageAssets(svc.Images, rewrite); ok {
+		svc.Images, dirty = images, true
+	}
+	return dirty
+}
+
+func rewriteGalleryURLs(item *models.GalleryItem, rewrite func(string) string) bool {
+	dirty := rewriteURL(&item.Thumbnail, rewrite)
+	for i := range item.Assets {
+		if rewriteURL(&item.Assets[i].URL, rewrite) {
+			dirty = true
+		}
+	}
+	if images, ok := rewriteImageAssets(item.Images, rewrite); ok {
+		item.Images, dirty = images, true
+	}
+	return dirty
+}
+
+func rewriteCaseStudyURLs(cs *models.CaseStudy, rewrite func(string) string) bool {
+	dirty := rewriteURL(&cs.CoverImage, rewrite)
+	for i := range cs.BeforeAfter {
+		if rewriteURL(&cs.BeforeAfter[i].Before, rewrite) {
+			dirty = true
+		}
+		if rewriteURL(&cs.BeforeAfter[i].After, rewrite) {
+			dirty = true
+		}
+	}
+	return dirty
+}
+
+// rewriteRevisionURLs rewrites the upload URLs inside a revision snapshot.
+// Snapshots that do not decode are left alone.
+func rewriteRevisionURLs(rev *models.Revision, rewrite func(string) string) bool {
+	var entity any
+	var dirty bool
+	switch rev.EntityType {
+	case RevisionService:
+		var snap models.Service
+		if json.Unmarshal(rev.Snapshot, &snap) != nil {
+			return false
+		}
+		entity, dirty = &snap, rewriteServiceURLs(&snap, rewrite)
+	case RevisionCategory:
+		var snap models.Category
+		if json.Unmarshal(rev.Snapshot, &snap) != nil {
+			return false
+		}
+		entity, dirty = &snap, rewriteURL(&snap.CoverImage, rewrite)
+	case RevisionGallery:
+		var snap models.GalleryItem
+		if json.Unmarshal(rev.Snapshot, &snap) != nil {
+			return false
+		}
+		entity, dirty = &snap, rewriteGalleryURLs(&snap, rewrite)
+	case RevisionCaseStudy:
+		var snap models.CaseStudy
+		if json.Unmarshal(rev.Snapshot, &snap) != nil {
+			return false
+		}
+		entity, dirty = &snap, rewriteCaseStudyURLs(&snap, rewrite)
+	}
+	if !dirty {
+		return false
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return false
+	}
+	rev.Snapshot = raw
+	return true
+}
+
+func rewriteImageAssets(images map[string]models.ImageAsset, rewrite func(string) string) (map[string]models.ImageAsset, bool) {
+	if len(images) == 0 {
+		return images, false
+	}
+	dirty := false
+	out := make(map[string]models.ImageAsset, len(images))
+	for key, asset := range images {
+		next := asset
+		next.Variants = append([]models.ImageVariant(nil), asset.Variants...)
+		for i := range next.Variants {
+			next.Variants[i].URL = rewrite(next.Variants[i].URL)
+		}
+		next.SrcSet = rewriteSrcSet(next.SrcSet, rewrite)
+		next.WebPSrcSet = rewriteSrcSet(next.WebPSrcSet, rewrite)
+		newKey := rewrite(key)
+		if newKey != key || next.SrcSet != asset.SrcSet || next.WebPSrcSet != asset.WebPSrcSet {
+			dirty = true
+		}
+		out[newKey] = next
+	}
+	return out, dirty
+}
+
+func rewriteSrcSet(srcset string, rewrite func(string) string) string {
+	if srcset == "" {
+		return srcset
+	}
+	entries := strings.Split(srcset, ", ")
+	for i, entry := range entries {
+		url, descriptor, _ := strings.Cut(entry, " ")
+		entries[i] = strings.TrimSpace(rewrite(url) + " " + descriptor)
+	}
+	return strings.Join(entries, ", ")
+}
@@ -20,6 +20,10 @@ func getenv(key, fallback string) string {
 }
 
 func SendEmail(to, subject, htmlBody, textBody string) error {
+	return SendEmailWithReplyTo(to, "", subject, htmlBody, textBody)
+}
+
+func SendEmailWithReplyTo(to, replyTo, subject, htmlBody, textBody string) error {
 	host := getenv("SMTP_HOST", "smtp.gmail.com")
 	portStr := getenv("SMTP_PORT", "587")
 	user := getenv("SMTP_USERNAME", "")
@@ -40,6 +44,9 @@ func SendEmail(to, subject, htmlBody, textBody string) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", from)
 	m.SetHeader("To", to)
+	if replyTo != "" {
+		m.SetHeader("Reply-To", replyTo)
+	}
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/plain", textBody)
 	m.AddAlternative("text/html", htmlBody)
@@ -262,17 +262,32 @@ func BuildOrderConfirmationEmail(order *models.Order, service *models.Service, p
 	if strings.TrimSpace(order.PaymentStatus) != "" {
 		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Status Pembayaran", Value: humanizePaymentStatus(order.PaymentStatus)})
 	}
+	if !order.BookingStart.IsZero() {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Jadwal", Value: formatDate(order.BookingStart)})
+	}
 	if !order.PaymentExpiresAt.IsZero() {
 		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Batas Pembayaran", Value: formatDate(order.PaymentExpiresAt)})
 	}
 	if strings.TrimSpace(order.Notes) != "" {
 		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Catatan", Value: order.Notes})
 	}
+	summaryItems = append(summaryItems, orderPackageSummaryItems(order)...)
 	lineItems := []EmailLineItem{{
 		Title:    serviceTitle,
 		Quantity: "1",
 		Amount:   formatCurrencyIDR(order.Amount),
 	}}
+	if len(order.LineItems) > 0 {
+		lineItems = make([]EmailLineItem, 0, len(order.LineItems))
+		for _, item := range order.LineItems {
+			lineItems = append(lineItems, EmailLineItem{
+				Title:       item.Title,
+				Description: item.Description,
+				Quantity:    strconv.Itoa(item.Quantity),
+				Amount:      formatCurrencyIDR(item.Amount),
+			})
+		}
+	}
 	data := EmailTemplateData{
 		Preheader:       fmt.Sprintf("Pesanan #%d berhasil kami terima", order.ID),
 		Title:           "Konfirmasi Pesanan",
@@ -304,6 +319,30 @@ func BuildOrderConfirmationEmail(order *models.Order, service *models.Service, p
 	return subject, htmlBody, textBody, nil
 }
 
+func orderPackageSummaryItems(order *models.Order) []EmailSummaryItem {
+	var items []EmailSummaryItem
+	if pkg := order.Package; pkg != nil {
+		items = append(items, EmailSummaryItem{Label: "Paket", Value: pkg.Name})
+		if pkg.DeliveryDays > 0 {
+			items = append(items, EmailSummaryItem{Label: "Estimasi Pengerjaan", Value: fmt.Sprintf("%d hari", pkg.DeliveryDays)})
+		}
+		switch {
+		case pkg.Revisions < 0:
+			items = append(items, EmailSummaryItem{Label: "Revisi", Value: "Tanpa batas"})
+		case pkg.Revisions > 0:
+			items = append(items, EmailSummaryItem{Label: "Revisi", Value: fmt.Sprintf("%dx", pkg.Revisions)})
+		}
+	}
+	if len(order.AddOns) > 0 {
+		names := make([]string, 0, len(order.AddOns))
+		for _, addOn := range order.AddOns {
+			names = append(names, addOn.Name)
+		}
+		items = append(items, EmailSummaryItem{Label: "Add-on", Value: strings.Join(names, ", ")})
+	}
+	return items
+}
+
 func BuildOrderStatusEmail(order *models.Order, service *models.Service, customMessage string) (string, string, string, error) {
 	if order == nil {
 		return "", "", "", fmt.Errorf("order is required")
@@ -322,6 +361,7 @@ func BuildOrderStatusEmail(order *models.Order, service *models.Service, customM
 		{Label: "Layanan", Value: serviceTitle},
 		{Label: "Status Terbaru", Value: humanizeOrderStatus(order.Status)},
 	}
+	summaryItems = append(summaryItems, orderPackageSummaryItems(order)...)
 	if strings.TrimSpace(order.PaymentStatus) != "" {
 		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Status Pembayaran", Value: humanizePaymentStatus(order.PaymentStatus)})
 	}
@@ -430,6 +470,450 @@ func BuildContactNotificationEmail(message *models.Message) (string, string, str
 	return subject, htmlBody, textBody, nil
 }
 
+func BuildOrderMessageEmail(order *models.Order, service *models.Service, message *models.OrderMessage, threadURL string) (string, string, string, error) {
+	if order == nil {
+		return "", "", "", fmt.Errorf("order is required")
+	}
+	if message == nil {
+		return "", "", "", fmt.Errorf("message is required")
+	}
+	branding := getEmailBranding()
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	sentAt := message.CreatedAt
+	if sentAt.IsZero() {
+		sentAt = time.Now()
+	}
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(order.CustomerName))
+	if strings.TrimSpace(order.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	intro := fmt.Sprintf("Ada pesan baru dari tim %s terkait pesanan Anda.", branding.Name)
+	if message.AuthorRole != "admin" {
+		greeting = fmt.Sprintf("Halo Tim %s,", branding.Name)
+		intro = fmt.Sprintf("%s mengirim pesan baru terkait pesanan #%d.", firstNonEmpty(message.AuthorName, order.CustomerName, "Pelanggan"), order.ID)
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Pesanan", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Layanan", Value: serviceTitle},
+		{Label: "Pengirim", Value: firstNonEmpty(message.AuthorName, message.AuthorEmail)},
+		{Label: "Dikirim", Value: formatDate(sentAt)},
+	}
+	if len(message.Attachments) > 0 {
+		names := make([]string, 0, len(message.Attachments))
+		for _, att := range message.Attachments {
+			names = append(names, firstNonEmpty(att.Name, att.URL))
+		}
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Lampiran", Value: strings.Join(names, ", ")})
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Pesan baru untuk pesanan #%d", order.ID),
+		Title:           "Pesan Baru pada Pesanan",
+		Greeting:        greeting,
+		IntroParagraphs: []string{intro},
+		SummaryTitle:    "Detail Pesan",
+		SummaryItems:    summaryItems,
+		BodyParagraphs:  []string{message.Body},
+		AdditionalParagraphs: []string{
+			"Anda dapat membalas email ini secara langsung, balasan Anda akan masuk ke percakapan pesanan.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if strings.TrimSpace(threadURL) != "" {
+		data.Button = &EmailButton{Label: "Buka Percakapan", URL: threadURL}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Pesan Baru • Order #%d", order.ID)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildQuoteRequestEmail(quote *models.QuoteRequest, subjectTitle string) (string, string, string, error) {
+	if quote == nil {
+		return "", "", "", fmt.Errorf("quote is required")
+	}
+	branding := getEmailBranding()
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Permintaan", Value: fmt.Sprintf("#%d", quote.ID)},
+		{Label: "Layanan", Value: firstNonEmpty(subjectTitle, "Layanan kustom")},
+		{Label: "Nama", Value: quote.CustomerName},
+		{Label: "Email", Value: quote.CustomerEmail},
+	}
+	if strings.TrimSpace(quote.CustomerPhone) != "" {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Telepon", Value: quote.CustomerPhone})
+	}
+	if strings.TrimSpace(quote.Budget) != "" {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Perkiraan Budget", Value: quote.Budget})
+	}
+	if strings.TrimSpace(quote.Deadline) != "" {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Tenggat", Value: quote.Deadline})
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Permintaan penawaran baru dari %s", quote.CustomerName),
+		Title:           "Permintaan Penawaran Baru",
+		Greeting:        fmt.Sprintf("Halo Tim %s,", branding.Name),
+		IntroParagraphs: []string{"Seorang calon klien meminta penawaran harga melalui website."},
+		SummaryTitle:    "Detail Permintaan",
+		SummaryItems:    summaryItems,
+		BodyParagraphs:  []string{quote.Details},
+		AdditionalParagraphs: []string{
+			"Siapkan penawaran melalui panel admin agar klien dapat meninjau dan menyetujuinya secara langsung.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh sistem %s.", branding.Name),
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Permintaan Penawaran #%d • %s", quote.ID, firstNonEmpty(subjectTitle, branding.Name))
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildQuoteOfferEmail(quote *models.QuoteRequest, service *models.Service, offerURL string) (string, string, string, error) {
+	if quote == nil {
+		return "", "", "", fmt.Errorf("quote is required")
+	}
+	branding := getEmailBranding()
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(quote.CustomerName))
+	if strings.TrimSpace(quote.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Penawaran", Value: fmt.Sprintf("#%d", quote.ID)},
+		{Label: "Layanan", Value: serviceTitle},
+	}
+	if !quote.OfferExpiresAt.IsZero() {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Berlaku Hingga", Value: formatDate(quote.OfferExpiresAt)})
+	}
+	lineItems := make([]EmailLineItem, 0, len(quote.OfferItems))
+	for _, item := range quote.OfferItems {
+		lineItems = append(lineItems, EmailLineItem{
+			Title:       item.Title,
+			Description: item.Description,
+			Quantity:    strconv.Itoa(item.Quantity),
+			Amount:      formatCurrencyIDR(item.Amount),
+		})
+	}
+	body := []string{}
+	if strings.TrimSpace(quote.OfferNotes) != "" {
+		body = append(body, quote.OfferNotes)
+	}
+	if strings.TrimSpace(quote.OfferTerms) != "" {
+		body = append(body, "Syarat & ketentuan: "+quote.OfferTerms)
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Penawaran untuk permintaan #%d sudah siap", quote.ID),
+		Title:           "Penawaran Harga",
+		Greeting:        greeting,
+		IntroParagraphs: []string{"Terima kasih telah menghubungi " + branding.Name + ". Berikut penawaran yang kami siapkan sesuai kebutuhan Anda."},
+		Highlight: &EmailHighlight{
+			Label: "Total Penawaran",
+			Value: formatCurrencyIDR(quote.OfferAmount),
+		},
+		SummaryTitle:   "Ringkasan Penawaran",
+		SummaryItems:   summaryItems,
+		LineItems:      lineItems,
+		BodyParagraphs: body,
+		AdditionalParagraphs: []string{
+			"Silakan tinjau penawaran ini lalu pilih terima atau tolak melalui tautan di bawah.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if strings.TrimSpace(offerURL) != "" {
+		data.Button = &EmailButton{Label: "Tinjau Penawaran", URL: offerURL}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Penawaran #%d • %s", quote.ID, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildCheckoutRecoveryEmail(order *models.Order, service *models.Service, step int, recoveryURL, unsubscribeURL string, promo *models.PromoCode) (string, string, string, error) {
+	if order == nil {
+		return "", "", "", fmt.Errorf("order is required")
+	}
+	branding := getEmailBranding()
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(order.CustomerName))
+	if strings.TrimSpace(order.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Pesanan", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Layanan", Value: serviceTitle},
+		{Label: "Total", Value: formatCurrencyIDR(order.Amount + order.PromoDiscountAmount)},
+	}
+	summaryItems = append(summaryItems, orderPackageSummaryItems(order)...)
+	intro := fmt.Sprintf("Pesanan Anda untuk layanan %s belum diselesaikan. Jangan khawatir, Anda masih bisa melanjutkannya kapan saja.", serviceTitle)
+	if step > 1 {
+		intro = fmt.Sprintf("Kami ingin mengingatkan kembali bahwa pesanan Anda untuk layanan %s masih menunggu pembayaran.", serviceTitle)
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Lanjutkan pesanan %s Anda", serviceTitle),
+		Title:           "Pesanan Anda Belum Selesai",
+		Greeting:        greeting,
+		IntroParagraphs: []string{intro},
+		SummaryTitle:    "Ringkasan Pesanan",
+		SummaryItems:    summaryItems,
+		BodyParagraphs: []string{
+			"Klik tombol di bawah untuk melanjutkan pembayaran dengan tautan baru.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if promo != nil && strings.TrimSpace(promo.Code) != "" {
+		data.Highlight = &EmailHighlight{
+			Label:       fmt.Sprintf("Diskon %s%% khusus untuk Anda", strconv.FormatFloat(promo.DiscountPercent, 'f', -1, 64)),
+			Value:       promo.Code,
+			Description: fmt.Sprintf("Gunakan kode ini sebelum %s.", formatDate(promo.ValidUntil)),
+		}
+	}
+	if strings.TrimSpace(recoveryURL) != "" {
+		data.Button = &EmailButton{Label: "Lanjutkan Pembayaran", URL: recoveryURL}
+	}
+	if strings.TrimSpace(unsubscribeURL) != "" {
+		data.AdditionalParagraphs = []string{fmt.Sprintf("Tidak ingin menerima pengingat seperti ini? Berhenti berlangganan di %s", unsubscribeURL)}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Lanjutkan Pesanan #%d • %s", order.ID, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildReviewRequestEmail(order *models.Order, service *models.Service, reviewURL string) (string, string, string, error) {
+	if order == nil {
+		return "", "", "", fmt.Errorf("order is required")
+	}
+	branding := getEmailBranding()
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(order.CustomerName))
+	if strings.TrimSpace(order.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Order", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Layanan", Value: serviceTitle},
+	}
+	if !order.CompletedAt.IsZero() {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Selesai Pada", Value: formatDate(order.CompletedAt)})
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Bagaimana pengalaman Anda dengan %s?", serviceTitle),
+		Title:           "Bagikan Pengalaman Anda",
+		Greeting:        greeting,
+		IntroParagraphs: []string{fmt.Sprintf("Terima kasih telah mempercayakan %s kepada kami. Kami ingin tahu pendapat Anda tentang hasil pekerjaan kami.", serviceTitle)},
+		SummaryTitle:    "Detail Order",
+		SummaryItems:    summaryItems,
+		BodyParagraphs: []string{
+			"Beri rating dan ulasan singkat, lengkap dengan foto hasil bila berkenan. Ulasan yang disetujui akan tampil di halaman layanan dengan nama tampilan pilihan Anda.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if strings.TrimSpace(reviewURL) != "" {
+		data.Button = &EmailButton{Label: "Tulis Ulasan", URL: reviewURL}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Bagaimana %s Anda? • %s", serviceTitle, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildReviewResubmittedEmail(order *models.Order, service *models.Service, review *models.Review) (string, string, string, error) {
+	if order == nil || review == nil {
+		return "", "", "", fmt.Errorf("order and review are required")
+	}
+	branding := getEmailBranding()
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Order", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Layanan", Value: serviceTitle},
+		{Label: "Nama Tampilan", Value: review.DisplayName},
+		{Label: "Rating", Value: fmt.Sprintf("%d/5", review.Rating)},
+	}
+	var body []string
+	if strings.TrimSpace(review.Comment) != "" {
+		body = append(body, "Ulasan baru: "+review.Comment)
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Ulasan order #%d diubah dan menunggu moderasi", order.ID),
+		Title:           "Ulasan Perlu Dimoderasi Ulang",
+		Greeting:        fmt.Sprintf("Halo Tim %s,", branding.Name),
+		IntroParagraphs: []string{"Klien mengubah ulasan yang sebelumnya sudah disetujui. Ulasan disembunyikan dari halaman layanan sampai disetujui kembali."},
+		SummaryTitle:    "Ringkasan Ulasan",
+		SummaryItems:    summaryItems,
+		BodyParagraphs:  body,
+		FooterNote:      fmt.Sprintf("Email ini dikirim otomatis oleh sistem %s.", branding.Name),
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Ulasan Order #%d Perlu Moderasi • %s", order.ID, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildWaitlistReservationEmail(entry *models.WaitlistEntry, service *models.Service, reservationURL string) (string, string, string, error) {
+	if entry == nil {
+		return "", "", "", fmt.Errorf("waitlist entry is required")
+	}
+	branding := getEmailBranding()
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(entry.CustomerName))
+	if strings.TrimSpace(entry.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	serviceTitle := "Layanan"
+	if service != nil && strings.TrimSpace(service.Title) != "" {
+		serviceTitle = service.Title
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Layanan", Value: serviceTitle},
+		{Label: "Reservasi Berlaku Hingga", Value: formatDate(entry.ReservationExpiresAt)},
+	}
+	if service != nil && service.Price > 0 {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Harga", Value: formatCurrencyIDR(service.Price)})
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("Slot %s kini tersedia untuk Anda", serviceTitle),
+		Title:           "Slot Anda Sudah Tersedia",
+		Greeting:        greeting,
+		IntroParagraphs: []string{fmt.Sprintf("Kabar baik! Slot untuk layanan %s kini tersedia dan sudah kami sisihkan untuk Anda.", serviceTitle)},
+		SummaryTitle:    "Detail Reservasi",
+		SummaryItems:    summaryItems,
+		BodyParagraphs: []string{
+			"Selesaikan pemesanan sebelum batas waktu di atas. Setelah itu, slot akan ditawarkan kepada pelanggan berikutnya di daftar tunggu.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if strings.TrimSpace(reservationURL) != "" {
+		data.Button = &EmailButton{Label: "Pesan Sekarang", URL: reservationURL}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Slot %s Tersedia • %s", serviceTitle, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildProofingReadyEmail(order *models.Order, gallery *models.ProofingGallery, galleryURL string) (string, string, string, error) {
+	if order == nil || gallery == nil {
+		return "", "", "", fmt.Errorf("order and gallery are required")
+	}
+	branding := getEmailBranding()
+	greeting := fmt.Sprintf("Halo %s,", strings.TrimSpace(order.CustomerName))
+	if strings.TrimSpace(order.CustomerName) == "" {
+		greeting = "Halo,"
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Order", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Galeri", Value: gallery.Title},
+		{Label: "Jumlah Foto", Value: strconv.Itoa(len(gallery.Images))},
+	}
+	if gallery.SelectionLimit > 0 {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Batas Pilihan", Value: fmt.Sprintf("%d foto", gallery.SelectionLimit)})
+	}
+	if !gallery.ExpiresAt.IsZero() {
+		summaryItems = append(summaryItems, EmailSummaryItem{Label: "Berlaku Hingga", Value: formatDate(gallery.ExpiresAt)})
+	}
+	body := []string{}
+	if strings.TrimSpace(gallery.Message) != "" {
+		body = append(body, gallery.Message)
+	}
+	additional := []string{"Tandai foto favorit, pilih foto yang ingin diedit, lalu kirim pilihan Anda agar kami dapat melanjutkan proses editing."}
+	if gallery.PasswordProtected {
+		additional = append(additional, "Galeri ini dilindungi kata sandi. Gunakan kata sandi yang kami bagikan secara terpisah.")
+	}
+	data := EmailTemplateData{
+		Preheader:            fmt.Sprintf("Foto untuk order #%d siap ditinjau", order.ID),
+		Title:                "Galeri Proofing Siap",
+		Greeting:             greeting,
+		IntroParagraphs:      []string{"Foto dari sesi Anda sudah siap untuk ditinjau."},
+		SummaryTitle:         "Detail Galeri",
+		SummaryItems:         summaryItems,
+		BodyParagraphs:       body,
+		AdditionalParagraphs: additional,
+		FooterNote:           fmt.Sprintf("Email ini dikirim otomatis oleh %s.", branding.Name),
+	}
+	if strings.TrimSpace(galleryURL) != "" {
+		data.Button = &EmailButton{Label: "Buka Galeri", URL: galleryURL}
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Galeri Proofing Order #%d • %s", order.ID, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
+func BuildProofingSubmittedEmail(order *models.Order, gallery *models.ProofingGallery) (string, string, string, error) {
+	if order == nil || gallery == nil {
+		return "", "", "", fmt.Errorf("order and gallery are required")
+	}
+	branding := getEmailBranding()
+	selected := make([]string, 0)
+	favorites := 0
+	for _, img := range gallery.Images {
+		if img.Selected {
+			selected = append(selected, firstNonEmpty(img.FileName, fmt.Sprintf("Foto #%d", img.ID)))
+		}
+		if img.Favorite {
+			favorites++
+		}
+	}
+	summaryItems := []EmailSummaryItem{
+		{Label: "Nomor Order", Value: fmt.Sprintf("#%d", order.ID)},
+		{Label: "Klien", Value: order.CustomerName},
+		{Label: "Galeri", Value: gallery.Title},
+		{Label: "Foto Dipilih", Value: strconv.Itoa(len(selected))},
+		{Label: "Favorit", Value: strconv.Itoa(favorites)},
+	}
+	body := []string{"Foto yang dipilih: " + strings.Join(selected, ", ")}
+	if strings.TrimSpace(gallery.SubmissionNote) != "" {
+		body = append(body, "Catatan klien: "+gallery.SubmissionNote)
+	}
+	data := EmailTemplateData{
+		Preheader:       fmt.Sprintf("%s mengirim pilihan foto untuk order #%d", order.CustomerName, order.ID),
+		Title:           "Pilihan Foto Diterima",
+		Greeting:        fmt.Sprintf("Halo Tim %s,", branding.Name),
+		IntroParagraphs: []string{"Klien telah mengirim pilihan akhir dari galeri proofing."},
+		SummaryTitle:    "Ringkasan Pilihan",
+		SummaryItems:    summaryItems,
+		BodyParagraphs:  body,
+		AdditionalParagraphs: []string{
+			"Lihat detail pilihan dan komentar per foto melalui panel admin sebelum memulai editing.",
+		},
+		FooterNote: fmt.Sprintf("Email ini dikirim otomatis oleh sistem %s.", branding.Name),
+	}
+	htmlBody, textBody, err := RenderEmailTemplate(data)
+	if err != nil {
+		return "", "", "", err
+	}
+	subject := fmt.Sprintf("Pilihan Proofing Order #%d • %s", order.ID, branding.Name)
+	return subject, htmlBody, textBody, nil
+}
+
 func buildPlainTextEmail(data EmailTemplateData) string {
 	var sections []string
 	if data.Title != "" {
@@ -627,6 +1111,10 @@ func humanizePaymentMethod(method string) string {
 		return "Gerai Retail"
 	case "qris":
 		return "QRIS"
+	case "offline_cash":
+		return "Tunai"
+	case "offline_transfer":
+		return "Transfer Manual"
 	default:
 		if method == "" {
 			return "-"
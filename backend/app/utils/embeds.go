@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+const (
+	EmbedYouTube     = "youtube"
+	EmbedVimeo       = "vimeo"
+	EmbedInstagram   = "instagram"
+	EmbedTikTok      = "tiktok"
+	EmbedBehance     = "behance"
+	EmbedGoogleDrive = "google_drive"
+
+	EmbedKindVideo   = "video"
+	EmbedKindPost    = "post"
+	EmbedKindProject = "project"
+	EmbedKindFile    = "file"
+)
+
+var (
+	ErrUnsupportedEmbed = errors.New("unsupported link host")
+	ErrInvalidEmbed     = errors.New("link does not point to a supported item")
+
+	youtubeIDPattern   = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+	numericIDPattern   = regexp.MustCompile(`^[0-9]+$`)
+	shortcodePattern   = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	driveFileIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{10,}$`)
+)
+
+// ParseEmbedLink recognizes links to YouTube, Vimeo, Instagram, TikTok,
+// Behance and Google Drive and describes how to embed them. Other hosts
+// return ErrUnsupportedEmbed; a known host without a usable ID returns
+// ErrInvalidEmbed.
+func ParseEmbedLink(raw string) (*models.LinkEmbed, error) {
+	raw = strings.TrimSpace(raw)
+	if raw != "" && !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, ErrInvalidEmbed
+	}
+	host := strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+	host = strings.TrimPrefix(host, "m.")
+	parts := strings.FieldsFunc(parsed.Path, func(r rune) bool { return r == '/' })
+	var embed *models.LinkEmbed
+	switch host {
+	case "youtube.com", "youtube-nocookie.com", "youtu.be", "music.youtube.com":
+		embed = parseYouTube(host, parts, parsed.Query())
+	case "vimeo.com", "player.vimeo.com":
+		embed = parseVimeo(parts, parsed.Query())
+	case "instagram.com":
+		embed = parseInstagram(parts)
+	case "tiktok.com":
+		embed = parseTikTok(parts)
+	case "vm.tiktok.com", "vt.tiktok.com":
+		return nil, fmt.Errorf("%w: use the full tiktok.com video link", ErrInvalidEmbed)
+	case "behance.net":
+		embed = parseBehance(parts)
+	case "drive.google.com", "docs.google.com":
+		embed = parseGoogleDrive(parts, parsed.Query())
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedEmbed, host)
+	}
+	if embed == nil {
+		return nil, ErrInvalidEmbed
+	}
+	return embed, nil
+}
+
+func parseYouTube(host string, parts []string, query url.Values) *models.LinkEmbed {
+	id, ratio := "", "16:9"
+	switch {
+	case host == "youtu.be" && len(parts) > 0:
+		id = parts[0]
+	case len(parts) >= 2 && (parts[0] == "embed" || parts[0] == "live" || parts[0] == "v"):
+		id = parts[1]
+	case len(parts) >= 2 && parts[0] == "shorts":
+		id, ratio = parts[1], "9:16"
+	case len(parts) > 0 && parts[0] == "watch":
+		id = query.Get("v")
+	}
+	if !youtubeIDPattern.MatchString(id) {
+		return nil
+	}
+	canonical := "https://www.youtube.com/watch?v=" + id
+	if ratio == "9:16" {
+		canonical = "https://www.youtube.com/shorts/" + id
+	}
+	return &models.LinkEmbed{
+		Provider:     EmbedYouTube,
+		Kind:         EmbedKindVideo,
+		ID:           id,
+		URL:          canonical,
+		EmbedURL:     "https://www.youtube-nocookie.com/embed/" + id,
+		ThumbnailURL: "https://i.ytimg.com/vi/" + id + "/hqdefault.jpg",
+		AspectRatio:  ratio,
+	}
+}
+
+func parseVimeo(parts []string, query url.Values) *models.LinkEmbed {
+	id, hash := "", query.Get("h")
+	for i, part := range parts {
+		if numericIDPattern.MatchString(part) {
+			id = part
+			if hash == "" && i+1 < len(parts) && shortcodePattern.MatchString(parts[i+1]) {
+				hash = parts[i+1]
+			}
+			break
+		}
+	}
+	if id == "" {
+		return nil
+	}
+	embedURL := "https://player.vimeo.com/video/" + id
+	canonical := "https://vimeo.com/" + id
+	if hash != "" {
+		embedURL += "?h=" + url.QueryEscape(hash)
+		canonical += "/" + hash
+	}
+	return &models.LinkEmbed{
+		Provider:     EmbedVimeo,
+		Kind:         EmbedKindVideo,
+		ID:           id,
+		URL:          canonical,
+		EmbedURL:     embedURL,
+		ThumbnailURL: "https://vumbnail.com/" + id + ".jpg",
+		AspectRatio:  "16:9",
+	}
+}
+
+func parseInstagram(parts []string) *models.LinkEmbed {
+	for i := 0; i+1 < len(parts); i++ {
+		kind, code := parts[i], parts[i+1]
+		if (kind != "p" && kind != "reel" && kind != "reels" && kind != "tv") || !shortcodePattern.MatchString(code) {
+			continue
+		}
+		if kind == "reels" {
+			kind = "reel"
+		}
+		embed := &models.LinkEmbed{
+			Provider:     EmbedInstagram,
+			Kind:         EmbedKindPost,
+			ID:           code,
+			URL:          fmt.Sprintf("https://www.instagram.com/%s/%s/", kind, code),
+			EmbedURL:     fmt.Sprintf("https://www.instagram.com/%s/%s/embed/", kind, code),
+			ThumbnailURL: fmt.Sprintf("https://www.instagram.com/p/%s/media/?size=l", code),
+			AspectRatio:  "4:5",
+		}
+		if kind != "p" {
+			embed.Kind, embed.AspectRatio = EmbedKindVideo, "9:16"
+		}
+		return embed
+	}
+	return nil
+}
+
+// parseTikTok only accepts full video URLs. Short links need a network round
+// trip to resolve.
+func parseTikTok(parts []string) *models.LinkEmbed {
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] != "video" || !numericIDPattern.MatchString(parts[i+1]) {
+			continue
+		}
+		id := parts[i+1]
+		canonical := "https://www.tiktok.com/video/" + id
+		if i > 0 && strings.HasPrefix(parts[i-1], "@") {
+			canonical = "https://www.tiktok.com/" + parts[i-1] + "/video/" + id
+		}
+		return &models.LinkEmbed{
+			Provider:    EmbedTikTok,
+			Kind:        EmbedKindVideo,
+			ID:          id,
+			URL:         canonical,
+			EmbedURL:    "https://www.tiktok.com/embed/v2/" + id,
+			AspectRatio: "9:16",
+		}
+	}
+	return nil
+}
+
+func parseBehance(parts []string) *models.LinkEmbed {
+	for i := 0; i+1 < len(parts); i++ {
+		if (parts[i] != "gallery" && parts[i] != "project") || !numericIDPattern.MatchString(parts[i+1]) {
+			continue
+		}
+		id := parts[i+1]
+		canonical := "https://www.behance.net/gallery/" + id
+		if i+2 < len(parts) {
+			canonical += "/" + parts[i+2]
+		}
+		return &models.LinkEmbed{
+			Provider:    EmbedBehance,
+			Kind:        EmbedKindProject,
+			ID:          id,
+			URL:         canonical,
+			EmbedURL:    "https://www.behance.net/embed/project/" + id + "?ilo0=1",
+			AspectRatio: "4:3",
+		}
+	}
+	return nil
+}
+
+func parseGoogleDrive(parts []string, query url.Values) *models.LinkEmbed {
+	id := ""
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "d" {
+			id = parts[i+1]
+			break
+		}
+	}
+	if id == "" {
+		id = query.Get("id")
+	}
+	if !driveFileIDPattern.MatchString(id) {
+		return nil
+	}
+	return &models.LinkEmbed{
+		Provider:     EmbedGoogleDrive,
+		Kind:         EmbedKindFile,
+		ID:           id,
+		URL:          "https://drive.google.com/file/d/" + id + "/view",
+		EmbedURL:     "https://drive.google.com/file/d/" + id + "/preview",
+		ThumbnailURL: "https://drive.google.com/thumbnail?id=" + id + "&sz=w1280",
+		AspectRatio:  "16:9",
+	}
+}
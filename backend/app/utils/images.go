@@ -0,0 +1,429 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"devara-creative-backend/app/models"
+)
+
+const placeholderWidth = 16
+
+var ErrUnsupportedImage = errors.New("unsupported image format")
+
+type ImageOptions struct {
+	Widths       []int
+	MaxDimension int
+	JPEGQuality  int
+	WebPQuality  int
+	WebPEncoder  string
+	Watermark    *Watermark
+}
+
+func ParseImageWidths(raw string) []int {
+	var widths []int
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		w, err := strconv.Atoi(part)
+		if err != nil || w <= 0 {
+			log.Printf("ignoring invalid image variant width %q", part)
+			continue
+		}
+		if seen[w] {
+			continue
+		}
+		seen[w] = true
+		widths = append(widths, w)
+	}
+	sort.Ints(widths)
+	return widths
+}
+
+type ImageFile struct {
+	Suffix      string
+	Ext         string
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+type ProcessedImage struct {
+	Format      string
+	Width       int
+	Height      int
+	Placeholder string
+	Files       []ImageFile
+}
+
+// ProcessImage re-encodes a JPEG or PNG without its metadata and renders the
+// configured width variants, applying the watermark when one is set. The
+// original size has an empty suffix and comes last among files of the same
+// format.
+func ProcessImage(data []byte, opts ImageOptions) (*ProcessedImage, error) {
+	img, format, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	if opts.MaxDimension > 0 && (bounds.Dx() > opts.MaxDimension || bounds.Dy() > opts.MaxDimension) {
+		w, h := fitDimensions(bounds.Dx(), bounds.Dy(), opts.MaxDimension)
+		img = resizeImage(img, w, h)
+	}
+	opts.Watermark.Apply(img)
+	ext, contentType := ".jpg", "image/jpeg"
+	if format == "png" {
+		ext, contentType = ".png", "image/png"
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	out := &ProcessedImage{Format: format, Width: width, Height: height, Placeholder: imagePlaceholder(img)}
+	var sizes []ImageFile
+	for _, w := range opts.Widths {
+		if w >= width {
+			continue
+		}
+		resized := resizeImage(img, w, max(1, height*w/width))
+		encoded, err := encodeImage(resized, format, opts.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		b := resized.Bounds()
+		sizes = append(sizes, ImageFile{Suffix: fmt.Sprintf("-%dw", w), Ext: ext, ContentType: contentType, Width: b.Dx(), Height: b.Dy(), Data: encoded})
+	}
+	encoded, err := encodeImage(img, format, opts.JPEGQuality)
+	if err != nil {
+		return nil, err
+	}
+	sizes = append(sizes, ImageFile{Ext: ext, ContentType: contentType, Width: width, Height: height, Data: encoded})
+	out.Files = append(out.Files, sizes...)
+	if opts.WebPEncoder != "" {
+		for _, size := range sizes {
+			webp, err := encodeWebP(opts.WebPEncoder, size.Data, size.Ext, opts.WebPQuality)
+			if err != nil {
+				log.Printf("skipping webp variants: %v", err)
+				break
+			}
+			out.Files = append(out.Files, ImageFile{Suffix: size.Suffix, Ext: ".webp", ContentType: "image/webp", Width: size.Width, Height: size.Height, Data: webp})
+		}
+	}
+	return out, nil
+}
+
+// Asset describes the processed files using urlFor to map each suffix and
+// extension to its public URL.
+func (p *ProcessedImage) Asset(urlFor func(suffix, ext string) string) models.ImageAsset {
+	asset := models.ImageAsset{Width: p.Width, Height: p.Height, Format: p.Format, Placeholder: p.Placeholder}
+	var srcset, webpSrcset []string
+	for _, file := range p.Files {
+		format := p.Format
+		if file.Ext == ".webp" {
+			format = "webp"
+		}
+		u := urlFor(file.Suffix, file.Ext)
+		asset.Variants = append(asset.Variants, models.ImageVariant{URL: u, Width: file.Width, Height: file.Height, Format: format})
+		entry := fmt.Sprintf("%s %dw", u, file.Width)
+		if format == "webp" {
+			webpSrcset = append(webpSrcset, entry)
+		} else {
+			srcset = append(srcset, entry)
+		}
+	}
+	asset.SrcSet = strings.Join(srcset, ", ")
+	asset.WebPSrcSet = strings.Join(webpSrcset, ", ")
+	return asset
+}
+
+func decodeImage(data []byte) (*image.NRGBA, string, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return nil, "", ErrUnsupportedImage
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	nrgba := toNRGBA(img)
+	if format == "jpeg" {
+		nrgba = applyOrientation(nrgba, jpegOrientation(data))
+	}
+	return nrgba, format, nil
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
+
+func fitDimensions(w, h, limit int) (int, int) {
+	if w >= h {
+		return limit, max(1, h*limit/w)
+	}
+	return max(1, w*limit/h), limit
+}
+
+// resizeImage downsamples with an alpha-weighted box filter, which is enough
+// for the shrink-only variants produced here.
+func resizeImage(src *image.NRGBA, width, height int) *image.NRGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if width >= sw && height >= sh {
+		return src
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := max((y+1)*sh/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := max((x+1)*sw/width, x0+1)
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				off := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					pa := uint64(src.Pix[off+3])
+					r += uint64(src.Pix[off]) * pa
+					g += uint64(src.Pix[off+1]) * pa
+					b += uint64(src.Pix[off+2]) * pa
+					a += pa
+					n++
+					off += 4
+				}
+			}
+			i := dst.PixOffset(x, y)
+			if a > 0 {
+				dst.Pix[i] = uint8(r / a)
+				dst.Pix[i+1] = uint8(g / a)
+				dst.Pix[i+2] = uint8(b / a)
+			}
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+func encodeImage(img *image.NRGBA, format string, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == "png" {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality(quality)})
+	}
+	return buf.Bytes(), err
+}
+
+func jpegQuality(q int) int {
+	if q <= 0 || q > 100 {
+		return 82
+	}
+	return q
+}
+
+func encodeWebP(encoder string, data []byte, ext string, quality int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "webp-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "input"+ext)
+	output := filepath.Join(dir, "output.webp")
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(encoder, "-quiet", "-q", strconv.Itoa(jpegQuality(quality)), "-metadata", "none", input, "-o", output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("webp encode: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return os.ReadFile(output)
+}
+
+// StripImageMetadata removes embedded metadata from formats the image
+// pipeline stores as uploaded. GIF files are re-encoded, which drops comment
+// and application extensions; WebP files lose their EXIF and XMP chunks.
+func StripImageMetadata(data []byte, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/gif":
+		return stripGIFMetadata(data)
+	case "image/webp":
+		return stripWebPMetadata(data)
+	}
+	return data, nil
+}
+
+func stripGIFMetadata(data []byte) ([]byte, error) {
+	img, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+func stripWebPMetadata(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrUnsupportedImage
+	}
+	out := append([]byte(nil), data[:12]...)
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, ErrUnsupportedImage
+		}
+		fourcc := string(data[i : i+4])
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		if i+8+size > len(data) {
+			return nil, ErrUnsupportedImage
+		}
+		// The final chunk sometimes lacks its padding byte.
+		end := min(i+8+size+size%2, len(data))
+		switch fourcc {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := append([]byte(nil), data[i:end]...)
+			if size > 0 {
+				chunk[8] &^= webpFlagEXIF | webpFlagXMP
+			}
+			out = append(out, chunk...)
+		default:
+			out = append(out, data[i:end]...)
+		}
+		if (end-i)%2 == 1 {
+			out = append(out, 0)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
+
+func imagePlaceholder(img *image.NRGBA) string {
+	b := img.Bounds()
+	w := min(placeholderWidth, b.Dx())
+	h := max(1, b.Dy()*w/b.Dx())
+	small := resizeImage(img, w, h)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, small, &jpeg.Options{Quality: 40}); err != nil {
+		return ""
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if o := int(order.Uint16(tiff[entry+8:])); o >= 1 && o <= 8 {
+				return o
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+func applyOrientation(src *image.NRGBA, orientation int) *image.NRGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -7,10 +7,9 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
-	"strings"
 )
 
-func randomName(n int) string {
+func RandomName(n int) string {
 	b := make([]byte, n)
 	rand.Read(b)
 	return hex.EncodeToString(b)
@@ -23,8 +22,11 @@ func SaveUploadedFile(fh *multipart.FileHeader, dir string) (string, error) {
 		return "", err
 	}
 	defer src.Close()
-	ext := strings.ToLower(filepath.Ext(fh.Filename))
-	name := randomName(16) + ext
+	ext := ".bin"
+	if sniffed, err := SniffUpload(fh); err == nil && sniffed.Ext != "" {
+		ext = sniffed.Ext
+	}
+	name := RandomName(16) + ext
 	dstPath := filepath.Join(dir, name)
 	dst, err := os.Create(dstPath)
 	if err != nil {
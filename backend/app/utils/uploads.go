@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	UploadImage    = "image"
+	UploadVideo    = "video"
+	UploadDocument = "document"
+
+	sniffLength = 512
+)
+
+type UploadRule struct {
+	Types        []string
+	MaxBytes     int64
+	MaxPixels    int
+	MaxDimension int
+}
+
+type UploadPolicy map[string]UploadRule
+
+type UploadError struct {
+	Status  int
+	Message string
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+type SniffedFile struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+}
+
+type fileSignature struct {
+	contentType string
+	ext         string
+	match       func([]byte) bool
+}
+
+var fileSignatures = []fileSignature{
+	{"image/jpeg", ".jpg", prefixMatch("\xFF\xD8\xFF")},
+	{"image/png", ".png", prefixMatch("\x89PNG\r\n\x1a\n")},
+	{"image/gif", ".gif", func(b []byte) bool {
+		return bytes.HasPrefix(b, []byte("GIF87a")) || bytes.HasPrefix(b, []byte("GIF89a"))
+	}},
+	{"image/webp", ".webp", func(b []byte) bool {
+		return len(b) >= 12 && string(b[:4]) == "RIFF" && string(b[8:12]) == "WEBP"
+	}},
+	{"video/mp4", ".mp4", ftypMatch("isom", "iso2", "iso5", "iso6", "mp41", "mp42", "avc1", "dash", "M4V ")},
+	{"video/quicktime", ".mov", ftypMatch("qt  ")},
+	{"video/webm", ".webm", prefixMatch("\x1A\x45\xDF\xA3")},
+	{"application/pdf", ".pdf", prefixMatch("%PDF-")},
+	{"application/zip", ".zip", prefixMatch("PK\x03\x04")},
+}
+
+var zipDocumentExts = map[string]bool{".docx": true, ".xlsx": true, ".pptx": true}
+
+var markupPrefixes = []string{"<?xml", "<svg", "<!doctype", "<html", "<script", "<head", "<body", "<iframe"}
+
+func prefixMatch(prefix string) func([]byte) bool {
+	return func(b []byte) bool { return bytes.HasPrefix(b, []byte(prefix)) }
+}
+
+func ftypMatch(brands ...string) func([]byte) bool {
+	return func(b []byte) bool {
+		if len(b) < 12 || string(b[4:8]) != "ftyp" {
+			return false
+		}
+		major := string(b[8:12])
+		for _, brand := range brands {
+			if major == brand {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func DefaultUploadPolicy() UploadPolicy {
+	return UploadPolicy{
+		UploadImage: {
+			Types:        []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+			MaxBytes:     15 << 20,
+			MaxPixels:    40_000_000,
+			MaxDimension: 12000,
+		},
+		UploadVideo: {
+			Types:    []string{"video/mp4", "video/quicktime", "video/webm"},
+			MaxBytes: 200 << 20,
+		},
+		UploadDocument: {
+			Types:    []string{"application/pdf", "application/zip"},
+			MaxBytes: 25 << 20,
+		},
+	}
+}
+
+// SniffUpload identifies a file from its leading bytes, ignoring the client
+// supplied name and Content-Type.
+func SniffUpload(fh *multipart.FileHeader) (*SniffedFile, error) {
+	src, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	head = head[:n]
+	for _, sig := range fileSignatures {
+		if !sig.match(head) {
+			continue
+		}
+		sniffed := &SniffedFile{ContentType: sig.contentType, Ext: sig.ext}
+		if sig.contentType == "application/zip" {
+			if ext := strings.ToLower(filepath.Ext(fh.Filename)); zipDocumentExts[ext] {
+				sniffed.Ext = ext
+			}
+		}
+		if strings.HasPrefix(sig.contentType, "image/") {
+			if _, err := src.Seek(0, io.SeekStart); err == nil {
+				sniffed.Width, sniffed.Height = imageDimensions(src, sig.contentType, head)
+			}
+		}
+		return sniffed, nil
+	}
+	if isMarkup(head) {
+		return &SniffedFile{ContentType: "text/html"}, nil
+	}
+	return &SniffedFile{ContentType: http.DetectContentType(head)}, nil
+}
+
+func isMarkup(head []byte) bool {
+	text := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(string(head), "\xEF\xBB\xBF")))
+	for _, prefix := range markupPrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(text, "<svg")
+}
+
+func imageDimensions(r io.Reader, contentType string, head []byte) (int, int) {
+	if contentType == "image/webp" {
+		return webpDimensions(head)
+	}
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+func webpDimensions(b []byte) (int, int) {
+	if len(b) < 30 {
+		return 0, 0
+	}
+	switch string(b[12:16]) {
+	case "VP8X":
+		w := int(b[24]) | int(b[25])<<8 | int(b[26])<<16
+		h := int(b[27]) | int(b[28])<<8 | int(b[29])<<16
+		return w + 1, h + 1
+	case "VP8 ":
+		if b[23] != 0x9d || b[24] != 0x01 || b[25] != 0x2a {
+			return 0, 0
+		}
+		return int(binary.LittleEndian.Uint16(b[26:]) & 0x3fff), int(binary.LittleEndian.Uint16(b[28:]) & 0x3fff)
+	case "VP8L":
+		if b[20] != 0x2f {
+			return 0, 0
+		}
+		w := 1 + (int(b[21]) | int(b[22]&0x3f)<<8)
+		h := 1 + (int(b[22])>>6 | int(b[23])<<2 | int(b[24]&0x0f)<<10)
+		return w, h
+	}
+	return 0, 0
+}
+
+// Check validates an upload against the rules of the first kind whose
+// allow-list contains its sniffed type.
+func (p UploadPolicy) Check(fh *multipart.FileHeader, kinds ...string) (*SniffedFile, error) {
+	sniffed, err := SniffUpload(fh)
+	if err != nil {
+		return nil, &UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s: file could not be read", fh.Filename)}
+	}
+	if sniffed.ContentType == "text/html" {
+		return nil, &UploadError{Status: http.StatusUnsupportedMediaType, Message: fmt.Sprintf("%s: HTML and SVG uploads are not allowed", fh.Filename)}
+	}
+	for _, kind := range kinds {
+		rule, ok := p[kind]
+		if !ok || !containsType(rule.Types, sniffed.ContentType) {
+			continue
+		}
+		if rule.MaxBytes > 0 && fh.Size > rule.MaxBytes {
+			return nil, &UploadError{
+				Status:  http.StatusRequestEntityTooLarge,
+				Message: fmt.Sprintf("%s: %s exceeds the %s limit", fh.Filename, formatBytes(fh.Size), formatBytes(rule.MaxBytes)),
+			}
+		}
+		if kind == UploadImage {
+			if sniffed.Width == 0 || sniffed.Height == 0 {
+				return nil, &UploadError{Status: http.StatusBadRequest, Message: fmt.Sprintf("%s: image dimensions could not be read", fh.Filename)}
+			}
+			if rule.MaxDimension > 0 && (sniffed.Width > rule.MaxDimension || sniffed.Height > rule.MaxDimension) {
+				return nil, &UploadError{
+					Status:  http.StatusBadRequest,
+					Message: fmt.Sprintf("%s: %dx%d exceeds the maximum of %dpx per side", fh.Filename, sniffed.Width, sniffed.Height, rule.MaxDimension),
+				}
+			}
+			if rule.MaxPixels > 0 && sniffed.Width*sniffed.Height > rule.MaxPixels {
+				return nil, &UploadError{
+					Status:  http.StatusBadRequest,
+					Message: fmt.Sprintf("%s: %dx%d exceeds the %g megapixel limit", fh.Filename, sniffed.Width, sniffed.Height, float64(rule.MaxPixels)/1e6),
+				}
+			}
+		}
+		return sniffed, nil
+	}
+	return nil, &UploadError{
+		Status:  http.StatusUnsupportedMediaType,
+		Message: fmt.Sprintf("%s: file type %s is not allowed here (expected %s)", fh.Filename, sniffed.ContentType, strings.Join(kinds, " or ")),
+	}
+}
+
+func containsType(types []string, contentType string) bool {
+	for _, t := range types {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.0f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
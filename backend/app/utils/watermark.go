@@ -0,0 +1,237 @@
+package utils
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	proofBandSpacing = 96
+	proofBandWidth   = 18
+	proofBandAlpha   = 72
+)
+
+var WatermarkPositions = []string{"center", "top-left", "top-right", "bottom-left", "bottom-right", "tile"}
+
+// Watermark is a text or logo mark blended onto processed images. Scale is
+// the mark width as a fraction of the image width.
+type Watermark struct {
+	Text     string
+	Logo     *image.NRGBA
+	LogoPath string
+	Position string
+	Opacity  float64
+	Scale    float64
+}
+
+// LoadWatermark builds a watermark from its settings, decoding the logo when
+// logoPath is set. A logo takes precedence over text.
+func LoadWatermark(text, logoPath, position string, opacity, scale float64) (*Watermark, error) {
+	w := &Watermark{
+		Text:     strings.TrimSpace(text),
+		LogoPath: strings.TrimSpace(logoPath),
+		Position: strings.ToLower(strings.TrimSpace(position)),
+		Opacity:  opacity,
+		Scale:    scale,
+	}
+	if !isWatermarkPosition(w.Position) {
+		w.Position = "bottom-right"
+	}
+	if w.Opacity <= 0 || w.Opacity > 1 {
+		w.Opacity = 0.35
+	}
+	if w.Scale <= 0 || w.Scale > 1 {
+		w.Scale = 0.25
+	}
+	if w.LogoPath != "" {
+		data, err := os.ReadFile(w.LogoPath)
+		if err != nil {
+			return w, err
+		}
+		logo, _, err := decodeImage(data)
+		if err != nil {
+			return w, fmt.Errorf("%s: %w", w.LogoPath, err)
+		}
+		w.Logo = logo
+	}
+	return w, nil
+}
+
+func isWatermarkPosition(position string) bool {
+	for _, p := range WatermarkPositions {
+		if p == position {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Watermark) Enabled() bool {
+	return w != nil && (w.Logo != nil || w.Text != "")
+}
+
+// Apply blends the watermark onto img in place.
+func (w *Watermark) Apply(img *image.NRGBA) {
+	if !w.Enabled() {
+		return
+	}
+	b := img.Bounds()
+	target := max(1, int(float64(b.Dx())*w.Scale))
+	mark := w.render(target)
+	if mark == nil {
+		return
+	}
+	mw, mh := mark.Bounds().Dx(), mark.Bounds().Dy()
+	margin := min(b.Dx(), b.Dy()) * 3 / 100
+	if w.Position == "tile" {
+		stepX, stepY := mw+mw/2, mh*3
+		for y, row := margin, 0; y < b.Dy(); y, row = y+stepY, row+1 {
+			for x := margin - (row%2)*stepX/2; x < b.Dx(); x += stepX {
+				blendMark(img, mark, x, y, w.Opacity)
+			}
+		}
+		return
+	}
+	x, y := (b.Dx()-mw)/2, (b.Dy()-mh)/2
+	if strings.HasPrefix(w.Position, "top") {
+		y = margin
+	}
+	if strings.HasPrefix(w.Position, "bottom") {
+		y = b.Dy() - mh - margin
+	}
+	if strings.HasSuffix(w.Position, "left") {
+		x = margin
+	}
+	if strings.HasSuffix(w.Position, "right") {
+		x = b.Dx() - mw - margin
+	}
+	blendMark(img, mark, x, y, w.Opacity)
+}
+
+func (w *Watermark) render(width int) *image.NRGBA {
+	if w.Logo != nil {
+		lb := w.Logo.Bounds()
+		if width >= lb.Dx() {
+			return w.Logo
+		}
+		return resizeImage(w.Logo, width, max(1, lb.Dy()*width/lb.Dx()))
+	}
+	return renderText(w.Text, width)
+}
+
+// renderText draws text with the built-in bitmap font, scaled up in whole
+// pixels so it fits within width, with a soft shadow for light backgrounds.
+func renderText(text string, width int) *image.NRGBA {
+	runes := []rune(strings.ToUpper(text))
+	if len(runes) == 0 {
+		return nil
+	}
+	cols := len(runes)*6 - 1
+	cell := max(1, width/cols)
+	shadow := max(1, cell/2)
+	out := image.NewNRGBA(image.Rect(0, 0, cols*cell+shadow, 7*cell+shadow))
+	draw := func(ox, oy int, v uint8, alpha uint8) {
+		for i, r := range runes {
+			glyph, ok := font5x7[r]
+			if !ok && !unicode.IsSpace(r) {
+				glyph = font5x7['?']
+			}
+			for row := 0; row < 7; row++ {
+				for col := 0; col < 5; col++ {
+					if glyph[row]&(1<<(4-col)) == 0 {
+						continue
+					}
+					x0, y0 := ox+(i*6+col)*cell, oy+row*cell
+					for y := y0; y < y0+cell; y++ {
+						off := out.PixOffset(x0, y)
+						for x := 0; x < cell; x++ {
+							out.Pix[off], out.Pix[off+1], out.Pix[off+2], out.Pix[off+3] = v, v, v, alpha
+							off += 4
+						}
+					}
+				}
+			}
+		}
+	}
+	draw(shadow, shadow, 0, 128)
+	draw(0, 0, 255, 255)
+	return out
+}
+
+func blendMark(dst, mark *image.NRGBA, ox, oy int, opacity float64) {
+	db, mb := dst.Bounds(), mark.Bounds()
+	for y := 0; y < mb.Dy(); y++ {
+		dy := oy + y
+		if dy < 0 || dy >= db.Dy() {
+			continue
+		}
+		for x := 0; x < mb.Dx(); x++ {
+			dx := ox + x
+			if dx < 0 || dx >= db.Dx() {
+				continue
+			}
+			mo := mark.PixOffset(mb.Min.X+x, mb.Min.Y+y)
+			a := float64(mark.Pix[mo+3]) / 255 * opacity
+			if a <= 0 {
+				continue
+			}
+			do := dst.PixOffset(db.Min.X+dx, db.Min.Y+dy)
+			for c := 0; c < 3; c++ {
+				dst.Pix[do+c] = uint8(float64(dst.Pix[do+c])*(1-a) + float64(mark.Pix[mo+c])*a + 0.5)
+			}
+			if dst.Pix[do+3] < 255 {
+				dst.Pix[do+3] = uint8(float64(dst.Pix[do+3])*(1-a) + 255*a + 0.5)
+			}
+		}
+	}
+}
+
+// RenderProofPreview scales an image down to maxDimension and marks it so the
+// preview cannot stand in for the final file. The configured watermark is
+// tiled across the image; without one, translucent diagonal bands are drawn.
+// The result is always a JPEG.
+func RenderProofPreview(data []byte, maxDimension, quality int, mark *Watermark) (*ImageFile, error) {
+	img, _, err := decodeImage(data)
+	if err != nil {
+		return nil, err
+	}
+	b := img.Bounds()
+	if maxDimension > 0 && (b.Dx() > maxDimension || b.Dy() > maxDimension) {
+		w, h := fitDimensions(b.Dx(), b.Dy(), maxDimension)
+		img = resizeImage(img, w, h)
+	}
+	if mark.Enabled() {
+		tiled := *mark
+		tiled.Position = "tile"
+		tiled.Opacity = max(tiled.Opacity, 0.3)
+		tiled.Apply(img)
+	} else {
+		markProofBands(img)
+	}
+	encoded, err := encodeImage(img, "jpeg", quality)
+	if err != nil {
+		return nil, err
+	}
+	b = img.Bounds()
+	return &ImageFile{Suffix: "-preview", Ext: ".jpg", ContentType: "image/jpeg", Width: b.Dx(), Height: b.Dy(), Data: encoded}, nil
+}
+
+func markProofBands(img *image.NRGBA) {
+	b := img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		off := img.PixOffset(0, y)
+		for x := 0; x < b.Dx(); x++ {
+			if (x+y)%proofBandSpacing < proofBandWidth {
+				for c := 0; c < 3; c++ {
+					v := int(img.Pix[off+c])
+					img.Pix[off+c] = uint8(v + (255-v)*proofBandAlpha/255)
+				}
+				img.Pix[off+3] = 255
+			}
+			off += 4
+		}
+	}
+}
@@ -156,7 +156,7 @@ export default function AdminLayout({
           return;
         }
         const res = await fetch(
-          `${process.env.NEXT_PUBLIC_API_URL}/api/admin/orders`,
+          `${process.env.NEXT_PUBLIC_API_URL}/api/admin/orders?all=true`,
           {
             headers: { Authorization: `Bearer ${storedToken}` },
           }
@@ -53,7 +53,7 @@ export default function OrdersPage() {
       return;
     }
     try {
-      const res = await fetch(`${process.env.NEXT_PUBLIC_API_URL}/api/admin/orders`, {
+      const res = await fetch(`${process.env.NEXT_PUBLIC_API_URL}/api/admin/orders?all=true`, {
         headers: { Authorization: `Bearer ${storedToken}` },
       });
       if (res.ok) {
@@ -206,7 +206,7 @@ export const deleteAdminCategory = async (id: number) => {
 };
 
 export const getAdminOrders = async () => {
-  const { data } = await api.get("/admin/orders");
+  const { data } = await api.get("/admin/orders", { params: { all: true } });
   return data;
 };
 